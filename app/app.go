@@ -1,14 +1,18 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/events"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/grpc_server"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/interceptors"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/mailer"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -21,36 +25,158 @@ type repository interface {
 
 type App struct {
 	logger *slog.Logger
-	users repository
-	sessions repository
+	users UserStorage
+	sessions SessionStorage
+	tokens TokenStorage
+	audit AuditStorage
+	purger *services.AccountPurger
+	sweeper *services.SessionSweeper
+	broker events.Broker
+	relay *services.OutboxRelay
+	jobs context.Context
+	stopJobs context.CancelFunc
 	gRPCserver *grpc.Server
 	port int
 }
 
-func New(logger *slog.Logger, userStorageConfig, sessionStorageConfig *database.Config, port int) (*App, error) {
-	userRepository, err := database.NewUserRepository(userStorageConfig)
+// Config is what New wires the service from.
+type Config struct {
+	UserStorage *database.Config
+	SessionStorage *database.Config
+	Hasher *services.HasherConfig
+	EmailNormalizer services.EmailNormalizer
+	AccountPolicy services.AccountPolicy
+	// Mail sends messages through SMTP. Without it New fails unless LogMail is set, which only
+	// logs them and is meant for local runs.
+	Mail *mailer.Config
+	LogMail bool
+	// Events publishes the outbox when set.
+	Events *events.Config
+	AuditHashChain bool
+	Port int
+}
+
+func New(logger *slog.Logger, conf *Config) (*App, error) {
+	hasher, err := services.NewPasswordHasher(conf.Hasher)
+	if err != nil {
+		return nil, err
+	}
+
+	userRepository, auditRepository, err := OpenUserStorage(conf.UserStorage, conf.AuditHashChain)
 	if err != nil {
 		return nil, err
 	}
 
-	sessionRepository, err := database.NewSessionRepository(sessionStorageConfig)
+	sessionRepository, tokenRepository, err := OpenSessionStorage(conf.SessionStorage)
 	if err != nil {
+		userRepository.Exit()
+		auditRepository.Exit()
 		return nil, err
 	}
 
+	// exitStorage closes the repositories opened above when the wiring fails later on
+	exitStorage := func() {
+		userRepository.Exit()
+		auditRepository.Exit()
+		sessionRepository.Exit()
+		tokenRepository.Exit()
+	}
+
+	if conf.Mail == nil && !conf.LogMail {
+		exitStorage()
+		return nil, errors.New("mail delivery is not configured")
+	}
+
+	var mailSender services.IMailer = mailer.NewLogMailer(logger)
+	if conf.Mail != nil {
+		if mailSender, err = mailer.NewSMTPMailer(conf.Mail); err != nil {
+			exitStorage()
+			return nil, err
+		}
+	}
+
+	// without a broker events wait in the outbox until one is configured
+	var (
+		broker events.Broker
+		relay *services.OutboxRelay
+	)
+	if conf.Events != nil {
+		if broker, err = events.New(conf.Events); err != nil {
+			exitStorage()
+			return nil, err
+		}
+		relay = services.NewOutboxRelay(userRepository, broker, services.DefaultRelayConfig(), logger)
+	}
+
 	authService := services.NewAuthService(
 		userRepository, 
+		userRepository,
+		userRepository,
+		userRepository,
 		sessionRepository,
 		sessionRepository,
 		sessionRepository,
+		tokenRepository,
+		hasher,
+		conf.EmailNormalizer,
+		conf.AccountPolicy,
+		auditRepository,
+		userRepository,
 		logger,
 	)
 
+	purger := services.NewAccountPurger(userRepository, conf.AccountPolicy, logger)
+	sweeper := services.NewSessionSweeper(sessionRepository, conf.AccountPolicy, logger)
+
 	registrarService := services.NewRegistrarService(
 		userRepository,
 		userRepository,
 		sessionRepository,
 		sessionRepository,
+		hasher,
+		conf.EmailNormalizer,
+		auditRepository,
+		userRepository,
+		logger,
+	)
+
+	profileService := services.NewProfileService(
+		userRepository,
+		userRepository,
+		logger,
+	)
+
+	emailChangeService := services.NewEmailChangeService(
+		userRepository,
+		userRepository,
+		sessionRepository,
+		tokenRepository,
+		mailSender,
+		hasher,
+		conf.EmailNormalizer,
+		auditRepository,
+		logger,
+	)
+
+	roleService := services.NewRoleService(userRepository, auditRepository, logger)
+
+	adminService := services.NewAdminService(
+		userRepository,
+		userRepository,
+		sessionRepository,
+		tokenRepository,
+		tokenRepository,
+		auditRepository,
+		userRepository,
+		logger,
+	)
+
+	auditService := services.NewAuditService(auditRepository)
+
+	exportService := services.NewExportService(
+		userRepository,
+		sessionRepository,
+		auditRepository,
 		logger,
 	)
 
@@ -68,21 +194,61 @@ func New(logger *slog.Logger, userStorageConfig, sessionStorageConfig *database.
 		"/profile.ProfileService/Refresh": {},
 	}
 
+	// protectedHandlers map each method to the permissions it requires,
+	// account methods only need a valid access token
+	protectedHandlers := map[string][]string {
+		"/profile.AccountService/GetProfile": nil,
+		"/profile.AccountService/UpdateProfile": nil,
+		"/profile.AccountService/RequestEmailChange": nil,
+		"/profile.AccountService/Reauthenticate": nil,
+		"/profile.AccountService/ExportData": nil,
+	}
+
+	for _, declared := range []map[string][]string{grpc_server.RolePermissions, grpc_server.AdminPermissions, grpc_server.AuditPermissions} {
+		for method, permissions := range declared {
+			protectedHandlers[method] = permissions
+		}
+	}
+
+	// sensitiveHandlers need a fresh password confirmation on top of the usual credentials,
+	// MFA disable belongs here as soon as MFA is introduced.
+	sensitiveHandlers := map[string]struct{} {
+		"/profile.ProfileService/Unregister": {},
+		"/profile.AccountService/RequestEmailChange": {},
+		"/profile.AccountService/ExportData": {},
+	}
+
 	chain := grpc.ChainUnaryInterceptor(
 		recovery.UnaryServerInterceptor(recoveryOpts...),
 		interceptors.RefreshTokenInterceptor(privateHandlers),
+		interceptors.AuthInterceptor(protectedHandlers),
+		interceptors.StepUpInterceptor(sensitiveHandlers, conf.AccountPolicy.StepUpMaxAge),
 		interceptors.SlogUnaryServerInterceptor(logger),
 	)
 
 	server := grpc.NewServer(chain)
 	grpc_server.RegisterServer(server, authService, registrarService)
+	grpc_server.RegisterAccountServer(server, profileService, emailChangeService, authService, authService, exportService)
+	grpc_server.RegisterRoleServer(server, roleService)
+	grpc_server.RegisterAdminServer(server, adminService)
+	grpc_server.RegisterAuditServer(server, auditService)
+
+	jobs, stopJobs := context.WithCancel(context.Background())
 
 	return &App{
 		logger: logger,
 		users: userRepository,
 		sessions: sessionRepository,
+		tokens: tokenRepository,
+		audit: auditRepository,
+		purger: purger,
+		sweeper: sweeper,
+		broker: broker,
+		relay: relay,
+		jobs: jobs,
+		stopJobs: stopJobs,
 		gRPCserver: server,
-		port: port,
+		port: conf.Port,
 	}, nil
 }
 
@@ -96,6 +262,15 @@ func (app *App) Run() error {
 
 	app.logger.Info("grpc server started!")
 
+	go app.purger.Run(app.jobs)
+	go app.sweeper.Run(app.jobs)
+
+	if app.relay != nil {
+		go app.relay.Run(app.jobs)
+	} else {
+		app.logger.Warn("event broker is not configured, domain events stay in the outbox")
+	}
+
 	if err := app.gRPCserver.Serve(l); err != nil {
         return fmt.Errorf("run failed: %w", err)
     }
@@ -104,6 +279,8 @@ func (app *App) Run() error {
 }
 
 func (app *App) Stop() {
+	app.stopJobs()
+
 	if app.users != nil {
 		app.users.Exit()
 	}
@@ -112,5 +289,17 @@ func (app *App) Stop() {
 		app.sessions.Exit()
 	}
 
+	if app.tokens != nil {
+		app.tokens.Exit()
+	}
+
+	if app.audit != nil {
+		app.audit.Exit()
+	}
+
+	if app.broker != nil {
+		app.broker.Close()
+	}
+
 	app.gRPCserver.GracefulStop()
 }
\ No newline at end of file
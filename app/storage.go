@@ -0,0 +1,115 @@
+package app
+
+import (
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+// UserStorage is everything the services need from the user database.
+type UserStorage interface {
+	repository
+	services.IUserProvider
+	services.IUserUpdater
+	services.IAccountRestorer
+	services.IRoleManager
+	services.IUserSaver
+	services.IUserRemover
+	services.IUserPurger
+	services.IProfileUpdater
+	services.IEmailUpdater
+	services.IUserAdministration
+	services.IUserDataProvider
+	services.IEventOutbox
+	services.IOutboxStore
+}
+
+// SessionStorage is everything the services need from the session store.
+type SessionStorage interface {
+	repository
+	services.ISessionProvider
+	services.ISessionSaver
+	services.ISessionRemover
+	services.ISessionUpdater
+	services.ISessionPruner
+}
+
+// TokenStorage also keeps the failed login counters and one-time passwords of the users.
+type TokenStorage interface {
+	repository
+	services.ITokenStorage
+	services.ILoginLockout
+	services.IOneTimePasswordRemover
+}
+
+type AuditStorage interface {
+	repository
+	services.IAuditLog
+	services.IAuditReader
+}
+
+// OpenUserStorage also opens the audit log, it lives in the same database. The admin
+// commands open the storage through it as well, so they support every driver the service does.
+func OpenUserStorage(conf *database.Config, auditHashChain bool) (UserStorage, AuditStorage, error) {
+	var (
+		users UserStorage
+		err error
+	)
+
+	switch conf.Driver {
+	case database.DriverMemory:
+		return database.NewMemoryUserRepository(), database.NewMemoryAuditRepository(), nil
+	case database.DriverSQLite:
+		users, err = database.NewSQLiteUserRepository(conf)
+	default:
+		users, err = database.NewUserRepository(conf)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	audit, err := database.NewAuditRepository(conf, auditHashChain)
+	if err != nil {
+		users.Exit()
+		return nil, nil, err
+	}
+
+	return users, audit, nil
+}
+
+// OpenSessionStorage also opens the confirmation token storage, it lives in the same store.
+func OpenSessionStorage(conf *database.Config) (SessionStorage, TokenStorage, error) {
+	switch conf.Driver {
+	case database.DriverMemory:
+		return database.NewMemorySessionRepository(), database.NewMemoryTokenRepository(), nil
+	case database.DriverPostgres:
+		pool, err := database.NewPgPool(conf)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return database.NewPgSessionRepository(pool), database.NewPgTokenRepository(pool), nil
+	}
+
+	var sessions SessionStorage
+	sessions, err := database.NewSessionRepository(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if conf.CacheSize > 0 {
+		bus, err := database.NewRedisInvalidationBus(conf)
+		if err != nil {
+			sessions.Exit()
+			return nil, nil, err
+		}
+		sessions = database.NewSessionCache(sessions, bus, conf.CacheSize, conf.CacheTTL)
+	}
+
+	tokens, err := database.NewTokenRepository(conf)
+	if err != nil {
+		sessions.Exit()
+		return nil, nil, err
+	}
+
+	return sessions, tokens, nil
+}
@@ -0,0 +1,55 @@
+// Command auditverify recomputes the hash chain of the audit log written with AUDIT_HASH_CHAIN=true.
+//
+//	auditverify [-config file] [-<setting> value ...]
+//
+// The storage is taken from the service config: the config file, the environment and the
+// same flags the service accepts, so a SQLite deployment is checked as well. Exit codes: 0 - the chain is intact, 1 - the chain is broken, 2 - the check itself failed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/config"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+func main() {
+	conf, rest, err := config.Load("auditverify", os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Println(err)
+		os.Exit(2)
+	}
+	if len(rest) > 0 {
+		log.Printf("unexpected arguments %v\n", rest)
+		os.Exit(2)
+	}
+
+	repository, err := database.NewAuditRepository(conf.UserDatabase(), false)
+	if err != nil {
+		log.Printf("can't connect to audit storage - %v\n", err)
+		os.Exit(2)
+	}
+	defer repository.Exit()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Minute)
+	defer cancel()
+
+	checked, err := repository.VerifyChain(ctx)
+	if err != nil {
+		log.Printf("verification stopped after %d events - %v\n", checked, err)
+		if errors.Is(err, database.ErrAuditChainBroken) {
+			os.Exit(1)
+		}
+		os.Exit(2)
+	}
+
+	log.Printf("audit chain is intact, %d events checked\n", checked)
+}
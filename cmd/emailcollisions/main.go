@@ -0,0 +1,169 @@
+// Command emailcollisions reports accounts whose emails collide in the unique index on
+// lower(email). Run it before applying the 000003_email_identity migration: the index can't
+// be created while such groups exist.
+//
+//	emailcollisions [-backfill] [-config file] [-<setting> value ...]
+//
+// The user storage is taken from the service config: the config file, the environment and the
+// same flags the service accepts. The migration is a Postgres one, so only Postgres is checked.
+//
+// Accounts registered before the normalisation may keep a Unicode domain, which the punycode
+// form sent at login no longer matches. The IDNA form is printed as a hint, -backfill stores it
+// for every account whose normalised address is not taken by another one.
+//
+// Exit codes: 0 - no collisions, 1 - collisions or backfill conflicts found, 2 - the check itself failed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/config"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+// account is a listed row with the form the service would store for it.
+type account struct {
+	domain.UserPublic
+	normalized string
+	invalid error
+}
+
+func (a account) describe() string {
+	line := fmt.Sprintf("%s  %q  registered %s", a.Id, a.Email, a.RegisterDate.Format(time.RFC3339))
+	switch {
+	case a.invalid != nil:
+		line += "  INVALID: " + a.invalid.Error()
+	case a.normalized != a.Email:
+		line += "  idna: " + a.normalized
+	}
+	return line
+}
+
+func main() {
+	flags := flag.NewFlagSet("emailcollisions", flag.ContinueOnError)
+	backfill := flags.Bool("backfill", false, "store the normalised form of emails with a Unicode domain")
+
+	conf, rest, err := config.LoadFlags(flags, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Println(err)
+		os.Exit(2)
+	}
+	if len(rest) > 0 {
+		log.Printf("unexpected arguments %v\n", rest)
+		os.Exit(2)
+	}
+
+	userConfig := conf.UserDatabase()
+	if userConfig.Driver != database.DriverPostgres {
+		log.Printf("users are stored by the %s driver, only postgres is checked\n", userConfig.Driver)
+		os.Exit(2)
+	}
+
+	repository, err := database.NewUserRepository(conf.AsAppRole(userConfig))
+	if err != nil {
+		log.Printf("can't connect to user storage - %v\n", err)
+		os.Exit(2)
+	}
+	defer repository.Exit()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Minute)
+	defer cancel()
+
+	users, err := repository.ListEmails(ctx)
+	if err != nil {
+		log.Printf("can't list users - %v\n", err)
+		os.Exit(2)
+	}
+
+	// the local part is kept as the service stores it, the index folds the case anyway
+	normalizer := services.EmailNormalizer{}
+
+	// groups are keyed by the index expression, Postgres lower() folds Unicode as well
+	groups := make(map[string][]account)
+	order := make([]string, 0)
+	invalid := 0
+
+	for _, user := range users {
+		row := account{UserPublic: user}
+		if row.normalized, row.invalid = normalizer.Normalize(user.Email); row.invalid != nil {
+			invalid++
+		}
+
+		key := strings.ToLower(user.Email)
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	collisions := 0
+	for _, key := range order {
+		group := groups[key]
+		if len(group) < 2 {
+			if group[0].invalid != nil {
+				fmt.Printf("INVALID  %s\n", group[0].describe())
+			}
+			continue
+		}
+
+		collisions++
+		fmt.Printf("COLLISION  %s\n", key)
+		for _, row := range group {
+			fmt.Printf("    %s\n", row.describe())
+		}
+	}
+
+	fmt.Printf("checked %d accounts: %d collision group(s), %d invalid address(es)\n", len(users), collisions, invalid)
+
+	conflicts := 0
+	if *backfill {
+		var updated int
+		updated, conflicts = backfillEmails(ctx, repository, groups, order)
+		fmt.Printf("backfill: %d address(es) normalised, %d conflict(s)\n", updated, conflicts)
+	}
+
+	if collisions > 0 || conflicts > 0 {
+		os.Exit(1)
+	}
+}
+
+// backfillEmails stores the normalised form of single-row groups unless it belongs to another
+// group already, colliding groups are left for the operator to resolve first.
+func backfillEmails(ctx context.Context, repository *database.UserRepository, groups map[string][]account, order []string) (updated, conflicts int) {
+	for _, key := range order {
+		group := groups[key]
+		row := group[0]
+		if len(group) > 1 || row.invalid != nil || row.normalized == row.Email {
+			continue
+		}
+
+		target := strings.ToLower(row.normalized)
+		if _, taken := groups[target]; taken && target != key {
+			conflicts++
+			fmt.Printf("BACKFILL CONFLICT  %s  %q is used by %s\n", row.Id, row.normalized, groups[target][0].Id)
+			continue
+		}
+
+		if err := repository.BackfillEmail(ctx, row.Id, row.Email, row.normalized); err != nil {
+			conflicts++
+			fmt.Printf("BACKFILL FAILED  %s  %v\n", row.Id, err)
+			continue
+		}
+
+		updated++
+	}
+
+	return updated, conflicts
+}
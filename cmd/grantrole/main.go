@@ -0,0 +1,90 @@
+// Command grantrole assigns or revokes a role straight in the database.
+// It is meant for bootstrapping the first admin, later changes go through RoleService.
+//
+//	grantrole -email <address> -role admin [-config file] [-<setting> value ...]
+//	grantrole -email <address> -role admin -revoke
+//
+// The user storage is taken from the service config: the config file, the environment
+// and the same flags the service accepts.
+//
+// Exit codes: 0 - done, 1 - user or role not found, 2 - the operation itself failed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/app"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/config"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+func main() {
+	flags := flag.NewFlagSet("grantrole", flag.ContinueOnError)
+	var (
+		email = flags.String("email", "", "email of the user")
+		role = flags.String("role", "", "role to assign or revoke")
+		revoke = flags.Bool("revoke", false, "revoke the role instead of assigning it")
+	)
+
+	conf, rest, err := config.LoadFlags(flags, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Println(err)
+		os.Exit(2)
+	}
+
+	if *email == "" || *role == "" || len(rest) > 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if conf.Users.Driver == database.DriverMemory {
+		log.Println("the memory user storage only exists inside the service process")
+		os.Exit(2)
+	}
+
+	repository, audit, err := app.OpenUserStorage(conf.AsAppRole(conf.UserDatabase()), false)
+	if err != nil {
+		log.Printf("can't connect to user storage - %v\n", err)
+		os.Exit(2)
+	}
+	defer repository.Exit()
+	defer audit.Exit()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	user, err := repository.Get(ctx, *email)
+	if err != nil {
+		log.Printf("can't find the user - %v\n", err)
+		exit(err)
+	}
+
+	if *revoke {
+		err = repository.RevokeRole(ctx, user.Id, *role)
+	} else {
+		err = repository.AssignRole(ctx, domain.RoleAssignment{UserId: user.Id, Role: *role, GrantedAt: time.Now()})
+	}
+
+	if err != nil {
+		log.Printf("role change failed - %v\n", err)
+		exit(err)
+	}
+
+	log.Printf("done, the change takes effect on the next login or token refresh of %s\n", user.Email)
+}
+
+func exit(err error) {
+	if errors.Is(err, database.ErrUserNotFound) || errors.Is(err, database.ErrRoleNotFound) || errors.Is(err, database.ErrRoleNotAssigned) {
+		os.Exit(1)
+	}
+	os.Exit(2)
+}
@@ -0,0 +1,71 @@
+// Command keymigrate moves the Redis keys written before the key schema was introduced
+// under the configured namespace (sessions.key_namespace) and marks the schema version.
+// Run it before the first start of a build with the key schema, the service refuses
+// to start on an outdated marker, or on keys of the former layout without a marker.
+//
+//	keymigrate [-batch 500] [-dry-run] [-config file] [-<setting> value ...]
+//
+// The session storage is taken from the service config: the config file, the environment
+// and the same flags the service accepts.
+//
+// Exit codes: 0 - every key has been moved, 1 - some keys were left because of conflicts,
+// 2 - the migration failed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/config"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+func main() {
+	flags := flag.NewFlagSet("keymigrate", flag.ContinueOnError)
+	batch := flags.Int64("batch", 500, "keys requested per SCAN call")
+	dryRun := flags.Bool("dry-run", false, "only count the keys to move")
+
+	conf, rest, err := config.LoadFlags(flags, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Println(err)
+		os.Exit(2)
+	}
+	if len(rest) > 0 {
+		log.Printf("unexpected arguments %v\n", rest)
+		os.Exit(2)
+	}
+
+	sessionConfig := conf.SessionDatabase()
+	if sessionConfig.Driver != database.DriverRedis {
+		log.Printf("sessions are stored by the %s driver, only redis keys are migrated\n", sessionConfig.Driver)
+		os.Exit(2)
+	}
+
+	migrator, err := database.NewKeyMigrator(sessionConfig, *batch)
+	if err != nil {
+		log.Printf("can't connect to session storage - %v\n", err)
+		os.Exit(2)
+	}
+	defer migrator.Exit()
+
+	report, err := migrator.Migrate(context.Background(), *dryRun)
+	log.Printf(
+		"scanned %d, sessions %d, user indexes %d, tokens %d, conflicts %d, skipped %d\n",
+		report.Scanned, report.Sessions, report.Indexes, report.Tokens, report.Conflicts, report.Skipped,
+	)
+
+	if err != nil {
+		log.Printf("key migration failed - %v\n", err)
+		os.Exit(2)
+	}
+
+	if report.Conflicts > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,141 @@
+// Command userexport answers a data subject access request from the admin side:
+// it writes everything the service keeps about one user as JSON or as a zip archive.
+//
+//	userexport -id <uuid> [-format json|zip] [-out file] [-config file] [-<setting> value ...]
+//	userexport -email <address> [-format json|zip] [-out file]
+//
+// The storages are taken from the service config: the config file, the environment and the
+// same flags the service accepts, so the sessions are read from the namespace the service
+// writes them to. Without -out the document is written to stdout. Exit codes: 0 - exported, 1 - user not found,
+// 2 - the export itself failed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/app"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/config"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+func main() {
+	flags := flag.NewFlagSet("userexport", flag.ContinueOnError)
+	var (
+		id = flags.String("id", "", "id of the user to export")
+		email = flags.String("email", "", "email of the user to export, used when -id is not set")
+		format = flags.String("format", services.ExportFormatJSON, "export format: json or zip")
+		out = flags.String("out", "", "output file, stdout by default")
+	)
+
+	conf, rest, err := config.LoadFlags(flags, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Println(err)
+		os.Exit(2)
+	}
+
+	if (*id == "" && *email == "") || len(rest) > 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if conf.Users.Driver == database.DriverMemory || conf.Sessions.Driver == database.DriverMemory {
+		log.Println("the memory storage only exists inside the service process")
+		os.Exit(2)
+	}
+
+	users, audit, err := app.OpenUserStorage(conf.AsAppRole(conf.UserDatabase()), false)
+	if err != nil {
+		log.Printf("can't connect to user storage - %v\n", err)
+		os.Exit(2)
+	}
+	defer users.Exit()
+	defer audit.Exit()
+
+	// postgres sessions are read as the application role, redis ones with the redis password
+	sessionConfig := conf.SessionDatabase()
+	if sessionConfig.Driver == database.DriverPostgres {
+		sessionConfig = conf.AsAppRole(sessionConfig)
+	}
+
+	sessions, tokens, err := app.OpenSessionStorage(sessionConfig)
+	if err != nil {
+		log.Printf("can't connect to session storage - %v\n", err)
+		os.Exit(2)
+	}
+	defer sessions.Exit()
+	defer tokens.Exit()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	userId, err := resolveUser(ctx, users, *id, *email)
+	if err != nil {
+		log.Printf("can't find the user - %v\n", err)
+		exit(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	export, err := services.NewExportService(users, sessions, audit, logger).Export(ctx, userId)
+	if err != nil {
+		log.Printf("export failed - %v\n", err)
+		exit(err)
+	}
+
+	data, err := services.EncodeExport(export, *format)
+	if err != nil {
+		log.Printf("export encoding failed - %v\n", err)
+		os.Exit(2)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.OpenFile(*out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			log.Printf("can't create output file - %v\n", err)
+			os.Exit(2)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if _, err = w.Write(data); err != nil {
+		log.Printf("can't write the export - %v\n", err)
+		os.Exit(2)
+	}
+}
+
+// resolveUser accepts an id as is and looks an email up among active and pending deletion accounts.
+func resolveUser(ctx context.Context, users app.UserStorage, id, email string) (uuid.UUID, error) {
+	if id != "" {
+		return uuid.Parse(id)
+	}
+
+	user, err := users.Get(ctx, email)
+	if errors.Is(err, database.ErrUserNotFound) {
+		user, err = users.GetDeleted(ctx, email)
+	}
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return user.Id, nil
+}
+
+func exit(err error) {
+	if errors.Is(err, database.ErrUserNotFound) {
+		os.Exit(1)
+	}
+	os.Exit(2)
+}
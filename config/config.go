@@ -0,0 +1,425 @@
+// Package config holds the settings of the service. Load builds them in layers: the defaults,
+// the YAML file, the environment and the command-line flags, each overriding the ones before.
+//
+// Every setting is named by its struct tags. The yaml tag is the key in the file, the yaml
+// tags on the way to a setting joined with dots are its flag (e.g. -users.connection.tls_mode)
+// and the env tags on the way joined together are its variable (e.g. PG_TLS_MODE).
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/events"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/mailer"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+const (
+	// ModeLocal logs text with debug messages and source positions.
+	ModeLocal = "local"
+	// ModeProduction logs JSON from the info level on.
+	ModeProduction = "production"
+)
+
+type Config struct {
+	Mode string `yaml:"mode" env:"MODE"`
+	// LogFile is appended to instead of writing the log to stdout.
+	LogFile string `yaml:"log_file" env:"LOG_FILE"`
+	Port int `yaml:"port" env:"PORT"`
+	// MetricsAddr serves the expvar metrics at /debug/vars when set.
+	MetricsAddr string `yaml:"metrics_addr" env:"METRICS_ADDR"`
+	// JWTSecret signs the access tokens, it may only be empty in the local mode.
+	JWTSecret string `yaml:"jwt_secret" env:"SECRET_KEY" secret:"true"`
+	AuditHashChain bool `yaml:"audit_hash_chain" env:"AUDIT_HASH_CHAIN"`
+	EmailLowercaseLocal bool `yaml:"email_lowercase_local" env:"EMAIL_LOWERCASE_LOCAL"`
+
+	Users UserStorage `yaml:"users"`
+	Sessions SessionStorage `yaml:"sessions"`
+	PasswordHash PasswordHash `yaml:"password_hash"`
+	Policy Policy `yaml:"policy"`
+	Mail Mail `yaml:"mail"`
+	Events Events `yaml:"events"`
+}
+
+// Connection holds the pool, timeout and TLS settings of a store, zero keeps the driver default.
+type Connection struct {
+	MaxOpenConns int `yaml:"max_open_conns" env:"MAX_OPEN_CONNS"`
+	MaxIdleConns int `yaml:"max_idle_conns" env:"MAX_IDLE_CONNS"`
+	MaxRetries int `yaml:"max_retries" env:"MAX_RETRIES"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"CONN_MAX_IDLE_TIME"`
+	DialTimeout time.Duration `yaml:"dial_timeout" env:"DIAL_TIMEOUT"`
+	ReadTimeout time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT"`
+	StatementTimeout time.Duration `yaml:"statement_timeout" env:"STATEMENT_TIMEOUT"`
+	TLSMode string `yaml:"tls_mode" env:"TLS_MODE"`
+	TLSCAFile string `yaml:"tls_ca_file" env:"TLS_CA_FILE"`
+	TLSCertFile string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+}
+
+type UserStorage struct {
+	// Driver is postgres, sqlite3 or memory.
+	Driver string `yaml:"driver" env:"USER_STORAGE"`
+	Addr string `yaml:"addr" env:"PG_ADDR"`
+	User string `yaml:"user" env:"PG_USER"`
+	Password string `yaml:"password" env:"PG_PASSWORD" secret:"true"`
+	Name string `yaml:"name" env:"PG_NAME"`
+	SQLitePath string `yaml:"sqlite_path" env:"SQLITE_PATH"`
+	Connection Connection `yaml:"connection" env:"PG_"`
+	Bootstrap Bootstrap `yaml:"bootstrap"`
+}
+
+// Bootstrap says how the Postgres databases are prepared on start, see database.Bootstrapper.
+type Bootstrap struct {
+	// Enabled is turned off where the database is provisioned beforehand.
+	Enabled bool `yaml:"enabled" env:"DB_BOOTSTRAP"`
+	CreateDatabase bool `yaml:"create_database" env:"DB_CREATE"`
+	Migrate bool `yaml:"migrate" env:"DB_MIGRATE"`
+	// AppRole is what the service connects as once the bootstrap is done, UserStorage.User
+	// is only needed for the bootstrap then.
+	AppRole string `yaml:"app_role" env:"PG_APP_ROLE"`
+	AppPassword string `yaml:"app_password" env:"PG_APP_PASSWORD" secret:"true"`
+}
+
+type SessionStorage struct {
+	// Driver is redis, postgres or memory. postgres keeps the sessions on the user storage server.
+	Driver string `yaml:"driver" env:"SESSION_STORAGE"`
+	Addr string `yaml:"addr" env:"REDIS_ADDR"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB string `yaml:"db" env:"REDIS_DB_NUM"`
+	KeyNamespace string `yaml:"key_namespace" env:"REDIS_KEY_NAMESPACE"`
+	SentinelMaster string `yaml:"sentinel_master" env:"REDIS_SENTINEL_MASTER"`
+	Cluster bool `yaml:"cluster" env:"REDIS_CLUSTER"`
+	CacheSize int `yaml:"cache_size" env:"SESSION_CACHE_SIZE"`
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"SESSION_CACHE_TTL"`
+	// PostgresName is the session database of the postgres driver, UserStorage.Name when empty.
+	PostgresName string `yaml:"postgres_name" env:"PG_SESSION_NAME"`
+	Connection Connection `yaml:"connection" env:"REDIS_"`
+}
+
+type PasswordHash struct {
+	Algorithm string `yaml:"algorithm" env:"PASSWORD_HASH_ALGO"`
+	BcryptCost int `yaml:"bcrypt_cost" env:"BCRYPT_COST"`
+	Argon2MemoryKB uint32 `yaml:"argon2_memory_kb" env:"ARGON2_MEMORY_KB"`
+	Argon2Iterations uint32 `yaml:"argon2_iterations" env:"ARGON2_ITERATIONS"`
+	Argon2Parallelism uint8 `yaml:"argon2_parallelism" env:"ARGON2_PARALLELISM"`
+}
+
+type Policy struct {
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" env:"DELETION_GRACE_PERIOD"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"PURGE_INTERVAL"`
+	StepUpMaxAge time.Duration `yaml:"step_up_max_age" env:"STEP_UP_MAX_AGE"`
+	ElevatedTokenTTL time.Duration `yaml:"elevated_token_ttl" env:"ELEVATED_TOKEN_TTL"`
+	SessionSweepInterval time.Duration `yaml:"session_sweep_interval" env:"SESSION_SWEEP_INTERVAL"`
+	MaxFailedLogins int `yaml:"max_failed_logins" env:"MAX_FAILED_LOGINS"`
+	LockoutDuration time.Duration `yaml:"lockout_duration" env:"LOCKOUT_DURATION"`
+}
+
+// Mail delivers through SMTP. Addr may only be empty in the local mode, where the messages
+// are logged instead.
+type Mail struct {
+	Addr string `yaml:"addr" env:"SMTP_ADDR"`
+	User string `yaml:"user" env:"SMTP_USER"`
+	Password string `yaml:"password" env:"SMTP_PASSWORD" secret:"true"`
+	From string `yaml:"from" env:"SMTP_FROM"`
+}
+
+// Events publishes the outbox when Driver is set.
+type Events struct {
+	Driver string `yaml:"driver" env:"EVENT_BROKER"`
+	Addr string `yaml:"addr" env:"EVENT_BROKER_ADDR"`
+	User string `yaml:"user" env:"EVENT_BROKER_USER"`
+	Password string `yaml:"password" env:"EVENT_BROKER_PASSWORD" secret:"true"`
+	Topic string `yaml:"topic" env:"EVENT_TOPIC"`
+	JetStream bool `yaml:"jetstream" env:"NATS_JETSTREAM"`
+	Timeout time.Duration `yaml:"timeout" env:"EVENT_BROKER_TIMEOUT"`
+}
+
+func Default() *Config {
+	hasher := services.DefaultHasherConfig()
+	policy := services.DefaultAccountPolicy()
+
+	return &Config{
+		Mode: ModeProduction,
+		Port: 4444,
+		Users: UserStorage{
+			Driver: database.DriverPostgres,
+			Bootstrap: Bootstrap{
+				Enabled: true,
+				CreateDatabase: true,
+				Migrate: true,
+			},
+		},
+		Sessions: SessionStorage{
+			Driver: database.DriverRedis,
+		},
+		PasswordHash: PasswordHash{
+			Algorithm: hasher.Algorithm,
+			BcryptCost: hasher.BcryptCost,
+			Argon2MemoryKB: hasher.Argon2.Memory,
+			Argon2Iterations: hasher.Argon2.Iterations,
+			Argon2Parallelism: hasher.Argon2.Parallelism,
+		},
+		Policy: Policy{
+			DeletionGracePeriod: policy.DeletionGracePeriod,
+			PurgeInterval: policy.PurgeInterval,
+			StepUpMaxAge: policy.StepUpMaxAge,
+			ElevatedTokenTTL: policy.ElevatedTokenTTL,
+			SessionSweepInterval: policy.SessionSweepInterval,
+			MaxFailedLogins: policy.MaxFailedLogins,
+			LockoutDuration: policy.LockoutDuration,
+		},
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(slices.Contains([]string{ModeLocal, ModeProduction}, c.Mode), "mode: %q is neither %s nor %s", c.Mode, ModeLocal, ModeProduction)
+	check(c.Port > 0 && c.Port < 65536, "port: %d is out of range", c.Port)
+	check(c.JWTSecret != "" || c.Mode == ModeLocal, "jwt_secret: must be set outside the %s mode", ModeLocal)
+
+	switch c.Users.Driver {
+	case database.DriverPostgres:
+		check(c.Users.Addr != "", "users.addr: must be set for postgres")
+		check(c.Users.Name != "", "users.name: must be set for postgres")
+		check(c.Users.Bootstrap.AppRole == "" || c.Users.Bootstrap.AppPassword != "", "users.bootstrap.app_password: must be set with app_role")
+	case database.DriverSQLite:
+		check(c.Users.SQLitePath != "", "users.sqlite_path: must be set for sqlite3")
+	case database.DriverMemory:
+	default:
+		check(false, "users.driver: unknown driver %q", c.Users.Driver)
+	}
+	errs = append(errs, c.Users.Connection.validate("users.connection")...)
+
+	switch c.Sessions.Driver {
+	case database.DriverRedis:
+		check(c.Sessions.Addr != "", "sessions.addr: must be set for redis")
+		check(!c.Sessions.Cluster || c.Sessions.SentinelMaster == "", "sessions.cluster: can't be combined with sentinel_master")
+	case database.DriverPostgres:
+		check(c.Users.Addr != "", "users.addr: must be set for postgres sessions")
+	case database.DriverMemory:
+	default:
+		check(false, "sessions.driver: unknown driver %q", c.Sessions.Driver)
+	}
+	check(c.Sessions.CacheSize >= 0, "sessions.cache_size: can't be negative")
+	check(c.Sessions.CacheTTL >= 0, "sessions.cache_ttl: can't be negative")
+	errs = append(errs, c.Sessions.Connection.validate("sessions.connection")...)
+
+	if _, err := services.NewPasswordHasher(c.Hasher()); err != nil {
+		check(false, "password_hash: %v", err)
+	}
+
+	for name, value := range map[string]time.Duration{
+		"deletion_grace_period": c.Policy.DeletionGracePeriod,
+		"purge_interval": c.Policy.PurgeInterval,
+		"step_up_max_age": c.Policy.StepUpMaxAge,
+		"elevated_token_ttl": c.Policy.ElevatedTokenTTL,
+		"session_sweep_interval": c.Policy.SessionSweepInterval,
+		"lockout_duration": c.Policy.LockoutDuration,
+	} {
+		check(value > 0, "policy.%s: must be positive", name)
+	}
+	check(c.Policy.MaxFailedLogins >= 0, "policy.max_failed_logins: can't be negative")
+
+	check(c.Mail.Addr != "" || c.Mode == ModeLocal, "mail.addr: must be set outside the %s mode", ModeLocal)
+	if c.Mail.Addr != "" {
+		check(c.Mail.From != "", "mail.from: must be set with mail.addr")
+	}
+
+	if c.Events.Driver != "" {
+		check(slices.Contains([]string{events.DriverNATS, events.DriverKafka, events.DriverMemory}, c.Events.Driver), "events.driver: unknown driver %q", c.Events.Driver)
+		check(c.Events.Driver == events.DriverMemory || c.Events.Addr != "", "events.addr: must be set for %s", c.Events.Driver)
+		check(c.Events.Timeout >= 0, "events.timeout: can't be negative")
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Connection) validate(path string) []error {
+	var errs []error
+
+	tlsModes := []string{"", database.TLSDisable, database.TLSRequire, database.TLSVerifyCA, database.TLSVerifyFull}
+	if !slices.Contains(tlsModes, c.TLSMode) {
+		errs = append(errs, fmt.Errorf("%s.tls_mode: %w: %q", path, database.ErrInvalidTLSMode, c.TLSMode))
+	}
+	if c.TLSMode == database.TLSVerifyCA && c.TLSCAFile == "" {
+		errs = append(errs, fmt.Errorf("%s.tls_ca_file: must be set for %s", path, database.TLSVerifyCA))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("%s.tls_cert_file: the certificate and the key are set together", path))
+	}
+
+	for name, value := range map[string]int64{
+		"max_open_conns": int64(c.MaxOpenConns),
+		"max_idle_conns": int64(c.MaxIdleConns),
+		"conn_max_lifetime": int64(c.ConnMaxLifetime),
+		"conn_max_idle_time": int64(c.ConnMaxIdleTime),
+		"dial_timeout": int64(c.DialTimeout),
+		"read_timeout": int64(c.ReadTimeout),
+		"write_timeout": int64(c.WriteTimeout),
+		"statement_timeout": int64(c.StatementTimeout),
+	} {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s.%s: can't be negative", path, name))
+		}
+	}
+
+	return errs
+}
+
+func (c Connection) apply(conf *database.Config) {
+	conf.MaxOpenConns = c.MaxOpenConns
+	conf.MaxIdleConns = c.MaxIdleConns
+	conf.MaxRetries = c.MaxRetries
+	conf.ConnMaxLifetime = c.ConnMaxLifetime
+	conf.ConnMaxIdleTime = c.ConnMaxIdleTime
+	conf.DialTimeout = c.DialTimeout
+	conf.ReadTimeout = c.ReadTimeout
+	conf.WriteTimeout = c.WriteTimeout
+	conf.StatementTimeout = c.StatementTimeout
+	conf.TLSMode = c.TLSMode
+	conf.TLSCAFile = c.TLSCAFile
+	conf.TLSCertFile = c.TLSCertFile
+	conf.TLSKeyFile = c.TLSKeyFile
+}
+
+// UserDatabase is the user storage as Users.User, the role the bootstrap and migrations run as.
+func (c *Config) UserDatabase() *database.Config {
+	conf := &database.Config{
+		Driver: c.Users.Driver,
+		Addr: c.Users.Addr,
+		User: c.Users.User,
+		Password: c.Users.Password,
+		DBName: c.Users.Name,
+	}
+	if conf.Driver == database.DriverSQLite {
+		conf.DBName = c.Users.SQLitePath
+	}
+	c.Users.Connection.apply(conf)
+
+	return conf
+}
+
+// SessionDatabase is the session storage, as Users.User for the postgres driver.
+func (c *Config) SessionDatabase() *database.Config {
+	if c.Sessions.Driver == database.DriverPostgres {
+		name := c.Sessions.PostgresName
+		if name == "" {
+			name = c.Users.Name
+		}
+
+		conf := &database.Config{
+			Driver: database.DriverPostgres,
+			Addr: c.Users.Addr,
+			User: c.Users.User,
+			Password: c.Users.Password,
+			DBName: name,
+		}
+		c.Users.Connection.apply(conf)
+
+		return conf
+	}
+
+	conf := &database.Config{
+		Driver: c.Sessions.Driver,
+		Addr: c.Sessions.Addr,
+		Password: c.Sessions.Password,
+		DBName: c.Sessions.DB,
+		KeyNamespace: c.Sessions.KeyNamespace,
+		SentinelMaster: c.Sessions.SentinelMaster,
+		Cluster: c.Sessions.Cluster,
+		CacheSize: c.Sessions.CacheSize,
+		CacheTTL: c.Sessions.CacheTTL,
+	}
+	c.Sessions.Connection.apply(conf)
+
+	return conf
+}
+
+func (c *Config) BootstrapConfig() database.BootstrapConfig {
+	return database.BootstrapConfig{
+		CreateDatabase: c.Users.Bootstrap.CreateDatabase,
+		Migrate: c.Users.Bootstrap.Migrate,
+		AppRole: c.Users.Bootstrap.AppRole,
+		AppRolePassword: c.Users.Bootstrap.AppPassword,
+	}
+}
+
+// AsAppRole returns a copy of a Postgres config connecting as Bootstrap.AppRole, when it is set.
+func (c *Config) AsAppRole(conf *database.Config) *database.Config {
+	result := *conf
+	if c.Users.Bootstrap.AppRole != "" {
+		result.User, result.Password = c.Users.Bootstrap.AppRole, c.Users.Bootstrap.AppPassword
+	}
+	return &result
+}
+
+func (c *Config) Hasher() *services.HasherConfig {
+	conf := services.DefaultHasherConfig()
+	conf.Algorithm = c.PasswordHash.Algorithm
+	conf.BcryptCost = c.PasswordHash.BcryptCost
+	conf.Argon2.Memory = c.PasswordHash.Argon2MemoryKB
+	conf.Argon2.Iterations = c.PasswordHash.Argon2Iterations
+	conf.Argon2.Parallelism = c.PasswordHash.Argon2Parallelism
+
+	return conf
+}
+
+func (c *Config) AccountPolicy() services.AccountPolicy {
+	return services.AccountPolicy{
+		DeletionGracePeriod: c.Policy.DeletionGracePeriod,
+		PurgeInterval: c.Policy.PurgeInterval,
+		StepUpMaxAge: c.Policy.StepUpMaxAge,
+		ElevatedTokenTTL: c.Policy.ElevatedTokenTTL,
+		SessionSweepInterval: c.Policy.SessionSweepInterval,
+		MaxFailedLogins: c.Policy.MaxFailedLogins,
+		LockoutDuration: c.Policy.LockoutDuration,
+	}
+}
+
+func (c *Config) EmailNormalizer() services.EmailNormalizer {
+	return services.EmailNormalizer{LowercaseLocal: c.EmailLowercaseLocal}
+}
+
+// MailConfig is nil when no SMTP server is set.
+func (c *Config) MailConfig() *mailer.Config {
+	if c.Mail.Addr == "" {
+		return nil
+	}
+
+	return &mailer.Config{
+		Addr: c.Mail.Addr,
+		Username: c.Mail.User,
+		Password: c.Mail.Password,
+		From: c.Mail.From,
+	}
+}
+
+// EventsConfig is nil when no broker is set.
+func (c *Config) EventsConfig() *events.Config {
+	if c.Events.Driver == "" {
+		return nil
+	}
+
+	return &events.Config{
+		Driver: c.Events.Driver,
+		Addr: c.Events.Addr,
+		Username: c.Events.User,
+		Password: c.Events.Password,
+		Topic: c.Events.Topic,
+		JetStream: c.Events.JetStream,
+		Timeout: c.Events.Timeout,
+	}
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "<redacted>"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setting is a leaf of Config with the names it goes by in every layer.
+type setting struct {
+	value reflect.Value
+	path string
+	env string
+	secret bool
+}
+
+// settings lists the leaves of the struct v points to.
+func settings(v reflect.Value, path, env string) []setting {
+	var result []setting
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("yaml")
+		if path != "" {
+			name = path + "." + name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			result = append(result, settings(v.Field(i), name, env + field.Tag.Get("env"))...)
+			continue
+		}
+
+		result = append(result, setting{
+			value: v.Field(i),
+			path: name,
+			env: env + field.Tag.Get("env"),
+			secret: field.Tag.Get("secret") == "true",
+		})
+	}
+
+	return result
+}
+
+// set parses raw into the setting the same way for the environment and the flags.
+func (s setting) set(raw string) error {
+	var err error
+
+	switch {
+	case s.value.Type() == durationType:
+		var value time.Duration
+		if value, err = time.ParseDuration(raw); err == nil {
+			s.value.SetInt(int64(value))
+		}
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(raw)
+	case s.value.Kind() == reflect.Bool:
+		var value bool
+		if value, err = strconv.ParseBool(raw); err == nil {
+			s.value.SetBool(value)
+		}
+	case s.value.CanInt():
+		var value int64
+		if value, err = strconv.ParseInt(raw, 10, s.value.Type().Bits()); err == nil {
+			s.value.SetInt(value)
+		}
+	case s.value.CanUint():
+		var value uint64
+		if value, err = strconv.ParseUint(raw, 10, s.value.Type().Bits()); err == nil {
+			s.value.SetUint(value)
+		}
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+
+	return err
+}
+
+// flagValue collects a flag, the flags are applied after the file and the environment.
+type flagValue struct {
+	raw *string
+	isBool bool
+}
+
+func (f flagValue) String() string {
+	if f.raw == nil {
+		return ""
+	}
+	return *f.raw
+}
+
+func (f flagValue) Set(raw string) error {
+	*f.raw = raw
+	return nil
+}
+
+func (f flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// Load builds the config from the defaults, the YAML file, the environment and the flags
+// in args, then validates it. The arguments after the flags are returned. The file is named
+// by the -config flag or CONFIG_FILE, a .env file in the working directory fills in the
+// variables missing from the environment.
+func Load(name string, args []string) (*Config, []string, error) {
+	return LoadFlags(flag.NewFlagSet(name, flag.ContinueOnError), args)
+}
+
+// LoadFlags is Load for a command with flags of its own. They are defined on flags beforehand
+// and parsed along with the settings, so their names must not clash with a setting.
+func LoadFlags(flags *flag.FlagSet, args []string) (*Config, []string, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf(".env loading failed: %w", err)
+	}
+
+	conf := Default()
+	leaves := settings(reflect.ValueOf(conf).Elem(), "", "")
+
+	file := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML config file, CONFIG_FILE")
+	raw := make(map[string]*string, len(leaves))
+	for _, leaf := range leaves {
+		raw[leaf.path] = new(string)
+		flags.Var(flagValue{raw: raw[leaf.path], isBool: leaf.value.Kind() == reflect.Bool}, leaf.path, leaf.env)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	if *file != "" {
+		if err := conf.loadFile(*file); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, leaf := range leaves {
+		// an empty variable counts as unset, as in .env files listing every variable
+		if value := os.Getenv(leaf.env); value != "" {
+			if err := leaf.set(value); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s: %w", leaf.env, err)
+			}
+		}
+	}
+
+	for _, leaf := range leaves {
+		if !set[leaf.path] {
+			continue
+		}
+		if err := leaf.set(*raw[leaf.path]); err != nil {
+			return nil, nil, fmt.Errorf("invalid -%s: %w", leaf.path, err)
+		}
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	return conf, flags.Args(), nil
+}
+
+// loadFile overrides the settings present in the file, unknown keys are an error.
+func (c *Config) loadFile(name string) error {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("config file reading failed: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s parsing failed: %w", name, err)
+	}
+
+	return nil
+}
+
+// Print writes the config as YAML, with the secrets that are set redacted. The output is a
+// valid config file once the secrets are filled back in.
+func (c *Config) Print(w io.Writer) error {
+	printed := *c
+	for _, leaf := range settings(reflect.ValueOf(&printed).Elem(), "", "") {
+		if leaf.secret && leaf.value.String() != "" {
+			leaf.value.SetString(redacted)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&printed); err != nil {
+		return fmt.Errorf("config printing failed: %w", err)
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers returns up to search.Limit users matching all given prefixes, accounts pending
+// deletion included. Pages are ordered by (registerDate, id) and continue after search.After.
+func (r *UserRepository) SearchUsers(ctx context.Context, search domain.UserSearch) ([]domain.User, error) {
+	conditions := make([]string, 0, 4)
+	args := make([]any, 0, 6)
+
+	addPrefix := func(column, prefix string) {
+		if prefix == "" {
+			return
+		}
+		args = append(args, likeEscaper.Replace(prefix) + "%")
+		conditions = append(conditions, fmt.Sprintf("%s LIKE $%d", column, len(args)))
+	}
+
+	addPrefix("lower(email)", strings.ToLower(search.EmailPrefix))
+	addPrefix("lower(fullName)", strings.ToLower(search.FullNamePrefix))
+	addPrefix("phone", search.PhonePrefix)
+
+	if search.After != nil {
+		args = append(args, search.After.RegisterDate, search.After.Id)
+		conditions = append(conditions, fmt.Sprintf("(registerDate, id) > ($%d, $%d)", len(args) - 1, len(args)))
+	}
+
+	query := "SELECT " + userColumns + " FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, search.Limit)
+	query += fmt.Sprintf(" ORDER BY registerDate, id LIMIT $%d;", len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("user search operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.User, 0, search.Limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("user search operation failed: %w", err)
+		}
+		result = append(result, *user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("user search operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// SetStatus changes the account status and stamps statusChangedAt.
+// suspendedUntil is only kept for suspensions.
+func (r *UserRepository) SetStatus(ctx context.Context, userId uuid.UUID, change domain.StatusChange) error {
+	query := "UPDATE users SET status=$1, statusReason=$2, suspendedUntil=$3, statusChangedAt=now() WHERE id=$4;"
+
+	until := sql.NullTime{Time: change.Until, Valid: change.Status == domain.UserStatusSuspended && !change.Until.IsZero()}
+
+	result, err := r.pool.Exec(ctx, query, change.Status, change.Reason, until, userId)
+	if err != nil {
+		return fmt.Errorf("user status update operation failed: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+var ErrAuditChainBroken = errors.New("audit hash chain is broken")
+
+// auditChainLock is the advisory lock key serialising writers of the hash chain.
+const auditChainLock = 0x61756469
+
+const auditColumns = "id, occurredAt, action, actor, target, ipAddress, userAgent, outcome, reason, metadata, prevHash, hash"
+
+// AuditRepository stores audit events. In the hash-chained mode every row also keeps
+// the hash of its predecessor, so deleting or editing rows can be detected by VerifyChain.
+//
+// The queries are shared by Postgres and SQLite, their parameters are numbered in order of appearance.
+type AuditRepository struct {
+	db *sql.DB
+	hashChain bool
+	// sqlite transactions take the write lock as they begin, so chain writers need no advisory lock
+	sqlite bool
+}
+
+func NewAuditRepository(conf *Config, hashChain bool) (*AuditRepository, error) {
+	if conf.Driver == DriverSQLite {
+		db, err := openSQLite(conf)
+		if err != nil {
+			return nil, err
+		}
+		return &AuditRepository{db: db, hashChain: hashChain, sqlite: true}, nil
+	}
+
+	db, err := sql.Open(pgxDriverName, conf.GetPgConnString(false))
+	if err != nil {
+		fmt.Println("invalid connection arguments:", err)
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		fmt.Println("database connection failed:", err)
+		return nil, err
+	}
+
+	configurePool(db, conf)
+
+	return &AuditRepository{
+		db: db,
+		hashChain: hashChain,
+	}, nil
+}
+
+func (r *AuditRepository) Record(ctx context.Context, event domain.AuditEvent) error {
+	// Postgres keeps microseconds, hashes must be computed over what is read back
+	event.OccurredAt = event.OccurredAt.UTC().Truncate(time.Microsecond)
+	if event.Metadata == nil {
+		event.Metadata = map[string]string{}
+	}
+
+	metadata, err := json.Marshal(event.Metadata)
+	if err != nil {
+		return fmt.Errorf("audit metadata encoding failed: %w", err)
+	}
+
+	if !r.hashChain {
+		_, err = r.db.ExecContext(ctx, insertAuditQuery, auditArgs(event, metadata, nil, nil)...)
+		if err != nil {
+			return fmt.Errorf("audit record operation failed: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("audit record operation failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	if !r.sqlite {
+		if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1);", auditChainLock); err != nil {
+			return fmt.Errorf("audit chain lock failed: %w", err)
+		}
+	}
+
+	var prevHash []byte
+	err = tx.QueryRowContext(ctx, "SELECT hash FROM audit_events WHERE hash IS NOT NULL ORDER BY id DESC LIMIT 1;").Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("audit chain head retrieve failed: %w", err)
+	}
+
+	hash, err := chainHash(prevHash, event)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, insertAuditQuery, auditArgs(event, metadata, prevHash, hash)...); err != nil {
+		return fmt.Errorf("audit record operation failed: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("audit record operation failed: %w", err)
+	}
+
+	return nil
+}
+
+const insertAuditQuery = `INSERT INTO audit_events(occurredAt, action, actor, target, ipAddress, userAgent, outcome, reason, metadata, prevHash, hash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`
+
+func auditArgs(event domain.AuditEvent, metadata, prevHash, hash []byte) []any {
+	return []any{
+		event.OccurredAt, event.Action, nullUUID(event.Actor), nullUUID(event.Target),
+		event.Source.IpAddress, event.Source.UserAgent, event.Outcome, event.Reason, metadata, prevHash, hash,
+	}
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
+
+// chainHash covers every stored field of the event, so any edit changes the hash.
+func chainHash(prevHash []byte, event domain.AuditEvent) ([]byte, error) {
+	canonical, err := json.Marshal(struct {
+		OccurredAt string `json:"t"`
+		Action string `json:"a"`
+		Actor uuid.UUID `json:"ac"`
+		Target uuid.UUID `json:"tg"`
+		IpAddress string `json:"ip"`
+		UserAgent string `json:"ua"`
+		Outcome string `json:"o"`
+		Reason string `json:"r"`
+		Metadata map[string]string `json:"m"`
+	}{
+		event.OccurredAt.UTC().Format(time.RFC3339Nano), event.Action, event.Actor, event.Target,
+		event.Source.IpAddress, event.Source.UserAgent, event.Outcome, event.Reason, event.Metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("audit event encoding failed: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write(prevHash)
+	h.Write(canonical)
+	return h.Sum(nil), nil
+}
+
+func scanAuditEvent(row rowScanner) (*domain.AuditEvent, []byte, error) {
+	var (
+		event domain.AuditEvent
+		actor, target uuid.NullUUID
+		metadata, prevHash []byte
+	)
+
+	if err := row.Scan(
+		&event.Id, &event.OccurredAt, &event.Action, &actor, &target, &event.Source.IpAddress,
+		&event.Source.UserAgent, &event.Outcome, &event.Reason, &metadata, &prevHash, &event.Hash,
+	); err != nil {
+		return nil, nil, err
+	}
+
+	event.Actor, event.Target = actor.UUID, target.UUID
+
+	if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+		return nil, nil, fmt.Errorf("audit metadata decoding failed: %w", err)
+	}
+
+	return &event, prevHash, nil
+}
+
+// List returns events matching the query, newest first.
+func (r *AuditRepository) List(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEvent, error) {
+	conditions := make([]string, 0, 3)
+	args := make([]any, 0, 4)
+
+	if query.Subject != uuid.Nil {
+		args = append(args, query.Subject)
+		conditions = append(conditions, fmt.Sprintf("(actor=$%d OR target=$%d)", len(args), len(args)))
+	}
+
+	if query.Action != "" {
+		args = append(args, query.Action)
+		conditions = append(conditions, fmt.Sprintf("action=$%d", len(args)))
+	}
+
+	if query.BeforeId > 0 {
+		args = append(args, query.BeforeId)
+		conditions = append(conditions, fmt.Sprintf("id < $%d", len(args)))
+	}
+
+	sqlQuery := "SELECT " + auditColumns + " FROM audit_events"
+	if len(conditions) > 0 {
+		sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, query.Limit)
+	sqlQuery += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d;", len(args))
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit list operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.AuditEvent, 0, query.Limit)
+	for rows.Next() {
+		event, _, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("audit list operation failed: %w", err)
+		}
+		result = append(result, *event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit list operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// VerifyChain recomputes the hash chain from the first chained row and returns how many
+// rows were checked. Rows written before the chained mode was enabled are skipped.
+func (r *AuditRepository) VerifyChain(ctx context.Context) (int64, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT " + auditColumns + " FROM audit_events WHERE hash IS NOT NULL ORDER BY id;")
+	if err != nil {
+		return 0, fmt.Errorf("audit chain verification failed: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		checked int64
+		expectedPrev []byte
+	)
+
+	for rows.Next() {
+		event, prevHash, err := scanAuditEvent(rows)
+		if err != nil {
+			return checked, fmt.Errorf("audit chain verification failed: %w", err)
+		}
+
+		if checked > 0 && !bytes.Equal(prevHash, expectedPrev) {
+			return checked, fmt.Errorf("%w: row %d doesn't follow its predecessor", ErrAuditChainBroken, event.Id)
+		}
+
+		hash, err := chainHash(prevHash, *event)
+		if err != nil {
+			return checked, err
+		}
+
+		if !bytes.Equal(hash, event.Hash) {
+			return checked, fmt.Errorf("%w: row %d was modified", ErrAuditChainBroken, event.Id)
+		}
+
+		expectedPrev = event.Hash
+		checked++
+	}
+
+	if err = rows.Err(); err != nil {
+		return checked, fmt.Errorf("audit chain verification failed: %w", err)
+	}
+
+	return checked, nil
+}
+
+func (r *AuditRepository) Exit() {
+	if r.db != nil {
+		r.db.Close()
+	}
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+)
+
+// bootstrapLockKey is the advisory lock instances take for the whole bootstrap, so the ones
+// starting together wait for the first instead of racing it.
+const bootstrapLockKey int64 = 0x63617261757468 // "carauth"
+
+// BootstrapConfig says what Bootstrapper prepares. Where the database is provisioned
+// beforehand the bootstrap is not run at all.
+type BootstrapConfig struct {
+	// CreateDatabase creates Config.DBName when it is missing, the connecting role must be allowed to.
+	CreateDatabase bool
+	// Migrate applies the embedded migrations.
+	Migrate bool
+	// AppRole is created when missing and is granted no more than reading and writing the
+	// rows of the service tables. Its password is only set on creation.
+	AppRole string
+	AppRolePassword string
+}
+
+// Bootstrapper prepares the Postgres database of the service with the privileged role of Config.
+type Bootstrapper struct {
+	conf *Config
+	boot BootstrapConfig
+}
+
+func NewBootstrapper(conf *Config, boot BootstrapConfig) *Bootstrapper {
+	return &Bootstrapper{
+		conf: conf,
+		boot: boot,
+	}
+}
+
+// Run creates the database, applies the migrations and sets up the application role, holding
+// the bootstrap lock throughout. Every step is idempotent, so each instance may run it.
+func (b *Bootstrapper) Run(ctx context.Context) error {
+	// advisory locks are per database, the lock is taken in the maintenance database when the
+	// target one may not exist yet
+	conn, err := pgx.Connect(ctx, b.conf.GetPgConnString(b.boot.CreateDatabase))
+	if err != nil {
+		return fmt.Errorf("bootstrap connection failed: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1);", bootstrapLockKey); err != nil {
+		return fmt.Errorf("bootstrap lock failed: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1);", bootstrapLockKey)
+
+	if b.boot.CreateDatabase {
+		if err = createDatabase(ctx, conn, b.conf.DBName); err != nil {
+			return err
+		}
+	}
+
+	if b.boot.Migrate {
+		m, err := NewMigrator(b.conf)
+		if err != nil {
+			return fmt.Errorf("bootstrap - %w", err)
+		}
+
+		err = m.Apply()
+		m.Close()
+		if err != nil {
+			return fmt.Errorf("bootstrap - %w", err)
+		}
+	}
+
+	if b.boot.AppRole != "" {
+		return b.setupAppRole(ctx)
+	}
+
+	return nil
+}
+
+func createDatabase(ctx context.Context, conn *pgx.Conn, name string) error {
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1);", name).Scan(&exists); err != nil {
+		return fmt.Errorf("database existence check failed: %w", err)
+	}
+
+	if exists {
+		return nil
+	}
+
+	// utility statements take no parameters, the name is quoted instead
+	_, err := conn.Exec(ctx, "CREATE DATABASE " + pgx.Identifier{name}.Sanitize() + ";")
+	if pgerr, ok := pgError(err); ok && pgerr.Code == pgerrcode.DuplicateDatabase {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("database creation failed: %w", err)
+	}
+
+	return nil
+}
+
+// setupAppRole creates the role without any attribute beyond LOGIN and grants it DML on the
+// tables and sequences of the public schema, present and future. Nobody but the owner may
+// create objects in the schema and the migration history stays read-only to the role.
+func (b *Bootstrapper) setupAppRole(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.conf.GetPgConnString(false))
+	if err != nil {
+		return fmt.Errorf("bootstrap connection failed: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	var exists bool
+	if err = conn.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM pg_roles WHERE rolname = $1);", b.boot.AppRole).Scan(&exists); err != nil {
+		return fmt.Errorf("role existence check failed: %w", err)
+	}
+
+	if !exists {
+		if b.boot.AppRolePassword == "" {
+			return errors.New("application role password is not set")
+		}
+
+		// format quotes both the name and the password on the server side
+		var statement string
+		err = conn.QueryRow(ctx,
+			"SELECT format('CREATE ROLE %I LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE NOINHERIT PASSWORD %L', $1::text, $2::text);",
+			b.boot.AppRole, b.boot.AppRolePassword,
+		).Scan(&statement)
+		if err != nil {
+			return fmt.Errorf("role statement building failed: %w", err)
+		}
+
+		if _, err = conn.Exec(ctx, statement); err != nil {
+			if pgerr, ok := pgError(err); !ok || pgerr.Code != pgerrcode.DuplicateObject {
+				return fmt.Errorf("role creation failed: %w", err)
+			}
+		}
+	}
+
+	role, database := pgx.Identifier{b.boot.AppRole}.Sanitize(), pgx.Identifier{b.conf.DBName}.Sanitize()
+	grants := []string{
+		"REVOKE CREATE ON SCHEMA public FROM PUBLIC",
+		"GRANT CONNECT ON DATABASE " + database + " TO " + role,
+		"GRANT USAGE ON SCHEMA public TO " + role,
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO " + role,
+		"GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO " + role,
+		"ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO " + role,
+		"ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO " + role,
+		"REVOKE INSERT, UPDATE, DELETE ON TABLE schema_migrations FROM " + role,
+	}
+
+	for _, grant := range grants {
+		if _, err = conn.Exec(ctx, grant + ";"); err != nil {
+			// schema_migrations is missing when the bootstrap applies no migrations
+			if pgerr, ok := pgError(err); ok && pgerr.Code == pgerrcode.UndefinedTable {
+				continue
+			}
+			return fmt.Errorf("role grant failed: %w", err)
+		}
+	}
+
+	return nil
+}
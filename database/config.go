@@ -2,6 +2,37 @@ package database
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	DriverPostgres = "postgres"
+	// DriverRedis is the default session storage.
+	DriverRedis = "redis"
+	// DriverSQLite keeps the user storage in the file named by Config.DBName.
+	DriverSQLite = "sqlite3"
+	// DriverMemory keeps the storage in process memory, see the Memory*Repository types.
+	DriverMemory = "memory"
+)
+
+// database/sql driver names: SQLite goes through the pure Go driver, so the service still
+// builds with CGO_ENABLED=0, Postgres through the stdlib adapter of pgx.
+const (
+	sqliteDriverName = "sqlite"
+	pgxDriverName = "pgx"
+)
+
+// TLS modes follow the Postgres sslmode names and mean the same for Redis.
+const (
+	TLSDisable = "disable"
+	// TLSRequire encrypts the connection without verifying the server certificate.
+	TLSRequire = "require"
+	// TLSVerifyCA checks that the server certificate is signed by TLSCAFile.
+	TLSVerifyCA = "verify-ca"
+	// TLSVerifyFull also checks that the certificate matches the server host name.
+	TLSVerifyFull = "verify-full"
 )
 
 type Config struct {
@@ -10,6 +41,39 @@ type Config struct {
 	User     string
 	Password string
 	DBName   string
+	// KeyNamespace prefixes every Redis key, DefaultKeyNamespace when empty.
+	KeyNamespace string
+	// CacheSize enables the in-process SessionCache of that many entries in front of Redis,
+	// CacheTTL bounds how long a cached read may lag behind Redis.
+	CacheSize int
+	CacheTTL time.Duration
+
+	// Pool settings, zero keeps the driver default. MaxOpenConns is the Redis pool size.
+	MaxOpenConns int
+	MaxIdleConns int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Timeouts, zero keeps the driver default. Postgres has no socket read and write
+	// timeouts, StatementTimeout bounds its queries on the server instead.
+	DialTimeout time.Duration
+	ReadTimeout time.Duration
+	WriteTimeout time.Duration
+	StatementTimeout time.Duration
+	// MaxRetries is how many times a failed Redis command is retried, -1 disables retries.
+	MaxRetries int
+
+	// TLSMode is one of the TLS* modes, TLSDisable when empty. TLSCertFile and TLSKeyFile
+	// hold an optional client certificate.
+	TLSMode string
+	TLSCAFile string
+	TLSCertFile string
+	TLSKeyFile string
+
+	// SentinelMaster makes Addr a comma separated list of Redis Sentinels watching that master.
+	SentinelMaster string
+	// Cluster makes Addr a comma separated list of Redis Cluster nodes.
+	Cluster bool
 }
 
 func (conf *Config) GetPgConnString(defaultConn bool) string {
@@ -18,13 +82,69 @@ func (conf *Config) GetPgConnString(defaultConn bool) string {
 		dbName = ""
 	}
 
-	return fmt.Sprintf(
-		"%s://%s:%s@%s/%s?sslmode=disable", conf.Driver, conf.User, conf.Password, conf.Addr, dbName,
-	)
+	params := url.Values{}
+	params.Set("sslmode", conf.tlsMode())
+	for param, file := range map[string]string{"sslrootcert": conf.TLSCAFile, "sslcert": conf.TLSCertFile, "sslkey": conf.TLSKeyFile} {
+		if file != "" {
+			params.Set(param, file)
+		}
+	}
+
+	if conf.DialTimeout > 0 {
+		// connect_timeout is in whole seconds, anything shorter would turn into no timeout
+		params.Set("connect_timeout", strconv.Itoa(max(1, int(conf.DialTimeout.Seconds()))))
+	}
+
+	if conf.StatementTimeout > 0 {
+		params.Set("statement_timeout", strconv.FormatInt(conf.StatementTimeout.Milliseconds(), 10))
+	}
+
+	// url.URL escapes what the names and the password may contain
+	connURL := url.URL{
+		Scheme: conf.Driver,
+		User: url.UserPassword(conf.User, conf.Password),
+		Host: conf.Addr,
+		Path: "/" + dbName,
+		RawQuery: params.Encode(),
+	}
+	return connURL.String()
+}
+
+func (conf *Config) tlsMode() string {
+	if conf.TLSMode == "" {
+		return TLSDisable
+	}
+	return conf.TLSMode
 }
 
 func (conf *Config) GetRedisConnString() string {
 	return fmt.Sprintf(
 		"%s://:%s@%s/%s", conf.Driver, conf.Password, conf.Addr, conf.DBName,
 	)
-}
\ No newline at end of file
+}
+
+// GetSQLiteConnString enables foreign keys, which SQLite leaves off by default, and takes the
+// write lock when a transaction begins, so concurrent transactions wait instead of failing.
+// Times are written in the SQLite text format, so they compare in order.
+func (conf *Config) GetSQLiteConnString() string {
+	return fmt.Sprintf(
+		"file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate&_time_format=sqlite",
+		conf.DBName,
+	)
+}
+
+// GetSQLDriverName returns the database/sql driver name of the SQL storage driver.
+func (conf *Config) GetSQLDriverName() string {
+	if conf.Driver == DriverSQLite {
+		return sqliteDriverName
+	}
+	return pgxDriverName
+}
+
+// GetSQLConnString returns the connection string of the SQL storage driver.
+func (conf *Config) GetSQLConnString() string {
+	if conf.Driver == DriverSQLite {
+		return conf.GetSQLiteConnString()
+	}
+	return conf.GetPgConnString(false)
+}
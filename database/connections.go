@@ -0,0 +1,252 @@
+package database
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrInvalidTLSMode = errors.New("invalid tls mode")
+
+// configurePool applies the pool settings of the config, zero values keep the defaults.
+func configurePool(db *sql.DB, conf *Config) {
+	if conf.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(conf.MaxOpenConns)
+	}
+
+	if conf.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(conf.MaxIdleConns)
+	}
+
+	if conf.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(conf.ConnMaxLifetime)
+	}
+
+	if conf.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(conf.ConnMaxIdleTime)
+	}
+}
+
+// configurePgxPool applies the pool settings to a pgx pool. Every statement is prepared on its
+// first run on a connection and reused from the cache afterwards. pgx keeps no idle connection
+// limit of its own, MaxIdleConns only applies to database/sql pools.
+func configurePgxPool(pool *pgxpool.Config, conf *Config) {
+	pool.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+
+	if conf.MaxOpenConns > 0 {
+		pool.MaxConns = int32(conf.MaxOpenConns)
+	}
+
+	if conf.ConnMaxLifetime > 0 {
+		pool.MaxConnLifetime = conf.ConnMaxLifetime
+	}
+
+	if conf.ConnMaxIdleTime > 0 {
+		pool.MaxConnIdleTime = conf.ConnMaxIdleTime
+	}
+}
+
+// NewPgPool connects to Postgres. The session and token repositories take the pool as an
+// argument, as they share a single one; closing either of them closes it.
+func NewPgPool(conf *Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(conf.GetPgConnString(false))
+	if err != nil {
+		fmt.Println("invalid connection arguments:", err)
+		return nil, err
+	}
+	configurePgxPool(poolConfig, conf)
+
+	ctx := context.Background()
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		fmt.Println("invalid connection arguments:", err)
+		return nil, err
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		fmt.Println("database connection failed:", err)
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// tlsConfig builds the client TLS settings of the Redis connection, nil when TLS is disabled.
+func tlsConfig(conf *Config, addr string) (*tls.Config, error) {
+	mode := conf.tlsMode()
+	if mode == TLSDisable {
+		return nil, nil
+	}
+
+	result := &tls.Config{MinVersion: tls.VersionTLS12}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		result.ServerName = host
+	}
+
+	if conf.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.TLSCertFile, conf.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate loading failed: %w", err)
+		}
+		result.Certificates = []tls.Certificate{cert}
+	}
+
+	if conf.TLSCAFile != "" {
+		pem, err := os.ReadFile(conf.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ca file reading failed: %w", err)
+		}
+
+		result.RootCAs = x509.NewCertPool()
+		if !result.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", conf.TLSCAFile)
+		}
+	}
+
+	switch mode {
+	case TLSRequire:
+		result.InsecureSkipVerify = true
+	case TLSVerifyCA:
+		// the chain is verified by hand, the standard verification would check the host name too
+		result.InsecureSkipVerify = true
+		result.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{Roots: result.RootCAs, Intermediates: intermediates})
+			return err
+		}
+	case TLSVerifyFull:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTLSMode, mode)
+	}
+
+	return result, nil
+}
+
+// redisAddrs splits Addr into the node or sentinel addresses.
+func (conf *Config) redisAddrs() []string {
+	addrs := strings.Split(conf.Addr, ",")
+	for i := range addrs {
+		addrs[i] = strings.TrimSpace(addrs[i])
+	}
+	return addrs
+}
+
+// newRedisClient connects to a single Redis server, a Sentinel-managed master or a Cluster,
+// depending on the config.
+func newRedisClient(conf *Config) (redis.UniversalClient, error) {
+	tlsConf, err := tlsConfig(conf, conf.redisAddrs()[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var client redis.UniversalClient
+	switch {
+	case conf.Cluster:
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs: conf.redisAddrs(),
+			Password: conf.Password,
+			DialTimeout: conf.DialTimeout,
+			ReadTimeout: conf.ReadTimeout,
+			WriteTimeout: conf.WriteTimeout,
+			MaxRetries: conf.MaxRetries,
+			PoolSize: conf.MaxOpenConns,
+			MaxIdleConns: conf.MaxIdleConns,
+			ConnMaxLifetime: conf.ConnMaxLifetime,
+			ConnMaxIdleTime: conf.ConnMaxIdleTime,
+			TLSConfig: tlsConf,
+		})
+	case conf.SentinelMaster != "":
+		db := 0
+		if conf.DBName != "" {
+			if db, err = strconv.Atoi(conf.DBName); err != nil {
+				return nil, fmt.Errorf("invalid redis database number %q: %w", conf.DBName, err)
+			}
+		}
+
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName: conf.SentinelMaster,
+			SentinelAddrs: conf.redisAddrs(),
+			Password: conf.Password,
+			DB: db,
+			DialTimeout: conf.DialTimeout,
+			ReadTimeout: conf.ReadTimeout,
+			WriteTimeout: conf.WriteTimeout,
+			MaxRetries: conf.MaxRetries,
+			PoolSize: conf.MaxOpenConns,
+			MaxIdleConns: conf.MaxIdleConns,
+			ConnMaxLifetime: conf.ConnMaxLifetime,
+			ConnMaxIdleTime: conf.ConnMaxIdleTime,
+			TLSConfig: tlsConf,
+		})
+	default:
+		options, err := redis.ParseURL(conf.GetRedisConnString())
+		if err != nil {
+			return nil, err
+		}
+
+		options.DialTimeout = durationOr(conf.DialTimeout, options.DialTimeout)
+		options.ReadTimeout = durationOr(conf.ReadTimeout, options.ReadTimeout)
+		options.WriteTimeout = durationOr(conf.WriteTimeout, options.WriteTimeout)
+		options.ConnMaxLifetime = durationOr(conf.ConnMaxLifetime, options.ConnMaxLifetime)
+		options.ConnMaxIdleTime = durationOr(conf.ConnMaxIdleTime, options.ConnMaxIdleTime)
+		if conf.MaxRetries != 0 {
+			options.MaxRetries = conf.MaxRetries
+		}
+		if conf.MaxOpenConns > 0 {
+			options.PoolSize = conf.MaxOpenConns
+		}
+		if conf.MaxIdleConns > 0 {
+			options.MaxIdleConns = conf.MaxIdleConns
+		}
+		options.TLSConfig = tlsConf
+
+		client = redis.NewClient(options)
+	}
+
+	if _, err := client.Ping(context.Background()).Result(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("redis connection error: %w", err)
+	}
+
+	return client, nil
+}
+
+func durationOr(value, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// forEachRedisNode runs fn on every master of a Cluster, or on the only server otherwise.
+// SCAN is per node, a scan through a Cluster client would see a single node only.
+func forEachRedisNode(ctx context.Context, client redis.UniversalClient, fn func(ctx context.Context, node *redis.Client) error) error {
+	switch client := client.(type) {
+	case *redis.ClusterClient:
+		return client.ForEachMaster(ctx, fn)
+	case *redis.Client:
+		return fn(ctx, client)
+	}
+	return fmt.Errorf("unsupported redis client %T", client)
+}
@@ -0,0 +1,269 @@
+package database
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	legacySessionKey = regexp.MustCompile(`^[a-zA-Z0-9]{64}$`)
+	legacyTokenKey = regexp.MustCompile(`^([a-z_-]+):([0-9a-f]{64})$`)
+)
+
+// KeyMigrationReport counts what a key migration has done, or would do on a dry run.
+type KeyMigrationReport struct {
+	Scanned int
+	Sessions int
+	Indexes int
+	Tokens int
+	// Conflicts are keys whose namespaced name is already taken, they are left in place.
+	Conflicts int
+	// Skipped are keys that don't belong to the former layout or vanished during the run.
+	Skipped int
+}
+
+// KeyMigrator moves the keys of schema version 1 (sessions and user indexes as top-level
+// keys, confirmation tokens as "<purpose>:<token>") under the namespace of KeySchema.
+// Confirmation tokens are renamed to the hash of the token TokenRepository looks them up by.
+type KeyMigrator struct {
+	client redis.UniversalClient
+	keys KeySchema
+	batch int64
+}
+
+// ErrClusterNotSupported is returned for a Redis Cluster, the former layout was never deployed on one.
+var ErrClusterNotSupported = errors.New("key migration does not support redis cluster")
+
+func NewKeyMigrator(conf *Config, batch int64) (*KeyMigrator, error) {
+	if conf.Cluster {
+		return nil, ErrClusterNotSupported
+	}
+
+	client, err := newRedisClient(conf)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating key migrator: %w", err)
+	}
+
+	return &KeyMigrator{
+		client: client,
+		keys: NewKeySchema(conf.KeyNamespace),
+		batch: batch,
+	}, nil
+}
+
+type keyMove struct {
+	from, to string
+	counter *int
+}
+
+// Migrate walks the keyspace with SCAN, renaming a batch of keys per round trip. RENAMENX keeps
+// the TTL of a key and never overwrites, so the run can be repeated. The schema marker is set
+// once every key has been visited.
+func (m *KeyMigrator) Migrate(ctx context.Context, dryRun bool) (KeyMigrationReport, error) {
+	report := KeyMigrationReport{}
+
+	version, err := m.client.Get(ctx, m.keys.Version()).Int()
+	if err != nil && err != redis.Nil {
+		return report, fmt.Errorf("redis error - key schema marker read failed: %w", err)
+	}
+
+	if version > KeySchemaVersion {
+		return report, fmt.Errorf("%w: have %d, want %d", ErrKeySchemaTooNew, version, KeySchemaVersion)
+	}
+
+	var cursor uint64
+	for {
+		keys, next, err := m.client.Scan(ctx, cursor, "*", m.batch).Result()
+		if err != nil {
+			return report, fmt.Errorf("redis error - keyspace scan failed: %w", err)
+		}
+
+		moves, err := m.plan(ctx, keys, &report)
+		if err != nil {
+			return report, err
+		}
+
+		if dryRun {
+			for _, move := range moves {
+				*move.counter++
+			}
+		} else if err = m.apply(ctx, moves, &report); err != nil {
+			return report, err
+		}
+
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if err = m.client.Set(ctx, m.keys.Version(), KeySchemaVersion, 0).Err(); err != nil {
+		return report, fmt.Errorf("redis error - key schema marker update failed: %w", err)
+	}
+
+	return report, nil
+}
+
+// legacyScanBatch is the SCAN count of the startup check for keys of the former layout.
+const legacyScanBatch = 1000
+
+// findLegacyKey returns a key of schema version 1 outside the namespace of schema, "" when
+// there is none. The former layout was never deployed on a Redis Cluster, so one isn't scanned.
+func findLegacyKey(ctx context.Context, client redis.UniversalClient, schema KeySchema) (string, error) {
+	if _, cluster := client.(*redis.ClusterClient); cluster {
+		return "", nil
+	}
+
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, "*", legacyScanBatch).Result()
+		if err != nil {
+			return "", fmt.Errorf("redis error - keyspace scan failed: %w", err)
+		}
+
+		candidates := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if !strings.HasPrefix(key, schema.Namespace() + ":") && legacyKeyName(key) {
+				candidates = append(candidates, key)
+			}
+		}
+
+		if len(candidates) > 0 {
+			pipe := client.Pipeline()
+			types := make([]*redis.StatusCmd, len(candidates))
+			for i, key := range candidates {
+				types[i] = pipe.Type(ctx, key)
+			}
+
+			if _, err = pipe.Exec(ctx); err != nil {
+				return "", fmt.Errorf("redis error - key type lookup failed: %w", err)
+			}
+
+			for i, key := range candidates {
+				if legacyKey(key, types[i].Val()) {
+					return key, nil
+				}
+			}
+		}
+
+		if cursor = next; cursor == 0 {
+			return "", nil
+		}
+	}
+}
+
+// legacyKeyName reports whether key is named like a session, token or user index of version 1.
+func legacyKeyName(key string) bool {
+	if legacySessionKey.MatchString(key) || legacyTokenKey.MatchString(key) {
+		return true
+	}
+
+	userId, err := uuid.Parse(key)
+	return err == nil && userId.String() == key
+}
+
+// legacyKey reports whether a key of keyType is one plan would move.
+func legacyKey(key, keyType string) bool {
+	switch keyType {
+	case "string":
+		return legacySessionKey.MatchString(key) || legacyTokenKey.MatchString(key)
+	case "set", "zset":
+		userId, err := uuid.Parse(key)
+		return err == nil && userId.String() == key
+	}
+	return false
+}
+
+// plan picks the keys of the former layout out of a scanned batch.
+func (m *KeyMigrator) plan(ctx context.Context, keys []string, report *KeyMigrationReport) ([]keyMove, error) {
+	legacy := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !strings.HasPrefix(key, m.keys.Namespace() + ":") {
+			legacy = append(legacy, key)
+		}
+	}
+	report.Scanned += len(legacy)
+
+	pipe := m.client.Pipeline()
+	types := make([]*redis.StatusCmd, len(legacy))
+	for i, key := range legacy {
+		types[i] = pipe.Type(ctx, key)
+	}
+
+	if len(legacy) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("redis error - key type lookup failed: %w", err)
+		}
+	}
+
+	moves := make([]keyMove, 0, len(legacy))
+	for i, key := range legacy {
+		keyType := types[i].Val()
+
+		switch {
+		case keyType == "string" && legacySessionKey.MatchString(key):
+			moves = append(moves, keyMove{key, m.keys.Session(key), &report.Sessions})
+		case keyType == "string" && legacyTokenKey.MatchString(key):
+			parts := legacyTokenKey.FindStringSubmatch(key)
+			moves = append(moves, keyMove{key, m.keys.Token(parts[1], hex.EncodeToString(hashToken(parts[2]))), &report.Tokens})
+		case keyType == "set" || keyType == "zset":
+			userId, err := uuid.Parse(key)
+			if err != nil || userId.String() != key {
+				report.Skipped++
+				continue
+			}
+			moves = append(moves, keyMove{key, m.keys.UserSessions(userId), &report.Indexes})
+		default:
+			report.Skipped++
+		}
+	}
+
+	return moves, nil
+}
+
+func (m *KeyMigrator) apply(ctx context.Context, moves []keyMove, report *KeyMigrationReport) error {
+	if len(moves) == 0 {
+		return nil
+	}
+
+	pipe := m.client.Pipeline()
+	renames := make([]*redis.BoolCmd, len(moves))
+	for i, move := range moves {
+		renames[i] = pipe.RenameNX(ctx, move.from, move.to)
+	}
+
+	// errors of single commands are examined below
+	_, _ = pipe.Exec(ctx)
+
+	for i, rename := range renames {
+		renamed, err := rename.Result()
+		switch {
+		case err != nil && strings.Contains(err.Error(), "no such key"):
+			report.Skipped++
+		case err != nil:
+			return fmt.Errorf("redis error - key %s rename failed: %w", moves[i].from, err)
+		case renamed:
+			*moves[i].counter++
+		default:
+			report.Conflicts++
+		}
+	}
+
+	return nil
+}
+
+func (m *KeyMigrator) Exit() {
+	if m != nil {
+		_ = m.client.Close()
+	}
+}
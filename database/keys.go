@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// KeySchemaVersion is the layout of the Redis keys written by this build.
+	// Version 1 is the former layout with raw tokens and user ids as top-level keys.
+	KeySchemaVersion = 2
+	DefaultKeyNamespace = "auth"
+)
+
+var (
+	ErrKeySchemaOutdated = errors.New("redis keys use an older schema, run keymigrate first")
+	ErrKeySchemaTooNew = errors.New("redis keys use a newer schema than this build supports")
+)
+
+// KeySchema builds every Redis key of the service under one namespace, so the service can
+// share a Redis instance and its keys are told apart from the rest:
+//
+//	<ns>:session:<token>            refresh session
+//	<ns>:user:<userId>:sessions     index of the user's refresh tokens
+//	<ns>:lockout:<subject>          failed login counter
+//	<ns>:otp:<purpose>:<userId>     one-time password
+//	<ns>:token:<purpose>:<hash>     confirmation token by its hex SHA-256
+//	<ns>:schema_version             layout marker
+type KeySchema struct {
+	namespace string
+}
+
+func NewKeySchema(namespace string) KeySchema {
+	if namespace == "" {
+		namespace = DefaultKeyNamespace
+	}
+	return KeySchema{namespace: namespace}
+}
+
+func (k KeySchema) Namespace() string {
+	return k.namespace
+}
+
+func (k KeySchema) Session(token string) string {
+	return k.sessionPrefix() + token
+}
+
+func (k KeySchema) UserSessions(userId uuid.UUID) string {
+	return k.userPrefix() + userId.String() + userSessionsSuffix
+}
+
+func (k KeySchema) Lockout(subject string) string {
+	return k.namespace + ":lockout:" + subject
+}
+
+func (k KeySchema) OTP(purpose string, userId uuid.UUID) string {
+	return k.namespace + ":otp:" + purpose + ":" + userId.String()
+}
+
+func (k KeySchema) Token(purpose, token string) string {
+	return k.namespace + ":token:" + purpose + ":" + token
+}
+
+// SessionInvalidations is the pub/sub channel SessionCache instances invalidate each other through.
+func (k KeySchema) SessionInvalidations() string {
+	return k.namespace + ":sessions:invalidate"
+}
+
+func (k KeySchema) Version() string {
+	return k.namespace + ":schema_version"
+}
+
+const userSessionsSuffix = ":sessions"
+
+func (k KeySchema) sessionPrefix() string {
+	return k.namespace + ":session:"
+}
+
+func (k KeySchema) userPrefix() string {
+	return k.namespace + ":user:"
+}
+
+// scriptArgs are the leading ARGV of every session script, they let the scripts build
+// session and index keys they learn about only while running.
+func (k KeySchema) scriptArgs(args ...any) []any {
+	return append([]any{k.sessionPrefix(), k.userPrefix(), userSessionsSuffix}, args...)
+}
+
+// checkKeySchema compares the layout marker with the one of this build. A namespace without
+// a marker is marked as current only when no keys of the former layout are found, otherwise
+// the live sessions and tokens would be orphaned, keymigrate has to move them first.
+func checkKeySchema(ctx context.Context, client redis.UniversalClient, schema KeySchema) error {
+	err := client.Get(ctx, schema.Version()).Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("redis error - key schema marker read failed: %w", err)
+	}
+
+	if err == redis.Nil {
+		legacyKey, err := findLegacyKey(ctx, client, schema)
+		if err != nil {
+			return err
+		}
+		if legacyKey != "" {
+			return fmt.Errorf("%w: no schema marker and key %q has the version 1 layout", ErrKeySchemaOutdated, legacyKey)
+		}
+
+		if err = client.SetNX(ctx, schema.Version(), KeySchemaVersion, 0).Err(); err != nil {
+			return fmt.Errorf("redis error - key schema marker setup failed: %w", err)
+		}
+	}
+
+	version, err := client.Get(ctx, schema.Version()).Int()
+	if err != nil {
+		return fmt.Errorf("redis error - key schema marker read failed: %w", err)
+	}
+
+	switch {
+	case version < KeySchemaVersion:
+		return fmt.Errorf("%w: have %d, want %d", ErrKeySchemaOutdated, version, KeySchemaVersion)
+	case version > KeySchemaVersion:
+		return fmt.Errorf("%w: have %d, want %d", ErrKeySchemaTooNew, version, KeySchemaVersion)
+	}
+
+	return nil
+}
+
+// keySchemaFor returns the key schema of the config. In a Cluster the namespace becomes a hash
+// tag, the session scripts touch several keys at once and those must share a hash slot.
+func keySchemaFor(conf *Config) KeySchema {
+	schema := NewKeySchema(conf.KeyNamespace)
+	if conf.Cluster && !strings.HasPrefix(schema.namespace, "{") {
+		schema.namespace = "{" + schema.namespace + "}"
+	}
+	return schema
+}
+
+// openKeySpace connects to Redis and makes sure the keys there are in the layout of this build.
+func openKeySpace(conf *Config) (redis.UniversalClient, KeySchema, error) {
+	schema := keySchemaFor(conf)
+
+	client, err := newRedisClient(conf)
+	if err != nil {
+		return nil, schema, err
+	}
+
+	if err = checkKeySchema(context.Background(), client, schema); err != nil {
+		_ = client.Close()
+		return nil, schema, err
+	}
+
+	return client, schema, nil
+}
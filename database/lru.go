@@ -0,0 +1,99 @@
+package database
+
+import (
+	"container/list"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key K
+	value V
+	expiresAt time.Time
+}
+
+// lruCache is a size-bounded cache whose entries also expire after ttl. It is not safe for
+// concurrent use, the owner serializes access.
+type lruCache[K comparable, V any] struct {
+	size int
+	ttl time.Duration
+	order *list.List
+	items map[K]*list.Element
+}
+
+func newLRUCache[K comparable, V any](size int, ttl time.Duration) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		size: size,
+		ttl: ttl,
+		order: list.New(),
+		items: make(map[K]*list.Element, size),
+	}
+}
+
+func (c *lruCache[K, V]) get(key K, now time.Time) (V, bool) {
+	var zero V
+
+	item, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := item.Value.(*lruEntry[K, V])
+	if !now.Before(entry.expiresAt) {
+		c.order.Remove(item)
+		delete(c.items, key)
+		return zero, false
+	}
+
+	c.order.MoveToFront(item)
+	return entry.value, true
+}
+
+// put stores the value and reports whether the least recently used entry was evicted for it.
+func (c *lruCache[K, V]) put(key K, value V, now time.Time) (evicted bool) {
+	if item, ok := c.items[key]; ok {
+		entry := item.Value.(*lruEntry[K, V])
+		entry.value, entry.expiresAt = value, now.Add(c.ttl)
+		c.order.MoveToFront(item)
+		return false
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: now.Add(c.ttl)})
+	if c.order.Len() <= c.size {
+		return false
+	}
+
+	oldest := c.order.Back()
+	c.order.Remove(oldest)
+	delete(c.items, oldest.Value.(*lruEntry[K, V]).key)
+	return true
+}
+
+func (c *lruCache[K, V]) remove(key K) bool {
+	item, ok := c.items[key]
+	if ok {
+		c.order.Remove(item)
+		delete(c.items, key)
+	}
+	return ok
+}
+
+// removeFunc drops every entry the predicate holds for and returns how many were dropped.
+func (c *lruCache[K, V]) removeFunc(drop func(V) bool) int {
+	removed := 0
+	for item := c.order.Front(); item != nil; {
+		next := item.Next()
+		entry := item.Value.(*lruEntry[K, V])
+		if drop(entry.value) {
+			c.order.Remove(item)
+			delete(c.items, entry.key)
+			removed++
+		}
+		item = next
+	}
+	return removed
+}
+
+func (c *lruCache[K, V]) clear() {
+	c.order.Init()
+	c.items = make(map[K]*list.Element, c.size)
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+// MemoryAuditRepository keeps audit events in process memory, numbered like the rows of AuditRepository.
+type MemoryAuditRepository struct {
+	mu sync.Mutex
+	events []domain.AuditEvent
+}
+
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+func (r *MemoryAuditRepository) Record(ctx context.Context, event domain.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metadata := make(map[string]string, len(event.Metadata))
+	for key, value := range event.Metadata {
+		metadata[key] = value
+	}
+
+	event.Id = int64(len(r.events)) + 1
+	event.OccurredAt = event.OccurredAt.UTC()
+	event.Metadata = metadata
+	r.events = append(r.events, event)
+
+	return nil
+}
+
+// List returns events matching the query, newest first.
+func (r *MemoryAuditRepository) List(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]domain.AuditEvent, 0, query.Limit)
+	for i := len(r.events) - 1; i >= 0 && len(result) < query.Limit; i-- {
+		event := r.events[i]
+
+		switch {
+		case query.Subject != uuid.Nil && event.Actor != query.Subject && event.Target != query.Subject:
+		case query.Action != "" && event.Action != query.Action:
+		case query.BeforeId > 0 && event.Id >= query.BeforeId:
+		default:
+			result = append(result, event)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *MemoryAuditRepository) Exit() {}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+type memorySession struct {
+	session domain.Session
+	expiresAt time.Time
+}
+
+// MemorySessionRepository keeps refresh sessions in process memory with the expiry and
+// error semantics of SessionRepository. Expired sessions are invisible at once and are
+// dropped on the next access or sweep.
+type MemorySessionRepository struct {
+	mu sync.Mutex
+	sessions map[string]memorySession
+	index map[uuid.UUID]map[string]struct{}
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{
+		sessions: make(map[string]memorySession),
+		index: make(map[uuid.UUID]map[string]struct{}),
+	}
+}
+
+// live returns the session if it has not expired, an expired one is removed.
+func (r *MemorySessionRepository) live(token string, now time.Time) (memorySession, bool) {
+	stored, ok := r.sessions[token]
+	if ok && !now.Before(stored.expiresAt) {
+		r.remove(token)
+		return memorySession{}, false
+	}
+	return stored, ok
+}
+
+func (r *MemorySessionRepository) put(token string, session *domain.Session, expiresIn time.Duration) {
+	r.sessions[token] = memorySession{session: *session, expiresAt: time.Now().Add(expiresIn)}
+
+	tokens, ok := r.index[session.UserId]
+	if !ok {
+		tokens = make(map[string]struct{})
+		r.index[session.UserId] = tokens
+	}
+	tokens[token] = struct{}{}
+}
+
+func (r *MemorySessionRepository) remove(token string) {
+	stored, ok := r.sessions[token]
+	if !ok {
+		return
+	}
+
+	delete(r.sessions, token)
+	if tokens := r.index[stored.session.UserId]; tokens != nil {
+		delete(tokens, token)
+		if len(tokens) == 0 {
+			delete(r.index, stored.session.UserId)
+		}
+	}
+}
+
+// prune drops the expired sessions of the user and returns how many were dropped.
+func (r *MemorySessionRepository) prune(userId uuid.UUID, now time.Time) int64 {
+	var pruned int64
+	for token := range r.index[userId] {
+		if _, ok := r.live(token, now); !ok {
+			pruned++
+		}
+	}
+	return pruned
+}
+
+func (r *MemorySessionRepository) Save(ctx context.Context, session *domain.Session, expiresIn time.Duration) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token := generateRefreshToken()
+	r.put(token, session, expiresIn)
+
+	return token, nil
+}
+
+func (r *MemorySessionRepository) Rotate(ctx context.Context, token string, session *domain.Session, expiresIn time.Duration) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.live(token, time.Now()); !ok {
+		return "", ErrSessionNotFound
+	}
+
+	r.remove(token)
+
+	newToken := generateRefreshToken()
+	r.put(newToken, session, expiresIn)
+
+	return newToken, nil
+}
+
+func (r *MemorySessionRepository) Delete(ctx context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.live(token, time.Now()); !ok {
+		return ErrSessionNotFound
+	}
+
+	r.remove(token)
+	return nil
+}
+
+func (r *MemorySessionRepository) DeleteUserSessions(ctx context.Context, userId uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for token := range r.index[userId] {
+		r.remove(token)
+	}
+
+	return nil
+}
+
+func (r *MemorySessionRepository) Get(ctx context.Context, token string) (*domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.live(token, time.Now())
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	session := stored.session
+	return &session, nil
+}
+
+func (r *MemorySessionRepository) GetUserSessions(ctx context.Context, userId uuid.UUID) ([]*domain.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if pruned := r.prune(userId, time.Now()); pruned > 0 {
+		sessionIndexMetrics.Add(metricPrunedOnRead, pruned)
+	}
+
+	result := make([]*domain.Session, 0, len(r.index[userId]))
+	for token := range r.index[userId] {
+		session := r.sessions[token].session
+		result = append(result, &session)
+	}
+
+	return result, nil
+}
+
+func (r *MemorySessionRepository) PruneExpiredSessions(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var total int64
+	now := time.Now()
+	for userId := range r.index {
+		total += r.prune(userId, now)
+	}
+
+	sessionIndexMetrics.Add(metricPrunedBySweeper, total)
+	sessionIndexMetrics.Add(metricSweeps, 1)
+
+	return total, nil
+}
+
+func (r *MemorySessionRepository) UpdateUserEmail(ctx context.Context, userId uuid.UUID, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(userId, time.Now())
+
+	for token := range r.index[userId] {
+		stored := r.sessions[token]
+		stored.session.Email = email
+		r.sessions[token] = stored
+	}
+
+	return nil
+}
+
+func (r *MemorySessionRepository) Exit() {}
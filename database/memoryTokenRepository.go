@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryToken struct {
+	payload []byte
+	expiresAt time.Time
+}
+
+type memoryFailures struct {
+	count int
+	expiresAt time.Time
+}
+
+// MemoryTokenRepository keeps single-use confirmation tokens and failed login counters in process memory.
+type MemoryTokenRepository struct {
+	mu sync.Mutex
+	tokens map[string]memoryToken
+	failures map[uuid.UUID]memoryFailures
+}
+
+func NewMemoryTokenRepository() *MemoryTokenRepository {
+	return &MemoryTokenRepository{
+		tokens: make(map[string]memoryToken),
+		failures: make(map[uuid.UUID]memoryFailures),
+	}
+}
+
+func (r *MemoryTokenRepository) Issue(ctx context.Context, purpose string, payload any, expiresIn time.Duration) (string, error) {
+	binary, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("token payload serialization error: %w", err)
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("token generation failed: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, stored := range r.tokens {
+		if !now.Before(stored.expiresAt) {
+			delete(r.tokens, key)
+		}
+	}
+
+	r.tokens[purpose + ":" + token] = memoryToken{payload: binary, expiresAt: now.Add(expiresIn)}
+
+	return token, nil
+}
+
+func (r *MemoryTokenRepository) Peek(ctx context.Context, purpose, token string, payload any) error {
+	r.mu.Lock()
+	stored, ok := r.tokens[purpose + ":" + token]
+	r.mu.Unlock()
+
+	if !ok || !time.Now().Before(stored.expiresAt) {
+		return ErrTokenNotFound
+	}
+
+	if err := json.Unmarshal(stored.payload, payload); err != nil {
+		return fmt.Errorf("token payload deserialization error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MemoryTokenRepository) Consume(ctx context.Context, purpose, token string, payload any) error {
+	r.mu.Lock()
+	stored, ok := r.tokens[purpose + ":" + token]
+	delete(r.tokens, purpose + ":" + token)
+	r.mu.Unlock()
+
+	if !ok || !time.Now().Before(stored.expiresAt) {
+		return ErrTokenNotFound
+	}
+
+	if err := json.Unmarshal(stored.payload, payload); err != nil {
+		return fmt.Errorf("token payload deserialization error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MemoryTokenRepository) FailedLogins(ctx context.Context, userId uuid.UUID) (int, time.Time, error) {
+	r.mu.Lock()
+	stored, ok := r.failures[userId]
+	r.mu.Unlock()
+
+	if !ok || !time.Now().Before(stored.expiresAt) {
+		return 0, time.Time{}, nil
+	}
+
+	return stored.count, stored.expiresAt, nil
+}
+
+func (r *MemoryTokenRepository) RecordFailedLogin(ctx context.Context, userId uuid.UUID, window time.Duration) (int, time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	stored, ok := r.failures[userId]
+	if !ok || !now.Before(stored.expiresAt) {
+		stored = memoryFailures{expiresAt: now.Add(window)}
+	}
+
+	stored.count++
+	r.failures[userId] = stored
+
+	return stored.count, stored.expiresAt, nil
+}
+
+func (r *MemoryTokenRepository) ResetFailedLogins(ctx context.Context, userId uuid.UUID) error {
+	r.mu.Lock()
+	delete(r.failures, userId)
+	r.mu.Unlock()
+
+	return nil
+}
+
+// DeleteOneTimePasswords has nothing to drop, one-time passwords are only kept in Redis.
+func (r *MemoryTokenRepository) DeleteOneTimePasswords(ctx context.Context, userId uuid.UUID) error {
+	return nil
+}
+
+func (r *MemoryTokenRepository) Exit() {}
@@ -0,0 +1,664 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+var (
+	memoryEmailFormat = regexp.MustCompile(`^.+@.+$`)
+	memoryPhoneFormat = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+	memoryMinBirthDate = time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type memoryOutboxEvent struct {
+	domain.OutboxEvent
+	seq int64
+	availableAt time.Time
+	lastError string
+	publishedAt time.Time
+}
+
+type memoryProfileChange struct {
+	userId uuid.UUID
+	record domain.ProfileChangeRecord
+}
+
+// MemoryUserRepository keeps users, roles, profile history and the outbox in process memory.
+// It rejects the writes the Postgres schema rejects and reports them with the same errors,
+// so services behave the same on both. Everything is lost on exit.
+type MemoryUserRepository struct {
+	mu sync.Mutex
+	users map[uuid.UUID]*domain.User
+	roles map[string]domain.Role
+	userRoles map[uuid.UUID]map[string]domain.RoleAssignment
+	changes []memoryProfileChange
+	outbox []*memoryOutboxEvent
+	outboxSeq int64
+}
+
+// NewMemoryUserRepository starts with the roles seeded by the migrations.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users: make(map[uuid.UUID]*domain.User),
+		roles: map[string]domain.Role{
+			"admin": {
+				Name: "admin",
+				Description: "full administrative access",
+				Permissions: []string{
+					domain.PermissionAuditRead, domain.PermissionRolesManage, domain.PermissionRolesRead,
+					domain.PermissionUsersManage, domain.PermissionUsersRead,
+				},
+			},
+			"support": {
+				Name: "support",
+				Description: "read-only access to user records",
+				Permissions: []string{domain.PermissionRolesRead, domain.PermissionUsersRead},
+			},
+		},
+		userRoles: make(map[uuid.UUID]map[string]domain.RoleAssignment),
+	}
+}
+
+// checkUserConstraints mirrors the CHECK constraints and column sizes of the users table.
+func checkUserConstraints(user *domain.User) error {
+	switch {
+	case utf8.RuneCountInString(user.FullName) > 127, utf8.RuneCountInString(user.Email) > 254, utf8.RuneCountInString(user.Phone) > 16:
+		return &ConstraintError{Err: ErrValueTooLong}
+	case strings.TrimSpace(user.FullName) == "":
+		return &ConstraintError{Column: "fullname", Err: ErrCheckViolation}
+	case !memoryEmailFormat.MatchString(user.Email):
+		return &ConstraintError{Column: "email", Err: ErrCheckViolation}
+	case !memoryPhoneFormat.MatchString(user.Phone):
+		return &ConstraintError{Column: "phone", Err: ErrCheckViolation}
+	case !user.BirthDate.IsZero() && !user.BirthDate.After(memoryMinBirthDate):
+		return &ConstraintError{Column: "birthdate", Err: ErrCheckViolation}
+	}
+	return nil
+}
+
+// conflicting finds another user holding the email (case-insensitively) or the phone.
+func (r *MemoryUserRepository) conflicting(userId uuid.UUID, email, phone string) (emailTaken, phoneTaken bool) {
+	for id, user := range r.users {
+		if id == userId {
+			continue
+		}
+		emailTaken = emailTaken || strings.EqualFold(user.Email, email)
+		phoneTaken = phoneTaken || user.Phone == phone
+	}
+	return emailTaken, phoneTaken
+}
+
+func copyUser(user *domain.User) *domain.User {
+	copied := *user
+	copied.PasswordHash = append([]byte(nil), user.PasswordHash...)
+	return &copied
+}
+
+func (r *MemoryUserRepository) Save(ctx context.Context, user domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := checkUserConstraints(&user); err != nil {
+		return err
+	}
+
+	emailTaken, phoneTaken := r.conflicting(user.Id, user.Email, user.Phone)
+	if _, idTaken := r.users[user.Id]; idTaken || emailTaken || phoneTaken {
+		return fmt.Errorf("unique constraint violation - %w", ErrUserAlreadyExists)
+	}
+
+	if user.RegisterDate.IsZero() {
+		user.RegisterDate = time.Now()
+	}
+
+	stored := copyUser(&user)
+	stored.Password = ""
+	stored.UpdatedAt = time.Now()
+	stored.Version = 1
+	stored.DeletedAt = time.Time{}
+	stored.Status = domain.UserStatusActive
+	stored.StatusReason = ""
+	stored.StatusChangedAt = time.Time{}
+	stored.SuspendedUntil = time.Time{}
+
+	if err := r.appendEvent(domain.EventUserRegistered, user.Id, domain.UserRegisteredPayload{
+		Email: user.Email,
+		FullName: user.FullName,
+		RegisterDate: user.RegisterDate.UTC(),
+	}); err != nil {
+		return err
+	}
+
+	r.users[user.Id] = stored
+	return nil
+}
+
+func (r *MemoryUserRepository) Get(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) && user.DeletedAt.IsZero() {
+			return copyUser(user), nil
+		}
+	}
+
+	return nil, fmt.Errorf("can't find user with email=%s - %w", email, ErrUserNotFound)
+}
+
+func (r *MemoryUserRepository) GetById(ctx context.Context, userId uuid.UUID) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok || !user.DeletedAt.IsZero() {
+		return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+	}
+
+	return copyUser(user), nil
+}
+
+func (r *MemoryUserRepository) GetIncludingDeleted(ctx context.Context, userId uuid.UUID) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+	}
+
+	return copyUser(user), nil
+}
+
+func (r *MemoryUserRepository) GetDeleted(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) && !user.DeletedAt.IsZero() {
+			return copyUser(user), nil
+		}
+	}
+
+	return nil, fmt.Errorf("can't find deleted user with email=%s - %w", email, ErrUserNotFound)
+}
+
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, userId uuid.UUID, update domain.ProfileUpdate) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[userId]
+	if !ok || !stored.DeletedAt.IsZero() {
+		return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+	}
+
+	user := copyUser(stored)
+	changes, err := applyProfileUpdate(user, update)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return user, nil
+	}
+
+	if err = checkUserConstraints(user); err != nil {
+		return nil, err
+	}
+
+	if _, phoneTaken := r.conflicting(userId, "", user.Phone); phoneTaken {
+		return nil, fmt.Errorf("unique constraint violation - %w", ErrPhoneAlreadyTaken)
+	}
+
+	user.Version++
+	user.UpdatedAt = time.Now()
+
+	r.users[userId] = user
+	r.saveProfileChanges(user, changes)
+
+	return copyUser(user), nil
+}
+
+func (r *MemoryUserRepository) UpdateEmail(ctx context.Context, userId uuid.UUID, oldEmail, newEmail string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[userId]
+	if !ok || !stored.DeletedAt.IsZero() {
+		return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+	}
+
+	if stored.Email != oldEmail {
+		return nil, fmt.Errorf("email changed since confirmation was requested - %w", ErrVersionConflict)
+	}
+
+	user := copyUser(stored)
+	user.Email = newEmail
+
+	if err := checkUserConstraints(user); err != nil {
+		return nil, err
+	}
+
+	if emailTaken, _ := r.conflicting(userId, newEmail, ""); emailTaken {
+		return nil, fmt.Errorf("unique constraint violation - %w", ErrEmailAlreadyTaken)
+	}
+
+	user.Version++
+	user.UpdatedAt = time.Now()
+
+	r.users[userId] = user
+	r.saveProfileChanges(user, []domain.ProfileChange{{Field: domain.ProfileFieldEmail, OldValue: oldEmail, NewValue: newEmail}})
+
+	return copyUser(user), nil
+}
+
+func (r *MemoryUserRepository) saveProfileChanges(user *domain.User, changes []domain.ProfileChange) {
+	for _, change := range changes {
+		r.changes = append(r.changes, memoryProfileChange{
+			userId: user.Id,
+			record: domain.ProfileChangeRecord{
+				Field: change.Field,
+				OldValue: change.OldValue,
+				NewValue: change.NewValue,
+				Version: user.Version,
+				ChangedAt: user.UpdatedAt,
+			},
+		})
+	}
+}
+
+func (r *MemoryUserRepository) UpdatePasswordHash(ctx context.Context, userId uuid.UUID, hash []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	err := r.appendEvent(domain.EventPasswordChanged, userId, domain.PasswordChangedPayload{ChangedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = append([]byte(nil), hash...)
+	return nil
+}
+
+// ListEmails returns id, email and registration date of every account, oldest first.
+func (r *MemoryUserRepository) ListEmails(ctx context.Context) ([]domain.UserPublic, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]domain.UserPublic, 0, len(r.users))
+	for _, user := range r.sortedUsers() {
+		result = append(result, domain.UserPublic{Id: user.Id, Email: user.Email, RegisterDate: user.RegisterDate})
+	}
+
+	return result, nil
+}
+
+func (r *MemoryUserRepository) ListProfileChanges(ctx context.Context, userId uuid.UUID) ([]domain.ProfileChangeRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]domain.ProfileChangeRecord, 0)
+	for _, change := range r.changes {
+		if change.userId == userId {
+			result = append(result, change.record)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *MemoryUserRepository) SoftDelete(ctx context.Context, userId uuid.UUID) error {
+	return r.setDeletedAt(userId, true)
+}
+
+func (r *MemoryUserRepository) Restore(ctx context.Context, userId uuid.UUID) error {
+	return r.setDeletedAt(userId, false)
+}
+
+func (r *MemoryUserRepository) setDeletedAt(userId uuid.UUID, deleted bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok || user.DeletedAt.IsZero() != deleted {
+		return ErrUserNotFound
+	}
+
+	now := time.Now().UTC()
+
+	var err error
+	if deleted {
+		err = r.appendEvent(domain.EventUserDeleted, userId, domain.UserDeletedPayload{DeletedAt: now})
+	} else {
+		err = r.appendEvent(domain.EventUserRestored, userId, domain.UserRestoredPayload{RestoredAt: now})
+	}
+	if err != nil {
+		return err
+	}
+
+	if deleted {
+		user.DeletedAt = now
+	} else {
+		user.DeletedAt = time.Time{}
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, user := range r.users {
+		if !user.DeletedAt.IsZero() && user.DeletedAt.Before(deletedBefore) {
+			r.remove(id)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+func (r *MemoryUserRepository) Delete(ctx context.Context, userId uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[userId]; !ok {
+		return ErrUserNotFound
+	}
+
+	r.remove(userId)
+	return nil
+}
+
+// remove drops the user with the rows referencing it, as ON DELETE CASCADE does.
+func (r *MemoryUserRepository) remove(userId uuid.UUID) {
+	delete(r.users, userId)
+	delete(r.userRoles, userId)
+
+	kept := r.changes[:0]
+	for _, change := range r.changes {
+		if change.userId != userId {
+			kept = append(kept, change)
+		}
+	}
+	r.changes = kept
+}
+
+// sortedUsers orders users by (registerDate, id) as the admin search does.
+func (r *MemoryUserRepository) sortedUsers() []*domain.User {
+	users := make([]*domain.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].RegisterDate.Equal(users[j].RegisterDate) {
+			return users[i].RegisterDate.Before(users[j].RegisterDate)
+		}
+		return users[i].Id.String() < users[j].Id.String()
+	})
+
+	return users
+}
+
+func (r *MemoryUserRepository) SearchUsers(ctx context.Context, search domain.UserSearch) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]domain.User, 0, search.Limit)
+	for _, user := range r.sortedUsers() {
+		if len(result) >= search.Limit {
+			break
+		}
+
+		if search.After != nil {
+			if user.RegisterDate.Before(search.After.RegisterDate) ||
+				user.RegisterDate.Equal(search.After.RegisterDate) && user.Id.String() <= search.After.Id.String() {
+				continue
+			}
+		}
+
+		if !strings.HasPrefix(strings.ToLower(user.Email), strings.ToLower(search.EmailPrefix)) ||
+			!strings.HasPrefix(strings.ToLower(user.FullName), strings.ToLower(search.FullNamePrefix)) ||
+			!strings.HasPrefix(user.Phone, search.PhonePrefix) {
+			continue
+		}
+
+		result = append(result, *copyUser(user))
+	}
+
+	return result, nil
+}
+
+func (r *MemoryUserRepository) SetStatus(ctx context.Context, userId uuid.UUID, change domain.StatusChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	user.Status = change.Status
+	user.StatusReason = change.Reason
+	user.StatusChangedAt = time.Now()
+	user.SuspendedUntil = time.Time{}
+	if change.Status == domain.UserStatusSuspended {
+		user.SuspendedUntil = change.Until
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) GetUserGrants(ctx context.Context, userId uuid.UUID) (*domain.Grants, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	grants := &domain.Grants{Roles: make([]string, 0), Permissions: make([]string, 0)}
+	seenPermissions := make(map[string]struct{})
+
+	for role := range r.userRoles[userId] {
+		grants.Roles = append(grants.Roles, role)
+		for _, permission := range r.roles[role].Permissions {
+			if _, ok := seenPermissions[permission]; !ok {
+				seenPermissions[permission] = struct{}{}
+				grants.Permissions = append(grants.Permissions, permission)
+			}
+		}
+	}
+
+	sort.Strings(grants.Roles)
+	sort.Strings(grants.Permissions)
+
+	return grants, nil
+}
+
+func (r *MemoryUserRepository) ListRoleAssignments(ctx context.Context, userId uuid.UUID) ([]domain.RoleAssignment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]domain.RoleAssignment, 0, len(r.userRoles[userId]))
+	for _, assignment := range r.userRoles[userId] {
+		result = append(result, assignment)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Role < result[j].Role })
+
+	return result, nil
+}
+
+func (r *MemoryUserRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]domain.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		role.Permissions = append([]string(nil), role.Permissions...)
+		result = append(result, role)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+func (r *MemoryUserRepository) AssignRole(ctx context.Context, assignment domain.RoleAssignment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[assignment.Role]; !ok {
+		return ErrRoleNotFound
+	}
+
+	if _, ok := r.users[assignment.UserId]; !ok {
+		return ErrUserNotFound
+	}
+
+	assigned, ok := r.userRoles[assignment.UserId]
+	if !ok {
+		assigned = make(map[string]domain.RoleAssignment)
+		r.userRoles[assignment.UserId] = assigned
+	}
+
+	if _, ok = assigned[assignment.Role]; !ok {
+		assigned[assignment.Role] = assignment
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) RevokeRole(ctx context.Context, userId uuid.UUID, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.userRoles[userId][role]; !ok {
+		return ErrRoleNotAssigned
+	}
+
+	delete(r.userRoles[userId], role)
+	return nil
+}
+
+// appendEvent must be called under the lock, together with the change it describes.
+func (r *MemoryUserRepository) appendEvent(eventType string, userId uuid.UUID, payload any) error {
+	event, err := domain.NewEvent(eventType, userId, payload)
+	if err != nil {
+		return err
+	}
+
+	r.insertEvent(event)
+	return nil
+}
+
+func (r *MemoryUserRepository) insertEvent(event domain.Event) {
+	r.outboxSeq++
+	r.outbox = append(r.outbox, &memoryOutboxEvent{
+		OutboxEvent: domain.OutboxEvent{Event: event},
+		seq: r.outboxSeq,
+		availableAt: time.Now(),
+	})
+}
+
+func (r *MemoryUserRepository) AppendEvent(ctx context.Context, event domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insertEvent(event)
+	return nil
+}
+
+func (r *MemoryUserRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	result := make([]domain.OutboxEvent, 0, limit)
+
+	// r.outbox is kept in seq order
+	for _, event := range r.outbox {
+		if len(result) >= limit {
+			break
+		}
+
+		if !event.publishedAt.IsZero() || event.availableAt.After(now) {
+			continue
+		}
+
+		event.availableAt = now.Add(lease)
+		event.Attempts++
+		result = append(result, event.OutboxEvent)
+	}
+
+	return result, nil
+}
+
+func (r *MemoryUserRepository) MarkPublished(ctx context.Context, ids []uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	published := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		published[id] = struct{}{}
+	}
+
+	now := time.Now()
+	for _, event := range r.outbox {
+		if _, ok := published[event.Id]; ok {
+			event.publishedAt = now
+			event.lastError = ""
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, event := range r.outbox {
+		if event.Id == id && event.publishedAt.IsZero() {
+			event.availableAt = retryAt
+			event.lastError = reason
+		}
+	}
+
+	return nil
+}
+
+func (r *MemoryUserRepository) DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	kept := r.outbox[:0]
+	for _, event := range r.outbox {
+		if !event.publishedAt.IsZero() && event.publishedAt.Before(publishedBefore) {
+			deleted++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	r.outbox = kept
+
+	return deleted, nil
+}
+
+func (r *MemoryUserRepository) Exit() {}
@@ -0,0 +1,23 @@
+package database
+
+import "expvar"
+
+const (
+	metricPrunedOnRead = "pruned_on_read"
+	metricPrunedBySweeper = "pruned_by_sweeper"
+	metricSweeps = "sweeps"
+)
+
+// sessionIndexMetrics is published by expvar as "session_index" at /debug/vars.
+var sessionIndexMetrics = expvar.NewMap("session_index")
+
+const (
+	metricCacheHits = "hits"
+	metricCacheMisses = "misses"
+	metricCacheEvictions = "evictions"
+	metricCacheInvalidations = "invalidations"
+	metricCachePublishFailures = "publish_failures"
+)
+
+// sessionCacheMetrics is published by expvar as "session_cache" at /debug/vars.
+var sessionCacheMetrics = expvar.NewMap("session_cache")
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS inx_profile_changes_user;
+
+DROP TABLE IF EXISTS profile_changes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS updatedAt;
+ALTER TABLE users DROP COLUMN IF EXISTS version;
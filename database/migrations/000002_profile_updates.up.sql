@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS updatedAt timestamp with time zone NOT NULL DEFAULT now();
+
+CREATE TABLE IF NOT EXISTS profile_changes (
+    id bigserial PRIMARY KEY,
+    userId uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    field varchar(32) NOT NULL,
+    oldValue text,
+    newValue text,
+    version integer NOT NULL,
+    changedAt timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS inx_profile_changes_user ON profile_changes(userId, changedAt);
@@ -0,0 +1,3 @@
+-- run `go run ./cmd/emailcollisions` first: the index can't be built while
+-- several accounts share an address that differs only in letter case
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_email_lower ON users(lower(email));
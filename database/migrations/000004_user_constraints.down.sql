@@ -0,0 +1,8 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_birthdate_range;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_phone_format;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_format;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_fullname_not_blank;
+
+-- fails if longer values were stored meanwhile
+ALTER TABLE users ALTER COLUMN phone TYPE varchar(12);
+ALTER TABLE users ALTER COLUMN email TYPE varchar(32);
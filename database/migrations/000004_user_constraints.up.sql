@@ -0,0 +1,8 @@
+ALTER TABLE users ALTER COLUMN email TYPE varchar(254);
+ALTER TABLE users ALTER COLUMN phone TYPE varchar(16);
+
+-- NOT VALID: enforced for new writes only, rows stored before the checks existed are kept as is
+ALTER TABLE users ADD CONSTRAINT users_fullname_not_blank CHECK (char_length(btrim(fullName)) > 0) NOT VALID;
+ALTER TABLE users ADD CONSTRAINT users_email_format CHECK (email LIKE '_%@_%') NOT VALID;
+ALTER TABLE users ADD CONSTRAINT users_phone_format CHECK (phone ~ '^\+?[0-9]{10,15}$') NOT VALID;
+ALTER TABLE users ADD CONSTRAINT users_birthdate_range CHECK (birthDate > DATE '1900-01-01') NOT VALID;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS inx_users_deleted;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deletedAt;
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletedAt timestamp with time zone;
+
+CREATE INDEX IF NOT EXISTS inx_users_deleted ON users(deletedAt) WHERE deletedAt IS NOT NULL;
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name varchar(64) PRIMARY KEY,
+    description text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name varchar(64) PRIMARY KEY,
+    description text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role varchar(64) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission varchar(64) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    userId uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role varchar(64) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    grantedBy uuid,
+    grantedAt timestamp with time zone NOT NULL DEFAULT now(),
+    PRIMARY KEY (userId, role)
+);
+
+INSERT INTO permissions(name, description) VALUES
+    ('users:read', 'search users and read full user records'),
+    ('users:manage', 'change state of other users'' accounts'),
+    ('roles:read', 'list roles and role assignments'),
+    ('roles:manage', 'assign and revoke roles')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO roles(name, description) VALUES
+    ('admin', 'full administrative access'),
+    ('support', 'read-only access to user records')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions(role, permission) VALUES
+    ('admin', 'users:read'),
+    ('admin', 'users:manage'),
+    ('admin', 'roles:read'),
+    ('admin', 'roles:manage'),
+    ('support', 'users:read'),
+    ('support', 'roles:read')
+ON CONFLICT DO NOTHING;
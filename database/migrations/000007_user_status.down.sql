@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS inx_users_phone_prefix;
+DROP INDEX IF EXISTS inx_users_fullname_prefix;
+DROP INDEX IF EXISTS inx_users_email_prefix;
+DROP INDEX IF EXISTS inx_users_register_order;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_known;
+ALTER TABLE users DROP COLUMN IF EXISTS statusChangedAt;
+ALTER TABLE users DROP COLUMN IF EXISTS statusReason;
+ALTER TABLE users DROP COLUMN IF EXISTS status;
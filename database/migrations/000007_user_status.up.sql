@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status varchar(32) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS statusReason text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS statusChangedAt timestamp with time zone;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_known;
+ALTER TABLE users ADD CONSTRAINT users_status_known CHECK (status IN ('active', 'disabled'));
+
+-- admin search walks users in registration order with keyset pagination
+CREATE INDEX IF NOT EXISTS inx_users_register_order ON users(registerDate, id);
+CREATE INDEX IF NOT EXISTS inx_users_email_prefix ON users(lower(email) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS inx_users_fullname_prefix ON users(lower(fullName) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS inx_users_phone_prefix ON users(phone text_pattern_ops);
@@ -0,0 +1,6 @@
+UPDATE users SET status='disabled' WHERE status IN ('suspended', 'pending_verification');
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_known;
+ALTER TABLE users ADD CONSTRAINT users_status_known CHECK (status IN ('active', 'disabled'));
+
+ALTER TABLE users DROP COLUMN IF EXISTS suspendedUntil;
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspendedUntil timestamp with time zone;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_status_known;
+ALTER TABLE users ADD CONSTRAINT users_status_known
+    CHECK (status IN ('active', 'suspended', 'disabled', 'pending_verification'));
@@ -0,0 +1,4 @@
+DELETE FROM role_permissions WHERE permission='audit:read';
+DELETE FROM permissions WHERE name='audit:read';
+
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id bigserial PRIMARY KEY,
+    occurredAt timestamp with time zone NOT NULL,
+    action varchar(64) NOT NULL,
+    actor uuid,
+    target uuid,
+    ipAddress text NOT NULL DEFAULT '',
+    userAgent text NOT NULL DEFAULT '',
+    outcome varchar(16) NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    metadata jsonb NOT NULL DEFAULT '{}',
+    -- filled in the hash-chained mode only: hash = sha256(prevHash || event)
+    prevHash bytea,
+    hash bytea
+);
+
+-- audit rows outlive purged accounts, so actor and target are not foreign keys
+CREATE INDEX IF NOT EXISTS inx_audit_events_target ON audit_events(target, id DESC);
+CREATE INDEX IF NOT EXISTS inx_audit_events_actor ON audit_events(actor, id DESC);
+
+INSERT INTO permissions(name, description) VALUES
+    ('audit:read', 'read the security audit log of any user')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions(role, permission) VALUES
+    ('admin', 'audit:read')
+ON CONFLICT DO NOTHING;
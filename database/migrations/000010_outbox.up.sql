@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    -- id is the idempotency key of the event, it stays the same on every delivery attempt
+    id uuid PRIMARY KEY,
+    seq bigserial NOT NULL,
+    type varchar(64) NOT NULL,
+    userId uuid NOT NULL,
+    payload jsonb NOT NULL,
+    occurredAt timestamp with time zone NOT NULL,
+    -- a claimed event is invisible to other relays until availableAt
+    availableAt timestamp with time zone NOT NULL DEFAULT now(),
+    attempts integer NOT NULL DEFAULT 0,
+    lastError text NOT NULL DEFAULT '',
+    publishedAt timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS inx_outbox_events_pending ON outbox_events(availableAt, seq) WHERE publishedAt IS NULL;
+CREATE INDEX IF NOT EXISTS inx_outbox_events_published ON outbox_events(publishedAt) WHERE publishedAt IS NOT NULL;
@@ -0,0 +1,25 @@
+-- sessions and confirmation tokens for deployments that keep them in Postgres instead of Redis,
+-- only hashes of the tokens are stored
+CREATE TABLE IF NOT EXISTS sessions (
+    tokenHash bytea PRIMARY KEY,
+    userId uuid NOT NULL,
+    email varchar(255) NOT NULL,
+    createdAt timestamp with time zone NOT NULL,
+    authTime timestamp with time zone NOT NULL,
+    ipAddress text NOT NULL DEFAULT '',
+    userAgent text NOT NULL DEFAULT '',
+    expiresAt timestamp with time zone NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS inx_sessions_user ON sessions(userId);
+CREATE INDEX IF NOT EXISTS inx_sessions_expires ON sessions(expiresAt);
+
+CREATE TABLE IF NOT EXISTS confirmation_tokens (
+    purpose varchar(64) NOT NULL,
+    tokenHash bytea NOT NULL,
+    payload jsonb NOT NULL,
+    expiresAt timestamp with time zone NOT NULL,
+    PRIMARY KEY (purpose, tokenHash)
+);
+
+CREATE INDEX IF NOT EXISTS inx_confirmation_tokens_expires ON confirmation_tokens(expiresAt);
@@ -0,0 +1,7 @@
+-- failed login counters for deployments that keep sessions in Postgres instead of Redis,
+-- a row only counts until expiresAt
+CREATE TABLE IF NOT EXISTS login_failures (
+    userId uuid PRIMARY KEY,
+    failures integer NOT NULL,
+    expiresAt timestamp with time zone NOT NULL
+);
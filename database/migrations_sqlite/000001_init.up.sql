@@ -0,0 +1,128 @@
+-- SQLite counterpart of the Postgres migrations 000001-000010 in one step.
+-- Timestamps are stored as UTC text written by the application, so they compare in order.
+-- varchar sizes are not enforced by SQLite, the *_length checks stand in for them.
+
+CREATE TABLE IF NOT EXISTS users (
+    id text PRIMARY KEY NOT NULL,
+    fullName text NOT NULL
+        CONSTRAINT users_fullname_length CHECK (length(fullName) <= 127)
+        CONSTRAINT users_fullname_not_blank CHECK (length(trim(fullName)) > 0),
+    email text NOT NULL
+        CONSTRAINT users_email_length CHECK (length(email) <= 254)
+        CONSTRAINT users_email_format CHECK (email LIKE '_%@_%'),
+    phone text NOT NULL UNIQUE
+        CONSTRAINT users_phone_length CHECK (length(phone) <= 16)
+        CONSTRAINT users_phone_format CHECK (
+            length(CASE WHEN substr(phone, 1, 1) = '+' THEN substr(phone, 2) ELSE phone END) BETWEEN 10 AND 15
+            AND (CASE WHEN substr(phone, 1, 1) = '+' THEN substr(phone, 2) ELSE phone END) NOT GLOB '*[^0-9]*'
+        ),
+    password blob NOT NULL,
+    birthDate date CONSTRAINT users_birthdate_range CHECK (birthDate > '1900-01-01'),
+    registerDate timestamp NOT NULL,
+    updatedAt timestamp NOT NULL,
+    version integer NOT NULL DEFAULT 1,
+    deletedAt timestamp,
+    status text NOT NULL DEFAULT 'active'
+        CONSTRAINT users_status_known CHECK (status IN ('active', 'suspended', 'disabled', 'pending_verification')),
+    statusReason text NOT NULL DEFAULT '',
+    statusChangedAt timestamp,
+    suspendedUntil timestamp
+);
+
+-- lower() folds ASCII letters only, unlike Postgres
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_email_lower ON users(lower(email));
+CREATE INDEX IF NOT EXISTS inx_users_deleted ON users(deletedAt) WHERE deletedAt IS NOT NULL;
+CREATE INDEX IF NOT EXISTS inx_users_register_order ON users(registerDate, id);
+
+CREATE TABLE IF NOT EXISTS profile_changes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    userId text NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    field text NOT NULL,
+    oldValue text,
+    newValue text,
+    version integer NOT NULL,
+    changedAt timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS inx_profile_changes_user ON profile_changes(userId, changedAt);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name text PRIMARY KEY,
+    description text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name text PRIMARY KEY,
+    description text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role text NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission text NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    userId text NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role text NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    grantedBy text,
+    grantedAt timestamp NOT NULL,
+    PRIMARY KEY (userId, role)
+);
+
+INSERT INTO permissions(name, description) VALUES
+    ('users:read', 'search users and read full user records'),
+    ('users:manage', 'change state of other users'' accounts'),
+    ('roles:read', 'list roles and role assignments'),
+    ('roles:manage', 'assign and revoke roles'),
+    ('audit:read', 'read the security audit log of any user')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO roles(name, description) VALUES
+    ('admin', 'full administrative access'),
+    ('support', 'read-only access to user records')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions(role, permission) VALUES
+    ('admin', 'users:read'),
+    ('admin', 'users:manage'),
+    ('admin', 'roles:read'),
+    ('admin', 'roles:manage'),
+    ('admin', 'audit:read'),
+    ('support', 'users:read'),
+    ('support', 'roles:read')
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    occurredAt timestamp NOT NULL,
+    action text NOT NULL,
+    actor text,
+    target text,
+    ipAddress text NOT NULL DEFAULT '',
+    userAgent text NOT NULL DEFAULT '',
+    outcome text NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    metadata blob NOT NULL DEFAULT '{}',
+    prevHash blob,
+    hash blob
+);
+
+CREATE INDEX IF NOT EXISTS inx_audit_events_target ON audit_events(target, id DESC);
+CREATE INDEX IF NOT EXISTS inx_audit_events_actor ON audit_events(actor, id DESC);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    seq integer PRIMARY KEY AUTOINCREMENT,
+    id text NOT NULL UNIQUE,
+    type text NOT NULL,
+    userId text NOT NULL,
+    payload blob NOT NULL,
+    occurredAt timestamp NOT NULL,
+    availableAt timestamp NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    lastError text NOT NULL DEFAULT '',
+    publishedAt timestamp
+);
+
+CREATE INDEX IF NOT EXISTS inx_outbox_events_pending ON outbox_events(availableAt, seq) WHERE publishedAt IS NULL;
+CREATE INDEX IF NOT EXISTS inx_outbox_events_published ON outbox_events(publishedAt) WHERE publishedAt IS NOT NULL;
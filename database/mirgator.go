@@ -1,25 +1,47 @@
 package database
 
 import (
+	"embed"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
+	_ "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// the migrations are built into the binary, SQLite has its own set in migrations_sqlite
+var (
+	//go:embed migrations/*.sql
+	postgresMigrations embed.FS
+
+	//go:embed migrations_sqlite/*.sql
+	sqliteMigrations embed.FS
 )
 
 type Migrator struct {
 	migrationTool 	*migrate.Migrate
 }
 
-func NewMigrator(conf *Config, path string) (*Migrator, error) {
-	newMigrationTool, err := migrate.New(
-        "file://" + path, conf.GetPgConnString(false))
+func NewMigrator(conf *Config) (*Migrator, error) {
+	// the pgx driver of migrate is registered as pgx5, the rest of the URL is the usual one
+	databaseURL := "pgx5" + strings.TrimPrefix(conf.GetPgConnString(false), conf.Driver)
+	migrations, dir := postgresMigrations, "migrations"
+	if conf.Driver == DriverSQLite {
+		databaseURL = "sqlite://" + conf.DBName + "?_pragma=foreign_keys(1)"
+		migrations, dir = sqliteMigrations, "migrations_sqlite"
+	}
+
+	source, err := iofs.New(migrations, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migration source opening failed: %w", err)
+	}
 
+	newMigrationTool, err := migrate.NewWithSourceInstance("iofs", source, databaseURL)
 	if err != nil {
-		fmt.Println("Error occured trying to init migration tool - ", err)
-		return nil, err
+		return nil, fmt.Errorf("migration tool init failed: %w", err)
 	}
 
 	return &Migrator{
@@ -27,36 +49,54 @@ func NewMigrator(conf *Config, path string) (*Migrator, error) {
 	}, nil
 }
 
+// Apply migrates up to the latest version, an up-to-date database is not an error.
 func (m *Migrator) Apply() error {
-	err := m.migrationTool.Up()
-
-	if err == migrate.ErrNoChange {
-		fmt.Println("Nothing to apply")
-		return nil
+	if err := m.migrationTool.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrations apply failed: %w", err)
 	}
+	return nil
+}
 
-	if err != nil {
-		fmt.Println("Can't apply migrations - ", err)
-		return err
+// RollBack reverts the last steps migrations.
+func (m *Migrator) RollBack(steps int) error {
+	if err := m.migrationTool.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("rollback failed: %w", err)
 	}
-
-	fmt.Println("Migration(s) applied successfully")
 	return nil
 }
 
-func (m *Migrator) RollBack(steps int) error {
-	err := m.migrationTool.Steps(-steps)
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(version uint) error {
+	if err := m.migrationTool.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migration to version %d failed: %w", version, err)
+	}
+	return nil
+}
 
-	if err == migrate.ErrNoChange {
-		fmt.Println("Nothing to rollback")
-		return nil
+// Force sets the version and clears the dirty flag without running anything. It is the way
+// out of a failed migration once the database has been fixed by hand, -1 means no version.
+func (m *Migrator) Force(version int) error {
+	if err := m.migrationTool.Force(version); err != nil {
+		return fmt.Errorf("forcing version %d failed: %w", version, err)
 	}
+	return nil
+}
 
+// Status returns the current version, 0 when nothing is applied, and whether the last
+// migration failed halfway.
+func (m *Migrator) Status() (uint, bool, error) {
+	version, dirty, err := m.migrationTool.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
 	if err != nil {
-		fmt.Println("Rollback failed - ", err)
-		return err
+		return 0, false, fmt.Errorf("migration status failed: %w", err)
 	}
+	return version, dirty, nil
+}
 
-	fmt.Println("Rollback complete")
-	return nil
+func (m *Migrator) Close() {
+	if m.migrationTool != nil {
+		m.migrationTool.Close()
+	}
 }
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+// pgxExecer is either the pool or a transaction.
+type pgxExecer interface {
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+}
+
+// appendEvent writes the event within the caller's transaction, so it is
+// published if and only if the change it describes is committed.
+func appendEvent(ctx context.Context, db pgxExecer, eventType string, userId uuid.UUID, payload any) error {
+	event, err := domain.NewEvent(eventType, userId, payload)
+	if err != nil {
+		return err
+	}
+
+	return insertEvent(ctx, db, event)
+}
+
+func insertEvent(ctx context.Context, db pgxExecer, event domain.Event) error {
+	query := "INSERT INTO outbox_events(id, type, userId, payload, occurredAt) VALUES ($1, $2, $3, $4, $5);"
+	if _, err := db.Exec(ctx, query, event.Id, event.Type, event.UserId, []byte(event.Payload), event.OccurredAt); err != nil {
+		return fmt.Errorf("outbox append failed: %w", err)
+	}
+	return nil
+}
+
+// AppendEvent writes an event that has no Postgres change to go with, e.g. a revoked Redis session.
+func (r *UserRepository) AppendEvent(ctx context.Context, event domain.Event) error {
+	return insertEvent(ctx, r.pool, event)
+}
+
+// ClaimEvents leases up to limit pending events, oldest first. Claimed events are hidden from
+// other relays for the lease, so an event whose relay died is delivered again once it runs out.
+func (r *UserRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	// RETURNING doesn't keep the order of the subquery, hence the outer SELECT
+	query := `WITH claimed AS (
+			UPDATE outbox_events SET availableAt = now() + $2 * interval '1 second', attempts = attempts + 1
+			WHERE id IN (
+				SELECT id FROM outbox_events
+				WHERE publishedAt IS NULL AND availableAt <= now()
+				ORDER BY seq
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, type, userId, payload, occurredAt, attempts, seq
+		)
+		SELECT id, type, userId, payload, occurredAt, attempts FROM claimed ORDER BY seq;`
+
+	rows, err := r.pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("outbox claim failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.OutboxEvent, 0, limit)
+	for rows.Next() {
+		event := domain.OutboxEvent{}
+		payload := []byte{}
+		if err = rows.Scan(&event.Id, &event.Type, &event.UserId, &payload, &event.OccurredAt, &event.Attempts); err != nil {
+			return nil, fmt.Errorf("outbox claim failed: %w", err)
+		}
+		event.Payload = payload
+		event.OccurredAt = event.OccurredAt.UTC()
+		result = append(result, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox claim failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *UserRepository) MarkPublished(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := "UPDATE outbox_events SET publishedAt=now(), lastError='' WHERE id = ANY($1);"
+	if _, err := r.pool.Exec(ctx, query, ids); err != nil {
+		return fmt.Errorf("outbox mark published failed: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed keeps the event pending and postpones its next attempt.
+func (r *UserRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error {
+	query := "UPDATE outbox_events SET availableAt=$1, lastError=$2 WHERE id=$3 AND publishedAt IS NULL;"
+	if _, err := r.pool.Exec(ctx, query, retryAt, reason, id); err != nil {
+		return fmt.Errorf("outbox retry schedule failed: %w", err)
+	}
+	return nil
+}
+
+// DeletePublished drops delivered events older than the given moment.
+func (r *UserRepository) DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	query := "DELETE FROM outbox_events WHERE publishedAt IS NOT NULL AND publishedAt < $1;"
+
+	result, err := r.pool.Exec(ctx, query, publishedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("outbox cleanup failed: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+const sessionColumns = "userId, email, createdAt, authTime, ipAddress, userAgent"
+
+// PgSessionRepository keeps refresh sessions in the sessions table with the semantics of
+// SessionRepository. Only the SHA-256 of a token is stored, expired rows are invisible to
+// reads and are deleted by PruneExpiredSessions.
+//
+// The pool is shared with PgTokenRepository, see NewPgPool.
+type PgSessionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgSessionRepository(pool *pgxpool.Pool) *PgSessionRepository {
+	return &PgSessionRepository{
+		pool: pool,
+	}
+}
+
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+func scanSession(row rowScanner) (*domain.Session, error) {
+	session := domain.Session{}
+	if err := row.Scan(&session.UserId, &session.Email, &session.CreatedAt, &session.AuthTime, &session.IpAddress, &session.UserAgent); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+type sessionExecer interface {
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+}
+
+func insertSession(ctx context.Context, db sessionExecer, token string, session *domain.Session, expiresIn time.Duration) error {
+	_, err := db.Exec(ctx,
+		"INSERT INTO sessions (tokenHash, "+sessionColumns+", expiresAt) VALUES ($1, $2, $3, $4, $5, $6, $7, now() + $8 * interval '1 millisecond')",
+		hashToken(token), session.UserId, session.Email, session.CreatedAt, session.AuthTime, session.IpAddress, session.UserAgent, expiresIn.Milliseconds(),
+	)
+	return err
+}
+
+func (r *PgSessionRepository) Save(ctx context.Context, session *domain.Session, expiresIn time.Duration) (string, error) {
+	token := generateRefreshToken()
+	if err := insertSession(ctx, r.pool, token, session, expiresIn); err != nil {
+		return "", fmt.Errorf("session saving failed: %w", err)
+	}
+
+	return token, nil
+}
+
+// Rotate locks the row of the old token, so of concurrent rotations of one token only the
+// first succeeds, the rest find the row gone and get ErrSessionNotFound.
+func (r *PgSessionRepository) Rotate(ctx context.Context, token string, session *domain.Session, expiresIn time.Duration) (string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("transaction begin failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var userId uuid.UUID
+	err = tx.QueryRow(ctx,
+		"SELECT userId FROM sessions WHERE tokenHash = $1 AND expiresAt > now() FOR UPDATE", hashToken(token),
+	).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrSessionNotFound
+		}
+		return "", fmt.Errorf("session lock failed: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, "DELETE FROM sessions WHERE tokenHash = $1", hashToken(token)); err != nil {
+		return "", fmt.Errorf("session rotation failed: %w", err)
+	}
+
+	newToken := generateRefreshToken()
+	if err = insertSession(ctx, tx, newToken, session, expiresIn); err != nil {
+		return "", fmt.Errorf("session rotation failed: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return newToken, nil
+}
+
+func (r *PgSessionRepository) Delete(ctx context.Context, token string) error {
+	result, err := r.pool.Exec(ctx, "DELETE FROM sessions WHERE tokenHash = $1 AND expiresAt > now()", hashToken(token))
+	if err != nil {
+		return fmt.Errorf("can't delete user session: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (r *PgSessionRepository) DeleteUserSessions(ctx context.Context, userId uuid.UUID) error {
+	if _, err := r.pool.Exec(ctx, "DELETE FROM sessions WHERE userId = $1", userId); err != nil {
+		return fmt.Errorf("user sessions delete failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PgSessionRepository) Get(ctx context.Context, token string) (*domain.Session, error) {
+	session, err := scanSession(r.pool.QueryRow(ctx,
+		"SELECT "+sessionColumns+" FROM sessions WHERE tokenHash = $1 AND expiresAt > now()", hashToken(token),
+	))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("session retrieve op failed: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *PgSessionRepository) GetUserSessions(ctx context.Context, userId uuid.UUID) ([]*domain.Session, error) {
+	rows, err := r.pool.Query(ctx,
+		"SELECT "+sessionColumns+" FROM sessions WHERE userId = $1 AND expiresAt > now() ORDER BY createdAt", userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("user sessions search failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := []*domain.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("user sessions search failed: %w", err)
+		}
+		result = append(result, session)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("user sessions search failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// PruneExpiredSessions deletes the expired rows, SessionSweeper calls it periodically.
+func (r *PgSessionRepository) PruneExpiredSessions(ctx context.Context) (int64, error) {
+	result, err := r.pool.Exec(ctx, "DELETE FROM sessions WHERE expiresAt <= now()")
+	if err != nil {
+		return 0, fmt.Errorf("expired sessions delete failed: %w", err)
+	}
+
+	pruned := result.RowsAffected()
+	sessionIndexMetrics.Add(metricPrunedBySweeper, pruned)
+	sessionIndexMetrics.Add(metricSweeps, 1)
+
+	return pruned, nil
+}
+
+// UpdateUserEmail rewrites the email of every live session of the user, keeping their expiry.
+func (r *PgSessionRepository) UpdateUserEmail(ctx context.Context, userId uuid.UUID, email string) error {
+	_, err := r.pool.Exec(ctx, "UPDATE sessions SET email = $2 WHERE userId = $1 AND expiresAt > now()", userId, email)
+	if err != nil {
+		return fmt.Errorf("session update failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PgSessionRepository) Exit() {
+	if r.pool != nil {
+		r.pool.Close()
+	}
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PgTokenRepository keeps single-use confirmation tokens in the confirmation_tokens table and
+// failed login counters in login_failures, it goes along with PgSessionRepository when Redis is not available.
+type PgTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgTokenRepository(pool *pgxpool.Pool) *PgTokenRepository {
+	return &PgTokenRepository{
+		pool: pool,
+	}
+}
+
+func (r *PgTokenRepository) Issue(ctx context.Context, purpose string, payload any, expiresIn time.Duration) (string, error) {
+	binary, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("token payload serialization error: %w", err)
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("token generation failed: %w", err)
+	}
+
+	// expired tokens of the purpose are cleaned up on the way, they can't be consumed anyway
+	_, err = r.pool.Exec(ctx, `
+		WITH expired AS (DELETE FROM confirmation_tokens WHERE purpose = $1 AND expiresAt <= now())
+		INSERT INTO confirmation_tokens (purpose, tokenHash, payload, expiresAt)
+		VALUES ($1, $2, $3, now() + $4 * interval '1 millisecond')`,
+		purpose, hashToken(token), binary, expiresIn.Milliseconds(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("token saving failed: %w", err)
+	}
+
+	return token, nil
+}
+
+// Peek reads the payload of an unexpired token without deleting it.
+func (r *PgTokenRepository) Peek(ctx context.Context, purpose, token string, payload any) error {
+	var binary []byte
+	err := r.pool.QueryRow(ctx,
+		"SELECT payload FROM confirmation_tokens WHERE purpose = $1 AND tokenHash = $2 AND expiresAt > now()",
+		purpose, hashToken(token),
+	).Scan(&binary)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTokenNotFound
+		}
+		return fmt.Errorf("token retrieve op failed: %w", err)
+	}
+
+	if err = json.Unmarshal(binary, payload); err != nil {
+		return fmt.Errorf("token payload deserialization error: %w", err)
+	}
+
+	return nil
+}
+
+// Consume deletes the token and returns its payload in one statement, so it can be redeemed only once.
+func (r *PgTokenRepository) Consume(ctx context.Context, purpose, token string, payload any) error {
+	var binary []byte
+	err := r.pool.QueryRow(ctx,
+		"DELETE FROM confirmation_tokens WHERE purpose = $1 AND tokenHash = $2 AND expiresAt > now() RETURNING payload",
+		purpose, hashToken(token),
+	).Scan(&binary)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTokenNotFound
+		}
+		return fmt.Errorf("token retrieve op failed: %w", err)
+	}
+
+	if err = json.Unmarshal(binary, payload); err != nil {
+		return fmt.Errorf("token payload deserialization error: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PgTokenRepository) FailedLogins(ctx context.Context, userId uuid.UUID) (int, time.Time, error) {
+	var (
+		failures int
+		expiresAt time.Time
+	)
+	err := r.pool.QueryRow(ctx,
+		"SELECT failures, expiresAt FROM login_failures WHERE userId = $1 AND expiresAt > now()",
+		userId,
+	).Scan(&failures, &expiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, time.Time{}, nil
+		}
+		return 0, time.Time{}, fmt.Errorf("failed logins retrieve op failed: %w", err)
+	}
+
+	return failures, expiresAt, nil
+}
+
+// RecordFailedLogin starts a new window when the previous one is over, so an expired row
+// doesn't need a cleanup job.
+func (r *PgTokenRepository) RecordFailedLogin(ctx context.Context, userId uuid.UUID, window time.Duration) (int, time.Time, error) {
+	var (
+		failures int
+		expiresAt time.Time
+	)
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO login_failures (userId, failures, expiresAt)
+		VALUES ($1, 1, now() + $2 * interval '1 millisecond')
+		ON CONFLICT (userId) DO UPDATE SET
+			failures = CASE WHEN login_failures.expiresAt <= now() THEN 1 ELSE login_failures.failures + 1 END,
+			expiresAt = CASE WHEN login_failures.expiresAt <= now() THEN EXCLUDED.expiresAt ELSE login_failures.expiresAt END
+		RETURNING failures, expiresAt`,
+		userId, window.Milliseconds(),
+	).Scan(&failures, &expiresAt)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed login saving failed: %w", err)
+	}
+
+	return failures, expiresAt, nil
+}
+
+func (r *PgTokenRepository) ResetFailedLogins(ctx context.Context, userId uuid.UUID) error {
+	if _, err := r.pool.Exec(ctx, "DELETE FROM login_failures WHERE userId = $1", userId); err != nil {
+		return fmt.Errorf("failed logins reset failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteOneTimePasswords has nothing to drop, one-time passwords are only kept in Redis.
+func (r *PgTokenRepository) DeleteOneTimePasswords(ctx context.Context, userId uuid.UUID) error {
+	return nil
+}
+
+func (r *PgTokenRepository) Exit() {
+	if r.pool != nil {
+		r.pool.Close()
+	}
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+var (
+	ErrRoleNotFound = errors.New("no such role")
+	ErrRoleNotAssigned = errors.New("role is not assigned to the user")
+)
+
+// GetUserGrants returns the roles assigned to the user and the union of their permissions.
+func (r *UserRepository) GetUserGrants(ctx context.Context, userId uuid.UUID) (*domain.Grants, error) {
+	query := `SELECT ur.role, rp.permission
+		FROM user_roles ur LEFT JOIN role_permissions rp ON rp.role = ur.role
+		WHERE ur.userId=$1 ORDER BY ur.role, rp.permission;`
+
+	rows, err := r.pool.Query(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("user grants retrieve operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	grants := &domain.Grants{Roles: make([]string, 0), Permissions: make([]string, 0)}
+	seenRoles := make(map[string]struct{})
+	seenPermissions := make(map[string]struct{})
+
+	for rows.Next() {
+		var (
+			role string
+			permission sql.NullString
+		)
+		if err = rows.Scan(&role, &permission); err != nil {
+			return nil, fmt.Errorf("user grants retrieve operation failed: %w", err)
+		}
+
+		if _, ok := seenRoles[role]; !ok {
+			seenRoles[role] = struct{}{}
+			grants.Roles = append(grants.Roles, role)
+		}
+
+		if _, ok := seenPermissions[permission.String]; permission.Valid && !ok {
+			seenPermissions[permission.String] = struct{}{}
+			grants.Permissions = append(grants.Permissions, permission.String)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("user grants retrieve operation failed: %w", err)
+	}
+
+	return grants, nil
+}
+
+// ListRoleAssignments returns the roles assigned to the user with who granted them and when.
+func (r *UserRepository) ListRoleAssignments(ctx context.Context, userId uuid.UUID) ([]domain.RoleAssignment, error) {
+	query := "SELECT role, grantedBy, grantedAt FROM user_roles WHERE userId=$1 ORDER BY role;"
+
+	rows, err := r.pool.Query(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("role assignments retrieve operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.RoleAssignment, 0)
+	for rows.Next() {
+		assignment := domain.RoleAssignment{UserId: userId}
+		var grantedBy uuid.NullUUID
+		if err = rows.Scan(&assignment.Role, &grantedBy, &assignment.GrantedAt); err != nil {
+			return nil, fmt.Errorf("role assignments retrieve operation failed: %w", err)
+		}
+		assignment.GrantedBy = grantedBy.UUID
+		result = append(result, assignment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("role assignments retrieve operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *UserRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	query := `SELECT r.name, r.description, coalesce(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r LEFT JOIN role_permissions rp ON rp.role = r.name
+		GROUP BY r.name, r.description ORDER BY r.name;`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("role list operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.Role, 0)
+	for rows.Next() {
+		role := domain.Role{}
+		if err = rows.Scan(&role.Name, &role.Description, &role.Permissions); err != nil {
+			return nil, fmt.Errorf("role list operation failed: %w", err)
+		}
+		result = append(result, role)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("role list operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// AssignRole is idempotent: assigning a role the user already has changes nothing.
+func (r *UserRepository) AssignRole(ctx context.Context, assignment domain.RoleAssignment) error {
+	query := `INSERT INTO user_roles(userId, role, grantedBy, grantedAt) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (userId, role) DO NOTHING;`
+
+	grantedBy := uuid.NullUUID{UUID: assignment.GrantedBy, Valid: assignment.GrantedBy != uuid.Nil}
+
+	_, err := r.pool.Exec(ctx, query, assignment.UserId, assignment.Role, grantedBy, assignment.GrantedAt)
+	if err != nil {
+		if pgerr, ok := pgError(err); ok && pgerr.Code == pgerrcode.ForeignKeyViolation {
+			switch pgerr.ConstraintName {
+			case "user_roles_role_fkey":
+				return ErrRoleNotFound
+			case "user_roles_userid_fkey":
+				return ErrUserNotFound
+			}
+		}
+		return fmt.Errorf("role assign operation failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) RevokeRole(ctx context.Context, userId uuid.UUID, role string) error {
+	query := "DELETE FROM user_roles WHERE userId=$1 AND role=$2;"
+
+	result, err := r.pool.Exec(ctx, query, userId, role)
+	if err != nil {
+		return fmt.Errorf("role revoke operation failed: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrRoleNotAssigned
+	}
+
+	return nil
+}
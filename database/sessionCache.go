@@ -0,0 +1,316 @@
+package database
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const DefaultSessionCacheTTL = 5 * time.Second
+
+// Invalidation messages are "<kind>:<subject>". A token message drops one session, a list
+// message drops the cached session list of the user and a user message drops both the list
+// and every cached session of the user.
+const (
+	invalidateToken = "token"
+	invalidateList = "list"
+	invalidateUser = "user"
+)
+
+// SessionBackend is the session store SessionCache sits in front of.
+type SessionBackend interface {
+	Save(ctx context.Context, session *domain.Session, expiresIn time.Duration) (string, error)
+	Rotate(ctx context.Context, token string, session *domain.Session, expiresIn time.Duration) (string, error)
+	Delete(ctx context.Context, token string) error
+	DeleteUserSessions(ctx context.Context, userId uuid.UUID) error
+	Get(ctx context.Context, token string) (*domain.Session, error)
+	GetUserSessions(ctx context.Context, userId uuid.UUID) ([]*domain.Session, error)
+	PruneExpiredSessions(ctx context.Context) (int64, error)
+	UpdateUserEmail(ctx context.Context, userId uuid.UUID, email string) error
+	Exit()
+}
+
+// InvalidationBus carries cache invalidations between the instances sharing a session store.
+type InvalidationBus interface {
+	Publish(ctx context.Context, message string) error
+	// Listen passes every message to handle until ctx is cancelled. resync is called whenever
+	// messages may have been missed, on every (re)subscription included.
+	Listen(ctx context.Context, handle func(message string), resync func())
+	Close() error
+}
+
+// SessionCache keeps recent session reads in process memory for at most ttl. Writes go
+// straight to the backend and invalidate the affected entries here and, through the bus,
+// on every other instance, so a session deleted anywhere stops being served everywhere.
+type SessionCache struct {
+	inner SessionBackend
+	bus InvalidationBus
+	mu sync.Mutex
+	// generation changes on every invalidation, a read that raced with one is not cached
+	generation uint64
+	sessions *lruCache[string, domain.Session]
+	userSessions *lruCache[uuid.UUID, []domain.Session]
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+func NewSessionCache(inner SessionBackend, bus InvalidationBus, size int, ttl time.Duration) *SessionCache {
+	if ttl <= 0 {
+		ttl = DefaultSessionCacheTTL
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	c := &SessionCache{
+		inner: inner,
+		bus: bus,
+		sessions: newLRUCache[string, domain.Session](size, ttl),
+		userSessions: newLRUCache[uuid.UUID, []domain.Session](size, ttl),
+		stop: stop,
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(c.done)
+		bus.Listen(ctx, c.apply, c.reset)
+	}()
+
+	return c
+}
+
+func cacheKey(token string) string {
+	return hex.EncodeToString(hashToken(token))
+}
+
+// apply handles an invalidation message, from this instance or another one.
+func (c *SessionCache) apply(message string) {
+	kind, subject, ok := strings.Cut(message, ":")
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	sessionCacheMetrics.Add(metricCacheInvalidations, 1)
+
+	if kind == invalidateToken {
+		c.sessions.remove(subject)
+		return
+	}
+
+	userId, err := uuid.Parse(subject)
+	if err != nil {
+		return
+	}
+
+	c.userSessions.remove(userId)
+	if kind == invalidateUser {
+		c.sessions.removeFunc(func(session domain.Session) bool {
+			return session.UserId == userId
+		})
+	}
+}
+
+// reset drops everything, after a reconnect the cache can't tell what it has missed.
+func (c *SessionCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.sessions.clear()
+	c.userSessions.clear()
+}
+
+// invalidate applies the messages locally at once and publishes them to the other instances.
+// The write they follow has already succeeded, so a failed publish is only counted, the other
+// instances catch up when their entries expire.
+func (c *SessionCache) invalidate(ctx context.Context, messages ...string) {
+	for _, message := range messages {
+		c.apply(message)
+		if err := c.bus.Publish(ctx, message); err != nil {
+			sessionCacheMetrics.Add(metricCachePublishFailures, 1)
+		}
+	}
+}
+
+func (c *SessionCache) Save(ctx context.Context, session *domain.Session, expiresIn time.Duration) (string, error) {
+	token, err := c.inner.Save(ctx, session, expiresIn)
+	if err != nil {
+		return "", err
+	}
+
+	c.invalidate(ctx, invalidateList + ":" + session.UserId.String())
+	return token, nil
+}
+
+func (c *SessionCache) Rotate(ctx context.Context, token string, session *domain.Session, expiresIn time.Duration) (string, error) {
+	newToken, err := c.inner.Rotate(ctx, token, session, expiresIn)
+	c.invalidate(ctx, invalidateToken + ":" + cacheKey(token), invalidateList + ":" + session.UserId.String())
+	return newToken, err
+}
+
+func (c *SessionCache) Delete(ctx context.Context, token string) error {
+	// the owner is looked up first, the session lists of the user are stale afterwards
+	messages := []string{invalidateToken + ":" + cacheKey(token)}
+	if session, err := c.Get(ctx, token); err == nil {
+		messages = append(messages, invalidateList + ":" + session.UserId.String())
+	}
+
+	err := c.inner.Delete(ctx, token)
+	c.invalidate(ctx, messages...)
+	return err
+}
+
+func (c *SessionCache) DeleteUserSessions(ctx context.Context, userId uuid.UUID) error {
+	err := c.inner.DeleteUserSessions(ctx, userId)
+	c.invalidate(ctx, invalidateUser + ":" + userId.String())
+	return err
+}
+
+func (c *SessionCache) UpdateUserEmail(ctx context.Context, userId uuid.UUID, email string) error {
+	err := c.inner.UpdateUserEmail(ctx, userId, email)
+	c.invalidate(ctx, invalidateUser + ":" + userId.String())
+	return err
+}
+
+func (c *SessionCache) PruneExpiredSessions(ctx context.Context) (int64, error) {
+	return c.inner.PruneExpiredSessions(ctx)
+}
+
+func (c *SessionCache) Get(ctx context.Context, token string) (*domain.Session, error) {
+	key := cacheKey(token)
+
+	c.mu.Lock()
+	session, ok := c.sessions.get(key, time.Now())
+	generation := c.generation
+	c.mu.Unlock()
+
+	if ok {
+		sessionCacheMetrics.Add(metricCacheHits, 1)
+		return &session, nil
+	}
+	sessionCacheMetrics.Add(metricCacheMisses, 1)
+
+	stored, err := c.inner.Get(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation == generation && c.sessions.put(key, *stored, time.Now()) {
+		sessionCacheMetrics.Add(metricCacheEvictions, 1)
+	}
+	c.mu.Unlock()
+
+	return stored, nil
+}
+
+func (c *SessionCache) GetUserSessions(ctx context.Context, userId uuid.UUID) ([]*domain.Session, error) {
+	c.mu.Lock()
+	cached, ok := c.userSessions.get(userId, time.Now())
+	generation := c.generation
+	c.mu.Unlock()
+
+	if ok {
+		sessionCacheMetrics.Add(metricCacheHits, 1)
+		result := make([]*domain.Session, len(cached))
+		for i := range cached {
+			session := cached[i]
+			result[i] = &session
+		}
+		return result, nil
+	}
+	sessionCacheMetrics.Add(metricCacheMisses, 1)
+
+	stored, err := c.inner.GetUserSessions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]domain.Session, len(stored))
+	for i, session := range stored {
+		sessions[i] = *session
+	}
+
+	c.mu.Lock()
+	if c.generation == generation && c.userSessions.put(userId, sessions, time.Now()) {
+		sessionCacheMetrics.Add(metricCacheEvictions, 1)
+	}
+	c.mu.Unlock()
+
+	return stored, nil
+}
+
+func (c *SessionCache) Exit() {
+	c.stop()
+	_ = c.bus.Close()
+	<-c.done
+	c.inner.Exit()
+}
+
+// RedisInvalidationBus publishes invalidations on the KeySchema.SessionInvalidations channel.
+type RedisInvalidationBus struct {
+	client redis.UniversalClient
+	channel string
+}
+
+func NewRedisInvalidationBus(conf *Config) (*RedisInvalidationBus, error) {
+	client, err := newRedisClient(conf)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating invalidation bus: %w", err)
+	}
+
+	return &RedisInvalidationBus{
+		client: client,
+		channel: keySchemaFor(conf).SessionInvalidations(),
+	}, nil
+}
+
+func (b *RedisInvalidationBus) Publish(ctx context.Context, message string) error {
+	if err := b.client.Publish(ctx, b.channel, message).Err(); err != nil {
+		return fmt.Errorf("redis error - invalidation publish failed: %w", err)
+	}
+	return nil
+}
+
+func (b *RedisInvalidationBus) Listen(ctx context.Context, handle func(message string), resync func()) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	for {
+		reply, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			// the connection is lost, the next Receive reconnects and subscribes again
+			resync()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch reply := reply.(type) {
+		case *redis.Subscription:
+			resync()
+		case *redis.Message:
+			handle(reply.Payload)
+		}
+	}
+}
+
+func (b *RedisInvalidationBus) Close() error {
+	return b.client.Close()
+}
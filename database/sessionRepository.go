@@ -5,8 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,7 +21,8 @@ var (
 )
 
 type SessionRepository struct {
-	client *redis.Client
+	client redis.UniversalClient
+	keys KeySchema
 }
 
 func generateRefreshToken() string {
@@ -34,20 +35,14 @@ func generateRefreshToken() string {
 }
 
 func NewSessionRepository(conf *Config) (*SessionRepository, error) {
-	options, err := redis.ParseURL(conf.GetRedisConnString())
+	client, keys, err := openKeySpace(conf)
 	if err != nil {
 		return nil, fmt.Errorf("error while creating session repository: %w", err)
 	}
 
-	client := redis.NewClient(options)
-	ctx := context.Background()
-
-	if _, err := client.Ping(ctx).Result(); err != nil {
-		return nil, fmt.Errorf("redis connection error: %w", err)
-	}
-
 	return &SessionRepository{
 		client: client,
+		keys: keys,
 	}, nil
 }
 
@@ -58,53 +53,64 @@ func (r *SessionRepository) Save(ctx context.Context, session *domain.Session, e
 	}
 
 	newToken := generateRefreshToken()
-	if err = r.client.Set(ctx, newToken, binary, expiresIn).Err(); err != nil {
+	keys := []string{r.keys.Session(newToken), r.keys.UserSessions(session.UserId)}
+	args := r.keys.scriptArgs(newToken, binary, expiresIn.Milliseconds())
+
+	if err = saveSessionScript.Run(ctx, r.client, keys, args...).Err(); err != nil {
 		return "", fmt.Errorf("redis error - session saving failed: %w", err)
 	}
 
-	if err = r.client.SAdd(ctx, session.UserId.String(), newToken).Err(); err != nil {
-		return "", fmt.Errorf("redis error - can't add to user sessions set: %w", err)
-	}
 	return newToken, nil
 }
 
-func (r *SessionRepository) Delete(ctx context.Context, token string) error {
-	session, err := r.Get(ctx, token)
+// Rotate replaces the token by a new one for the same session in one step. Of concurrent
+// rotations of one token only the first succeeds, the rest get ErrSessionNotFound.
+func (r *SessionRepository) Rotate(ctx context.Context, token string, session *domain.Session, expiresIn time.Duration) (string, error) {
+	binary, err := json.Marshal(session)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("session serialization error: %w", err)
 	}
 
-	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
-		pipe.Del(ctx, token)
-        pipe.SRem(ctx, session.UserId.String(), token)
-        return nil
-	})
+	newToken := generateRefreshToken()
+	keys := []string{r.keys.Session(token), r.keys.Session(newToken), r.keys.UserSessions(session.UserId)}
+	args := r.keys.scriptArgs(token, newToken, binary, expiresIn.Milliseconds())
+
+	rotated, err := rotateSessionScript.Run(ctx, r.client, keys, args...).Int()
+	if err != nil {
+		return "", fmt.Errorf("redis error - session rotation failed: %w", err)
+	}
+
+	if rotated == 0 {
+		return "", ErrSessionNotFound
+	}
+
+	return newToken, nil
+}
 
+func (r *SessionRepository) Delete(ctx context.Context, token string) error {
+	deleted, err := deleteSessionScript.Run(ctx, r.client, []string{r.keys.Session(token)}, r.keys.scriptArgs(token)...).Int()
 	if err != nil {
 		return fmt.Errorf("redis error - can't delete user session: %w", err)
 	}
 
+	if deleted == 0 {
+		return ErrSessionNotFound
+	}
+
 	return nil
 }
 
 func (r *SessionRepository) DeleteUserSessions(ctx context.Context, userId uuid.UUID) error {
-	sessions, err := r.client.SMembers(ctx, userId.String()).Result()
-    if err != nil {
-        return fmt.Errorf("redis error - user sessions search failed: %w", err)
-    }
-
-    if len(sessions) > 0 {
-        if err := r.client.Del(ctx, sessions...).Err(); err != nil {
-            return err
-        }
-    }
-
-    return r.client.Del(ctx, userId.String()).Err()
+	if err := deleteUserSessionsScript.Run(ctx, r.client, []string{r.keys.UserSessions(userId)}, r.keys.scriptArgs()...).Err(); err != nil {
+		return fmt.Errorf("redis error - user sessions delete failed: %w", err)
+	}
+
+	return nil
 }
 
 func (r *SessionRepository) Get(ctx context.Context, token string) (*domain.Session, error) {
 	session := domain.Session{}
-	binary, err := r.client.Get(ctx, token).Bytes()
+	binary, err := r.client.Get(ctx, r.keys.Session(token)).Bytes()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, ErrSessionNotFound
@@ -120,24 +126,85 @@ func (r *SessionRepository) Get(ctx context.Context, token string) (*domain.Sess
 	return &session, nil
 }
 
+// GetUserSessions returns the live sessions of the user, pruning expired index members on the way.
 func (r *SessionRepository) GetUserSessions(ctx context.Context, userId uuid.UUID) ([]*domain.Session, error) {
-	result := make([]*domain.Session, 0)
-	sessions, err := r.client.SMembers(ctx, userId.String()).Result()
+	reply, err := listSessionsScript.Run(ctx, r.client, []string{r.keys.UserSessions(userId)}, r.keys.scriptArgs()...).Slice()
 	if err != nil {
 		return nil, fmt.Errorf("redis error - user sessions search failed: %w", err)
 	}
 
-	for _, refreshToken := range sessions {
-		s, err := r.Get(ctx, refreshToken)
-		if err != nil {
-			log.Printf("[WARNING] session deleted from its storage but not from user's set. token=%s", refreshToken)
-			continue
+	if len(reply) != 2 {
+		return nil, fmt.Errorf("redis error - unexpected user sessions reply of %d items", len(reply))
+	}
+
+	if pruned, _ := reply[0].(int64); pruned > 0 {
+		sessionIndexMetrics.Add(metricPrunedOnRead, pruned)
+	}
+
+	stored, _ := reply[1].([]any)
+	result := make([]*domain.Session, 0, len(stored))
+	for _, item := range stored {
+		binary, _ := item.(string)
+
+		session := domain.Session{}
+		if err = json.Unmarshal([]byte(binary), &session); err != nil {
+			return nil, fmt.Errorf("session deserialization error: %w", err)
 		}
-		result = append(result, s)
+		result = append(result, &session)
 	}
+
 	return result, nil
 }
 
+// PruneExpiredSessions drops expired members from every user index and returns how many were dropped.
+// Plain sets of the former layout are visited too, they are converted on the way.
+func (r *SessionRepository) PruneExpiredSessions(ctx context.Context) (int64, error) {
+	var pruned atomic.Int64
+
+	match := r.keys.userPrefix() + "*" + userSessionsSuffix
+	err := forEachRedisNode(ctx, r.client, func(ctx context.Context, node *redis.Client) error {
+		for _, keyType := range []string{"zset", "set"} {
+			iter := node.ScanType(ctx, 0, match, 500, keyType).Iterator()
+			for iter.Next(ctx) {
+				n, err := pruneSessionIndexScript.Run(ctx, node, []string{iter.Val()}, r.keys.scriptArgs()...).Int64()
+				if err != nil {
+					return fmt.Errorf("redis error - session index prune failed: %w", err)
+				}
+				pruned.Add(n)
+			}
+
+			if err := iter.Err(); err != nil {
+				return fmt.Errorf("redis error - session index scan failed: %w", err)
+			}
+		}
+		return nil
+	})
+
+	total := pruned.Load()
+	if err != nil {
+		return total, err
+	}
+
+	sessionIndexMetrics.Add(metricPrunedBySweeper, total)
+	sessionIndexMetrics.Add(metricSweeps, 1)
+
+	return total, nil
+}
+
+// UpdateUserEmail rewrites the email cached in every live session of the user, keeping their TTLs.
+func (r *SessionRepository) UpdateUserEmail(ctx context.Context, userId uuid.UUID, email string) error {
+	pruned, err := updateSessionEmailScript.Run(ctx, r.client, []string{r.keys.UserSessions(userId)}, r.keys.scriptArgs(email)...).Int64()
+	if err != nil {
+		return fmt.Errorf("redis error - session update failed: %w", err)
+	}
+
+	if pruned > 0 {
+		sessionIndexMetrics.Add(metricPrunedOnRead, pruned)
+	}
+
+	return nil
+}
+
 func (r *SessionRepository) Exit() {
 	if r != nil {
 		_ = r.client.Close()
@@ -0,0 +1,167 @@
+package database
+
+import "github.com/redis/go-redis/v9"
+
+// Every session mutation touches the session key and the index of the user's tokens,
+// the scripts keep both in step: Redis runs a script without interleaving other commands.
+//
+// The index is a sorted set scored by the expiry of each token in unix ms, so expired
+// members are cut off by score. The index key itself expires with the latest session.
+//
+// Index members are bare tokens. Keys learnt inside a script are built from the leading
+// ARGV every script gets from KeySchema.scriptArgs: ARGV[1] - session key prefix,
+// ARGV[2] and ARGV[3] - user index key prefix and suffix. Script arguments start at ARGV[4].
+
+const sessionIndexLua = `
+local function session_key(token)
+	return ARGV[1] .. token
+end
+
+local function index_key(userId)
+	return ARGV[2] .. userId .. ARGV[3]
+end
+
+local function now_ms()
+	local t = redis.call('TIME')
+	return tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+end
+
+-- plain sets written before the expiry index are converted in place,
+-- a token without TTL never expires and stays in the index for good
+local function ensure_index(key)
+	if redis.call('TYPE', key).ok ~= 'set' then
+		return
+	end
+	local now = now_ms()
+	local tokens = redis.call('SMEMBERS', key)
+	redis.call('DEL', key)
+	for _, token in ipairs(tokens) do
+		local ttl = redis.call('PTTL', session_key(token))
+		if ttl > 0 then
+			redis.call('ZADD', key, now + ttl, token)
+		elseif ttl == -1 then
+			redis.call('ZADD', key, '+inf', token)
+		end
+	end
+end
+
+local function prune(key)
+	return redis.call('ZREMRANGEBYSCORE', key, '-inf', now_ms())
+end
+
+local function refresh_index_ttl(key)
+	local last = redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')
+	if #last == 0 then
+		return
+	end
+	if last[2] == 'inf' then
+		redis.call('PERSIST', key)
+	else
+		redis.call('PEXPIREAT', key, last[2])
+	end
+end
+`
+
+// saveSessionScript: KEYS[1] - session, KEYS[2] - user index; ARGV[4] - token, ARGV[5] - session,
+// ARGV[6] - ttl in ms.
+var saveSessionScript = redis.NewScript(sessionIndexLua + `
+ensure_index(KEYS[2])
+prune(KEYS[2])
+redis.call('SET', KEYS[1], ARGV[5], 'PX', ARGV[6])
+redis.call('ZADD', KEYS[2], now_ms() + tonumber(ARGV[6]), ARGV[4])
+refresh_index_ttl(KEYS[2])
+return 1
+`)
+
+// deleteSessionScript: KEYS[1] - session; ARGV[4] - token. The user index is named after the
+// user of the session, so it is found inside the script. Returns 0 when there is no such session.
+var deleteSessionScript = redis.NewScript(sessionIndexLua + `
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return 0
+end
+redis.call('DEL', KEYS[1])
+local ok, session = pcall(cjson.decode, data)
+if ok and type(session) == 'table' and session.userId then
+	local index = index_key(session.userId)
+	ensure_index(index)
+	redis.call('ZREM', index, ARGV[4])
+	refresh_index_ttl(index)
+end
+return 1
+`)
+
+// rotateSessionScript: KEYS[1] - old session, KEYS[2] - new session, KEYS[3] - user index;
+// ARGV[4] - old token, ARGV[5] - new token, ARGV[6] - session, ARGV[7] - ttl in ms. Returns 0
+// without saving anything when the old token is already gone, that is when a concurrent
+// rotation has won.
+var rotateSessionScript = redis.NewScript(sessionIndexLua + `
+if redis.call('DEL', KEYS[1]) == 0 then
+	return 0
+end
+ensure_index(KEYS[3])
+redis.call('ZREM', KEYS[3], ARGV[4])
+prune(KEYS[3])
+redis.call('SET', KEYS[2], ARGV[6], 'PX', ARGV[7])
+redis.call('ZADD', KEYS[3], now_ms() + tonumber(ARGV[7]), ARGV[5])
+refresh_index_ttl(KEYS[3])
+return 1
+`)
+
+// deleteUserSessionsScript: KEYS[1] - user index. Returns the number of indexed tokens.
+var deleteUserSessionsScript = redis.NewScript(sessionIndexLua + `
+ensure_index(KEYS[1])
+local tokens = redis.call('ZRANGE', KEYS[1], 0, -1)
+for _, token in ipairs(tokens) do
+	redis.call('DEL', session_key(token))
+end
+redis.call('DEL', KEYS[1])
+return #tokens
+`)
+
+// listSessionsScript: KEYS[1] - user index. Returns {pruned, sessions}, members whose
+// session is gone before its expiry (e.g. evicted) are pruned as well.
+var listSessionsScript = redis.NewScript(sessionIndexLua + `
+ensure_index(KEYS[1])
+local pruned = prune(KEYS[1])
+local sessions = {}
+for _, token in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	local data = redis.call('GET', session_key(token))
+	if data then
+		table.insert(sessions, data)
+	else
+		redis.call('ZREM', KEYS[1], token)
+		pruned = pruned + 1
+	end
+end
+refresh_index_ttl(KEYS[1])
+return {pruned, sessions}
+`)
+
+// updateSessionEmailScript: KEYS[1] - user index; ARGV[4] - email. Sessions keep their TTL.
+// Returns the number of pruned members.
+var updateSessionEmailScript = redis.NewScript(sessionIndexLua + `
+ensure_index(KEYS[1])
+local pruned = prune(KEYS[1])
+for _, token in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	local data = redis.call('GET', session_key(token))
+	if data then
+		local session = cjson.decode(data)
+		session.email = ARGV[4]
+		redis.call('SET', session_key(token), cjson.encode(session), 'KEEPTTL')
+	else
+		redis.call('ZREM', KEYS[1], token)
+		pruned = pruned + 1
+	end
+end
+refresh_index_ttl(KEYS[1])
+return pruned
+`)
+
+// pruneSessionIndexScript: KEYS[1] - user index. Returns the number of pruned members.
+var pruneSessionIndexScript = redis.NewScript(sessionIndexLua + `
+ensure_index(KEYS[1])
+local pruned = prune(KEYS[1])
+refresh_index_ttl(KEYS[1])
+return pruned
+`)
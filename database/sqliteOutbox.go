@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+// sqlExecer is either the pool or a transaction.
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func appendSQLiteEvent(ctx context.Context, db sqlExecer, eventType string, userId uuid.UUID, payload any) error {
+	event, err := domain.NewEvent(eventType, userId, payload)
+	if err != nil {
+		return err
+	}
+
+	return insertSQLiteEvent(ctx, db, event)
+}
+
+// insertSQLiteEvent sets availableAt itself, a column default would be written in another format.
+func insertSQLiteEvent(ctx context.Context, db sqlExecer, event domain.Event) error {
+	query := "INSERT INTO outbox_events(id, type, userId, payload, occurredAt, availableAt) VALUES (?, ?, ?, ?, ?, ?);"
+	if _, err := db.ExecContext(
+		ctx, query, event.Id, event.Type, event.UserId, []byte(event.Payload), event.OccurredAt.UTC(), time.Now().UTC(),
+	); err != nil {
+		return fmt.Errorf("outbox append failed: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteUserRepository) AppendEvent(ctx context.Context, event domain.Event) error {
+	return insertSQLiteEvent(ctx, r.db, event)
+}
+
+// ClaimEvents leases up to limit pending events, oldest first. A single statement is atomic
+// in SQLite, so no other relay can claim the same events meanwhile.
+func (r *SQLiteUserRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	now := time.Now().UTC()
+
+	query := `UPDATE outbox_events SET availableAt = ?, attempts = attempts + 1
+		WHERE seq IN (
+			SELECT seq FROM outbox_events
+			WHERE publishedAt IS NULL AND availableAt <= ?
+			ORDER BY seq
+			LIMIT ?
+		)
+		RETURNING id, type, userId, payload, occurredAt, attempts, seq;`
+
+	rows, err := r.db.QueryContext(ctx, query, now.Add(lease), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("outbox claim failed: %w", err)
+	}
+	defer rows.Close()
+
+	type claimed struct {
+		event domain.OutboxEvent
+		seq int64
+	}
+
+	batch := make([]claimed, 0, limit)
+	for rows.Next() {
+		item := claimed{}
+		payload := []byte{}
+		if err = rows.Scan(
+			&item.event.Id, &item.event.Type, &item.event.UserId, &payload, &item.event.OccurredAt, &item.event.Attempts, &item.seq,
+		); err != nil {
+			return nil, fmt.Errorf("outbox claim failed: %w", err)
+		}
+		item.event.Payload = payload
+		item.event.OccurredAt = item.event.OccurredAt.UTC()
+		batch = append(batch, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox claim failed: %w", err)
+	}
+
+	// RETURNING doesn't keep the order of the subquery
+	sort.Slice(batch, func(i, j int) bool { return batch[i].seq < batch[j].seq })
+
+	result := make([]domain.OutboxEvent, 0, len(batch))
+	for _, item := range batch {
+		result = append(result, item.event)
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteUserRepository) MarkPublished(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, len(ids) + 1)
+	args = append(args, time.Now().UTC())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	query := "UPDATE outbox_events SET publishedAt=?, lastError='' WHERE id IN (" + placeholders + ");"
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("outbox mark published failed: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteUserRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error {
+	query := "UPDATE outbox_events SET availableAt=?, lastError=? WHERE id=? AND publishedAt IS NULL;"
+	if _, err := r.db.ExecContext(ctx, query, retryAt.UTC(), reason, id); err != nil {
+		return fmt.Errorf("outbox retry schedule failed: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLiteUserRepository) DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error) {
+	query := "DELETE FROM outbox_events WHERE publishedAt IS NOT NULL AND publishedAt < ?;"
+
+	result, err := r.db.ExecContext(ctx, query, publishedBefore.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("outbox cleanup failed: %w", err)
+	}
+
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
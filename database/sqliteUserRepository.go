@@ -0,0 +1,662 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteUserRepository is the user storage for deployments without Postgres. The schema comes
+// from migrations_sqlite and errors are reported the way UserRepository reports them.
+//
+// SQLite compares timestamps as text, so every timestamp is written in UTC by the repository.
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+func NewSQLiteUserRepository(conf *Config) (*SQLiteUserRepository, error) {
+	db, err := openSQLite(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLiteUserRepository{
+		db: db,
+	}, nil
+}
+
+func openSQLite(conf *Config) (*sql.DB, error) {
+	db, err := sql.Open(sqliteDriverName, conf.GetSQLiteConnString())
+	if err != nil {
+		fmt.Println("invalid connection arguments:", err)
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		fmt.Println("database connection failed:", err)
+		return nil, err
+	}
+
+	configurePool(db, conf)
+
+	return db, nil
+}
+
+// sqliteConstraintError maps a failed SQLite constraint the way constraintError maps a Postgres one.
+// unique reports a uniqueness violation, what it means is up to the caller.
+func sqliteConstraintError(err error) (cerr error, unique bool) {
+	var serr *sqlite.Error
+	if !errors.As(err, &serr) || serr.Code()&0xff != sqlite3.SQLITE_CONSTRAINT {
+		return nil, false
+	}
+
+	// messages look like "constraint failed: CHECK constraint failed: users_email_format (275)"
+	msg := strings.TrimSuffix(serr.Error(), fmt.Sprintf(" (%d)", serr.Code()))
+	detail := msg[strings.LastIndex(msg, ": ")+2:]
+
+	switch serr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return nil, true
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		_, column, _ := strings.Cut(detail, ".")
+		return &ConstraintError{Column: strings.ToLower(column), Err: ErrNullViolation}, false
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		if strings.HasSuffix(detail, "_length") {
+			return &ConstraintError{Err: ErrValueTooLong}, false
+		}
+		return &ConstraintError{Column: checkConstraintColumns[detail], Err: ErrCheckViolation}, false
+	}
+
+	return nil, false
+}
+
+// sqliteTime keeps zero times out of nullable columns.
+func sqliteTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+func (r *SQLiteUserRepository) Save(ctx context.Context, user domain.User) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("saving transaction start failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO users(id, fullName, email, phone, password, birthDate, registerDate, updatedAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?);"
+	_, err = tx.ExecContext(
+		ctx, query, user.Id, user.FullName, user.Email, user.Phone, user.PasswordHash,
+		sqliteTime(user.BirthDate), user.RegisterDate.UTC(), time.Now().UTC(),
+	)
+
+	if err != nil {
+		cerr, unique := sqliteConstraintError(err)
+		if unique {
+			return fmt.Errorf("unique constraint violation - %w", ErrUserAlreadyExists)
+		}
+		if cerr != nil {
+			return cerr
+		}
+		return fmt.Errorf("saving operation failed: %w", err)
+	}
+
+	if err = appendSQLiteEvent(ctx, tx, domain.EventUserRegistered, user.Id, domain.UserRegisteredPayload{
+		Email: user.Email,
+		FullName: user.FullName,
+		RegisterDate: user.RegisterDate.UTC(),
+	}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("saving commit failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) Get(ctx context.Context, email string) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE lower(email)=lower(?) AND deletedAt IS NULL;"
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("can't find user with email=%s - %w", email, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("user retrieve operation failed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) GetById(ctx context.Context, userId uuid.UUID) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE id=? AND deletedAt IS NULL;"
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("user retrieve by id operation failed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) GetIncludingDeleted(ctx context.Context, userId uuid.UUID) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE id=?;"
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("user retrieve by id operation failed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) GetDeleted(ctx context.Context, email string) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE lower(email)=lower(?) AND deletedAt IS NOT NULL;"
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("can't find deleted user with email=%s - %w", email, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("deleted user retrieve operation failed: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateProfile needs no row lock: transactions take the database write lock as they begin.
+func (r *SQLiteUserRepository) UpdateProfile(ctx context.Context, userId uuid.UUID, update domain.ProfileUpdate) (*domain.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("profile update transaction start failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + userColumns + " FROM users WHERE id=? AND deletedAt IS NULL;"
+
+	user, err := scanUser(tx.QueryRowContext(ctx, query, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("profile update operation failed: %w", err)
+	}
+
+	changes, err := applyProfileUpdate(user, update)
+	if err != nil || len(changes) == 0 {
+		return user, err
+	}
+
+	user.Version++
+	user.UpdatedAt = time.Now().UTC()
+
+	query = "UPDATE users SET fullName=?, phone=?, birthDate=?, version=?, updatedAt=? WHERE id=?;"
+	if _, err = tx.ExecContext(
+		ctx, query, user.FullName, user.Phone, sqliteTime(user.BirthDate), user.Version, user.UpdatedAt, user.Id,
+	); err != nil {
+		cerr, unique := sqliteConstraintError(err)
+		if unique {
+			return nil, fmt.Errorf("unique constraint violation - %w", ErrPhoneAlreadyTaken)
+		}
+		if cerr != nil {
+			return nil, cerr
+		}
+		return nil, fmt.Errorf("profile update operation failed: %w", err)
+	}
+
+	if err = saveSQLiteProfileChanges(ctx, tx, user, changes); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("profile update commit failed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) UpdateEmail(ctx context.Context, userId uuid.UUID, oldEmail, newEmail string) (*domain.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("email update transaction start failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "SELECT " + userColumns + " FROM users WHERE id=? AND deletedAt IS NULL;"
+
+	user, err := scanUser(tx.QueryRowContext(ctx, query, userId))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("email update operation failed: %w", err)
+	}
+
+	if user.Email != oldEmail {
+		return nil, fmt.Errorf("email changed since confirmation was requested - %w", ErrVersionConflict)
+	}
+
+	user.Email = newEmail
+	user.Version++
+	user.UpdatedAt = time.Now().UTC()
+
+	query = "UPDATE users SET email=?, version=?, updatedAt=? WHERE id=?;"
+	if _, err = tx.ExecContext(ctx, query, user.Email, user.Version, user.UpdatedAt, user.Id); err != nil {
+		cerr, unique := sqliteConstraintError(err)
+		if unique {
+			return nil, fmt.Errorf("unique constraint violation - %w", ErrEmailAlreadyTaken)
+		}
+		if cerr != nil {
+			return nil, cerr
+		}
+		return nil, fmt.Errorf("email update operation failed: %w", err)
+	}
+
+	changes := []domain.ProfileChange{{Field: domain.ProfileFieldEmail, OldValue: oldEmail, NewValue: newEmail}}
+	if err = saveSQLiteProfileChanges(ctx, tx, user, changes); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("email update commit failed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLiteUserRepository) UpdatePasswordHash(ctx context.Context, userId uuid.UUID, hash []byte) error {
+	query := "UPDATE users SET password=? WHERE id=?;"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("password hash update transaction start failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, hash, userId)
+	if err != nil {
+		return fmt.Errorf("password hash update operation failed: %w", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	err = appendSQLiteEvent(ctx, tx, domain.EventPasswordChanged, userId, domain.PasswordChangedPayload{ChangedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("password hash update commit failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) ListProfileChanges(ctx context.Context, userId uuid.UUID) ([]domain.ProfileChangeRecord, error) {
+	query := `SELECT field, coalesce(oldValue, ''), coalesce(newValue, ''), version, changedAt
+		FROM profile_changes WHERE userId=? ORDER BY changedAt, id;`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("profile changes list operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.ProfileChangeRecord, 0)
+	for rows.Next() {
+		change := domain.ProfileChangeRecord{}
+		if err = rows.Scan(&change.Field, &change.OldValue, &change.NewValue, &change.Version, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("profile changes list operation failed: %w", err)
+		}
+		result = append(result, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("profile changes list operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteUserRepository) SoftDelete(ctx context.Context, userId uuid.UUID) error {
+	return r.setDeletedAt(ctx, userId, true)
+}
+
+func (r *SQLiteUserRepository) Restore(ctx context.Context, userId uuid.UUID) error {
+	return r.setDeletedAt(ctx, userId, false)
+}
+
+func (r *SQLiteUserRepository) setDeletedAt(ctx context.Context, userId uuid.UUID, deleted bool) error {
+	now := time.Now().UTC()
+
+	operation, query, args := "user restore", "UPDATE users SET deletedAt=NULL WHERE id=? AND deletedAt IS NOT NULL;", []any{userId}
+	if deleted {
+		operation, query, args = "user soft delete", "UPDATE users SET deletedAt=? WHERE id=? AND deletedAt IS NULL;", []any{now, userId}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s transaction start failed: %w", operation, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s operation failed: %w", operation, err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	if deleted {
+		err = appendSQLiteEvent(ctx, tx, domain.EventUserDeleted, userId, domain.UserDeletedPayload{DeletedAt: now})
+	} else {
+		err = appendSQLiteEvent(ctx, tx, domain.EventUserRestored, userId, domain.UserRestoredPayload{RestoredAt: now})
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s commit failed: %w", operation, err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := "DELETE FROM users WHERE deletedAt IS NOT NULL AND deletedAt < ?;"
+
+	result, err := r.db.ExecContext(ctx, query, deletedBefore.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("deleted users purge operation failed: %w", err)
+	}
+
+	purged, _ := result.RowsAffected()
+	return purged, nil
+}
+
+func (r *SQLiteUserRepository) Delete(ctx context.Context, userId uuid.UUID) error {
+	query := "DELETE FROM users WHERE id=?;"
+
+	result, err := r.db.ExecContext(ctx, query, userId)
+	if err != nil {
+		return fmt.Errorf("user remove operation failed: %w", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) SearchUsers(ctx context.Context, search domain.UserSearch) ([]domain.User, error) {
+	conditions := make([]string, 0, 4)
+	args := make([]any, 0, 6)
+
+	addPrefix := func(column, prefix string) {
+		if prefix == "" {
+			return
+		}
+		args = append(args, likeEscaper.Replace(prefix) + "%")
+		conditions = append(conditions, column + ` LIKE ? ESCAPE '\'`)
+	}
+
+	addPrefix("lower(email)", strings.ToLower(search.EmailPrefix))
+	addPrefix("lower(fullName)", strings.ToLower(search.FullNamePrefix))
+	addPrefix("phone", search.PhonePrefix)
+
+	if search.After != nil {
+		args = append(args, search.After.RegisterDate.UTC(), search.After.Id)
+		conditions = append(conditions, "(registerDate, id) > (?, ?)")
+	}
+
+	query := "SELECT " + userColumns + " FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, search.Limit)
+	query += " ORDER BY registerDate, id LIMIT ?;"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("user search operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.User, 0, search.Limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("user search operation failed: %w", err)
+		}
+		result = append(result, *user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("user search operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteUserRepository) SetStatus(ctx context.Context, userId uuid.UUID, change domain.StatusChange) error {
+	query := "UPDATE users SET status=?, statusReason=?, suspendedUntil=?, statusChangedAt=? WHERE id=?;"
+
+	until := sql.NullTime{}
+	if change.Status == domain.UserStatusSuspended {
+		until = sqliteTime(change.Until)
+	}
+
+	result, err := r.db.ExecContext(ctx, query, change.Status, change.Reason, until, time.Now().UTC(), userId)
+	if err != nil {
+		if cerr, _ := sqliteConstraintError(err); cerr != nil {
+			return cerr
+		}
+		return fmt.Errorf("user status update operation failed: %w", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) GetUserGrants(ctx context.Context, userId uuid.UUID) (*domain.Grants, error) {
+	query := `SELECT ur.role, rp.permission
+		FROM user_roles ur LEFT JOIN role_permissions rp ON rp.role = ur.role
+		WHERE ur.userId=? ORDER BY ur.role, rp.permission;`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("user grants retrieve operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	grants := &domain.Grants{Roles: make([]string, 0), Permissions: make([]string, 0)}
+	seenRoles := make(map[string]struct{})
+	seenPermissions := make(map[string]struct{})
+
+	for rows.Next() {
+		var (
+			role string
+			permission sql.NullString
+		)
+		if err = rows.Scan(&role, &permission); err != nil {
+			return nil, fmt.Errorf("user grants retrieve operation failed: %w", err)
+		}
+
+		if _, ok := seenRoles[role]; !ok {
+			seenRoles[role] = struct{}{}
+			grants.Roles = append(grants.Roles, role)
+		}
+
+		if _, ok := seenPermissions[permission.String]; permission.Valid && !ok {
+			seenPermissions[permission.String] = struct{}{}
+			grants.Permissions = append(grants.Permissions, permission.String)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("user grants retrieve operation failed: %w", err)
+	}
+
+	return grants, nil
+}
+
+func (r *SQLiteUserRepository) ListRoleAssignments(ctx context.Context, userId uuid.UUID) ([]domain.RoleAssignment, error) {
+	query := "SELECT role, grantedBy, grantedAt FROM user_roles WHERE userId=? ORDER BY role;"
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("role assignments retrieve operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.RoleAssignment, 0)
+	for rows.Next() {
+		assignment := domain.RoleAssignment{UserId: userId}
+		var grantedBy uuid.NullUUID
+		if err = rows.Scan(&assignment.Role, &grantedBy, &assignment.GrantedAt); err != nil {
+			return nil, fmt.Errorf("role assignments retrieve operation failed: %w", err)
+		}
+		assignment.GrantedBy = grantedBy.UUID
+		result = append(result, assignment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("role assignments retrieve operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (r *SQLiteUserRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	query := `SELECT r.name, r.description, rp.permission
+		FROM roles r LEFT JOIN role_permissions rp ON rp.role = r.name
+		ORDER BY r.name, rp.permission;`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("role list operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.Role, 0)
+	for rows.Next() {
+		var (
+			role domain.Role
+			permission sql.NullString
+		)
+		if err = rows.Scan(&role.Name, &role.Description, &permission); err != nil {
+			return nil, fmt.Errorf("role list operation failed: %w", err)
+		}
+
+		if len(result) == 0 || result[len(result) - 1].Name != role.Name {
+			role.Permissions = make([]string, 0)
+			result = append(result, role)
+		}
+
+		if permission.Valid {
+			last := &result[len(result) - 1]
+			last.Permissions = append(last.Permissions, permission.String)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("role list operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// AssignRole checks the role and the user itself: SQLite doesn't name the foreign key that failed.
+func (r *SQLiteUserRepository) AssignRole(ctx context.Context, assignment domain.RoleAssignment) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("role assign transaction start failed: %w", err)
+	}
+	defer tx.Rollback()
+
+	var roleExists, userExists bool
+	query := "SELECT EXISTS(SELECT 1 FROM roles WHERE name=?), EXISTS(SELECT 1 FROM users WHERE id=?);"
+	if err = tx.QueryRowContext(ctx, query, assignment.Role, assignment.UserId).Scan(&roleExists, &userExists); err != nil {
+		return fmt.Errorf("role assign operation failed: %w", err)
+	}
+
+	if !roleExists {
+		return ErrRoleNotFound
+	}
+
+	if !userExists {
+		return ErrUserNotFound
+	}
+
+	query = `INSERT INTO user_roles(userId, role, grantedBy, grantedAt) VALUES (?, ?, ?, ?)
+		ON CONFLICT (userId, role) DO NOTHING;`
+
+	grantedBy := uuid.NullUUID{UUID: assignment.GrantedBy, Valid: assignment.GrantedBy != uuid.Nil}
+
+	if _, err = tx.ExecContext(ctx, query, assignment.UserId, assignment.Role, grantedBy, assignment.GrantedAt.UTC()); err != nil {
+		return fmt.Errorf("role assign operation failed: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("role assign commit failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) RevokeRole(ctx context.Context, userId uuid.UUID, role string) error {
+	query := "DELETE FROM user_roles WHERE userId=? AND role=?;"
+
+	result, err := r.db.ExecContext(ctx, query, userId, role)
+	if err != nil {
+		return fmt.Errorf("role revoke operation failed: %w", err)
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrRoleNotAssigned
+	}
+
+	return nil
+}
+
+func (r *SQLiteUserRepository) Exit() {
+	if r.db != nil {
+		r.db.Close()
+	}
+}
+
+func saveSQLiteProfileChanges(ctx context.Context, tx *sql.Tx, user *domain.User, changes []domain.ProfileChange) error {
+	query := "INSERT INTO profile_changes(userId, field, oldValue, newValue, version, changedAt) VALUES (?, ?, ?, ?, ?, ?);"
+	for _, change := range changes {
+		if _, err := tx.ExecContext(
+			ctx, query, user.Id, change.Field, change.OldValue, change.NewValue, user.Version, user.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("profile change audit failed: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrTokenNotFound = errors.New("token not found or expired")
+
+// OneTimePasswordPurposes are the flows one-time passwords are issued for, KeySchema.OTP
+// keeps one password per flow and user.
+var OneTimePasswordPurposes = []string{"login"}
+
+// TokenRepository keeps single-use confirmation tokens with a JSON payload.
+// Keys are KeySchema.Token(purpose, <hash of the token>), so tokens issued for one flow can't be
+// redeemed in another and the keyspace doesn't reveal them.
+type TokenRepository struct {
+	client redis.UniversalClient
+	keys KeySchema
+}
+
+func NewTokenRepository(conf *Config) (*TokenRepository, error) {
+	client, keys, err := openKeySpace(conf)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating token repository: %w", err)
+	}
+
+	return &TokenRepository{
+		client: client,
+		keys: keys,
+	}, nil
+}
+
+func generateSecureToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (r *TokenRepository) tokenKey(purpose, token string) string {
+	return r.keys.Token(purpose, hex.EncodeToString(hashToken(token)))
+}
+
+func (r *TokenRepository) Issue(ctx context.Context, purpose string, payload any, expiresIn time.Duration) (string, error) {
+	binary, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("token payload serialization error: %w", err)
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("token generation failed: %w", err)
+	}
+
+	if err = r.client.Set(ctx, r.tokenKey(purpose, token), binary, expiresIn).Err(); err != nil {
+		return "", fmt.Errorf("redis error - token saving failed: %w", err)
+	}
+
+	return token, nil
+}
+
+// Peek reads the token without deleting it.
+func (r *TokenRepository) Peek(ctx context.Context, purpose, token string, payload any) error {
+	binary, err := r.client.Get(ctx, r.tokenKey(purpose, token)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return ErrTokenNotFound
+		}
+		return fmt.Errorf("redis error - token retrieve op failed: %w", err)
+	}
+
+	if err = json.Unmarshal(binary, payload); err != nil {
+		return fmt.Errorf("token payload deserialization error: %w", err)
+	}
+
+	return nil
+}
+
+// Consume atomically reads and deletes the token, so it can be redeemed only once.
+func (r *TokenRepository) Consume(ctx context.Context, purpose, token string, payload any) error {
+	binary, err := r.client.GetDel(ctx, r.tokenKey(purpose, token)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return ErrTokenNotFound
+		}
+		return fmt.Errorf("redis error - token retrieve op failed: %w", err)
+	}
+
+	if err = json.Unmarshal(binary, payload); err != nil {
+		return fmt.Errorf("token payload deserialization error: %w", err)
+	}
+
+	return nil
+}
+
+// recordFailureScript: KEYS[1] - lockout counter; ARGV[1] - window in milliseconds.
+// The window starts with the first failure, later ones don't extend it.
+var recordFailureScript = redis.NewScript(`
+local failures = redis.call('INCR', KEYS[1])
+if failures == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {failures, redis.call('PTTL', KEYS[1])}
+`)
+
+// FailedLogins reads the failed login counter of the user kept under KeySchema.Lockout.
+func (r *TokenRepository) FailedLogins(ctx context.Context, userId uuid.UUID) (int, time.Time, error) {
+	key := r.keys.Lockout(userId.String())
+
+	var (
+		failures *redis.StringCmd
+		ttl *redis.DurationCmd
+	)
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		failures = pipe.Get(ctx, key)
+		ttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return 0, time.Time{}, fmt.Errorf("redis error - failed logins retrieve op failed: %w", err)
+	}
+
+	count, err := failures.Int()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, time.Time{}, nil
+		}
+		return 0, time.Time{}, fmt.Errorf("redis error - failed logins retrieve op failed: %w", err)
+	}
+
+	return count, time.Now().Add(ttl.Val()), nil
+}
+
+func (r *TokenRepository) RecordFailedLogin(ctx context.Context, userId uuid.UUID, window time.Duration) (int, time.Time, error) {
+	reply, err := recordFailureScript.Run(ctx, r.client, []string{r.keys.Lockout(userId.String())}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("redis error - failed login saving failed: %w", err)
+	}
+
+	return int(reply[0]), time.Now().Add(time.Duration(reply[1]) * time.Millisecond), nil
+}
+
+func (r *TokenRepository) ResetFailedLogins(ctx context.Context, userId uuid.UUID) error {
+	if err := r.client.Del(ctx, r.keys.Lockout(userId.String())).Err(); err != nil {
+		return fmt.Errorf("redis error - failed logins reset failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteOneTimePasswords drops the one-time passwords of every purpose issued to the user.
+func (r *TokenRepository) DeleteOneTimePasswords(ctx context.Context, userId uuid.UUID) error {
+	keys := make([]string, 0, len(OneTimePasswordPurposes))
+	for _, purpose := range OneTimePasswordPurposes {
+		keys = append(keys, r.keys.OTP(purpose, userId))
+	}
+
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("redis error - one-time passwords removal failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TokenRepository) Exit() {
+	if r != nil {
+		_ = r.client.Close()
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/lib/pq"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
 )
 
@@ -15,124 +19,520 @@ var (
 	ErrUserNotFound = errors.New("no user found")
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrNullViolation = errors.New("not null violation")
+	ErrPhoneAlreadyTaken = errors.New("phone already taken")
+	ErrVersionConflict = errors.New("user record version conflict")
+	ErrEmailAlreadyTaken = errors.New("email already taken")
 )
 
+var (
+	ErrCheckViolation = errors.New("check constraint violation")
+	ErrValueTooLong = errors.New("value too long for column")
+)
 
-type UserRepository struct {
-	db *sql.DB
+// checkConstraintColumns maps named CHECK constraints to the column they guard,
+// Postgres reports only the constraint name for this kind of violation.
+var checkConstraintColumns = map[string]string{
+	"users_fullname_not_blank": "fullname",
+	"users_email_format": "email",
+	"users_phone_format": "phone",
+	"users_birthdate_range": "birthdate",
 }
 
-func NewUserRepository(conf *Config) (*UserRepository, error) {
-	if err := CreateDBIfNotExists(conf); err != nil {
-		return nil, err
-	}
+// ConstraintError tells which column rejected a write, when it can be determined.
+type ConstraintError struct {
+	Column string
+	Err error
+}
 
-	db, err := sql.Open(conf.Driver, conf.GetPgConnString(false))
-	if err != nil {
-		fmt.Println("invalid connection arguments:", err)
-		return nil, err	
+func (e *ConstraintError) Error() string {
+	if e.Column == "" {
+		return e.Err.Error()
 	}
+	return fmt.Sprintf("%s - column %s", e.Err, e.Column)
+}
 
-	if err := db.Ping(); err != nil {
-		fmt.Println("database connection failed:", err)
-		return nil, err
-	}	
-	
-	return &UserRepository{
-		db: db,
-	}, nil
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
 }
 
-func CreateDBIfNotExists(conf *Config) error {
-	defaultConn, err := sql.Open(conf.Driver, conf.GetPgConnString(true))
-	if err != nil {
-		fmt.Println("invalid default connection arguments:", err)
-		return err
+func pgError(err error) (*pgconn.PgError, bool) {
+	var pgerr *pgconn.PgError
+	return pgerr, errors.As(err, &pgerr)
+}
+
+func constraintError(pgerr *pgconn.PgError) error {
+	switch pgerr.Code {
+	case pgerrcode.NotNullViolation:
+		return &ConstraintError{Column: pgerr.ColumnName, Err: ErrNullViolation}
+	case pgerrcode.CheckViolation:
+		return &ConstraintError{Column: checkConstraintColumns[pgerr.ConstraintName], Err: ErrCheckViolation}
+	case pgerrcode.StringDataRightTruncationDataException:
+		return &ConstraintError{Err: ErrValueTooLong}
 	}
+	return nil
+}
 
-	defer defaultConn.Close()
-	var exists bool
+// writeError maps a failed write, a uniqueness violation becomes uniqueErr.
+func writeError(err, uniqueErr error) error {
+	pgerr, ok := pgError(err)
+	if !ok {
+		return nil
+	}
 
-	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = '%s')", conf.DBName)
-	err = defaultConn.QueryRow(query).Scan(&exists)
-	if err != nil {
-		fmt.Println("database existense check query failed:", err)
-		return err
+	if pgerr.Code == pgerrcode.UniqueViolation {
+		return fmt.Errorf("unique constraint violation - %w", uniqueErr)
 	}
+	return constraintError(pgerr)
+}
 
-	if !exists {
-		_, err := defaultConn.Exec("CREATE DATABASE " + conf.DBName)
-		if err != nil {
-			fmt.Println("database creation failed:", err)
-			return err
-		}
+const userColumns = "id, fullName, email, phone, password, birthDate, registerDate, updatedAt, version, deletedAt, status, statusReason, statusChangedAt, suspendedUntil"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+
+// UserRepository keeps users in Postgres through a pgx pool. Queries are prepared on first
+// use and cached per connection, see configurePgxPool.
+type UserRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewUserRepository(conf *Config) (*UserRepository, error) {
+	pool, err := NewPgPool(conf)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &UserRepository{
+		pool: pool,
+	}, nil
 }
 
+// Save stores the user together with the UserRegistered event.
 func (r *UserRepository) Save(ctx context.Context, user domain.User) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("saving transaction start failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := "INSERT INTO users(id, fullName, email, phone, password, birthDate, registerDate) VALUES ($1, $2, $3, $4, $5, $6, $7);"
-	_, err := r.db.ExecContext(
+	_, err = tx.Exec(
 		ctx, query, user.Id, user.FullName, user.Email, user.Phone, user.PasswordHash, user.BirthDate, user.RegisterDate,
 	)
 
 	if err != nil {
-		if pgerr, ok := err.(*pq.Error); ok {
-			switch pgerr.Code {
-			case "23505":
-				return fmt.Errorf("unique constraint violation - %w", ErrUserAlreadyExists)
-			case "23502":
-				return ErrNullViolation
-			}
+		if werr := writeError(err, ErrUserAlreadyExists); werr != nil {
+			return werr
 		}
 		return fmt.Errorf("saving operation failed: %w", err)
 	}
 
+	if err = appendEvent(ctx, tx, domain.EventUserRegistered, user.Id, domain.UserRegisteredPayload{
+		Email: user.Email,
+		FullName: user.FullName,
+		RegisterDate: user.RegisterDate.UTC(),
+	}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("saving commit failed: %w", err)
+	}
+
 	return nil
 }
 
-func (r *UserRepository) Get(ctx context.Context, email string) (*domain.User, error) {
+func scanUser(row rowScanner) (*domain.User, error) {
 	user := domain.User{}
-	query := "SELECT * FROM users WHERE email=$1;"
-	
-	if err := r.db.QueryRowContext(ctx, query, email).Scan(
-		&user.Id, &user.FullName, &user.Email, &user.Phone, &user.PasswordHash, &user.BirthDate, &user.RegisterDate,
+	birthDate, deletedAt := sql.NullTime{}, sql.NullTime{}
+	statusChangedAt, suspendedUntil := sql.NullTime{}, sql.NullTime{}
+
+	if err := row.Scan(
+		&user.Id, &user.FullName, &user.Email, &user.Phone, &user.PasswordHash,
+		&birthDate, &user.RegisterDate, &user.UpdatedAt, &user.Version, &deletedAt,
+		&user.Status, &user.StatusReason, &statusChangedAt, &suspendedUntil,
 	); err != nil {
-		if err == sql.ErrNoRows {
+		return nil, err
+	}
+
+	user.BirthDate = birthDate.Time
+	user.DeletedAt = deletedAt.Time
+	user.StatusChangedAt = statusChangedAt.Time
+	user.SuspendedUntil = suspendedUntil.Time
+	return &user, nil
+}
+
+func (r *UserRepository) Get(ctx context.Context, email string) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE lower(email)=lower($1) AND deletedAt IS NULL;"
+	
+	user, err := scanUser(r.pool.QueryRow(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
             return nil, fmt.Errorf("can't find user with email=%s - %w", email, ErrUserNotFound)
         }
 		return nil, fmt.Errorf("user retrieve operation failed: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) GetById(ctx context.Context, userId uuid.UUID) (*domain.User, error) {
-	user := domain.User{}
-	query := "SELECT * FROM users WHERE id=$1;"
+	query := "SELECT " + userColumns + " FROM users WHERE id=$1 AND deletedAt IS NULL;"
 	
-	if err := r.db.QueryRowContext(ctx, query, userId).Scan(
-		&user.Id, &user.FullName, &user.Email, &user.Phone, &user.PasswordHash, &user.BirthDate, &user.RegisterDate,
-	); err != nil {
-		if err == sql.ErrNoRows {
+	user, err := scanUser(r.pool.QueryRow(ctx, query, userId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
             return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
         }
 		return nil, fmt.Errorf("user retrieve by id operation failed: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// GetIncludingDeleted also finds accounts that are unregistered but not purged yet.
+func (r *UserRepository) GetIncludingDeleted(ctx context.Context, userId uuid.UUID) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE id=$1;"
+
+	user, err := scanUser(r.pool.QueryRow(ctx, query, userId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("user retrieve by id operation failed: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateProfile applies the masked fields under a row lock, bumps the record version
+// and writes one profile_changes row per modified field in the same transaction.
+func (r *UserRepository) UpdateProfile(ctx context.Context, userId uuid.UUID, update domain.ProfileUpdate) (*domain.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("profile update transaction start failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := "SELECT " + userColumns + " FROM users WHERE id=$1 AND deletedAt IS NULL FOR UPDATE;"
+
+	user, err := scanUser(tx.QueryRow(ctx, query, userId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("profile update operation failed: %w", err)
+	}
+
+	changes, err := applyProfileUpdate(user, update)
+	if err != nil || len(changes) == 0 {
+		return user, err
+	}
+
+	user.Version++
+	user.UpdatedAt = time.Now()
+
+	query = "UPDATE users SET fullName=$1, phone=$2, birthDate=$3, version=$4, updatedAt=$5 WHERE id=$6;"
+	if _, err = tx.Exec(
+		ctx, query, user.FullName, user.Phone, user.BirthDate, user.Version, user.UpdatedAt, user.Id,
+	); err != nil {
+		if werr := writeError(err, ErrPhoneAlreadyTaken); werr != nil {
+			return nil, werr
+		}
+		return nil, fmt.Errorf("profile update operation failed: %w", err)
+	}
+
+	if err = saveProfileChanges(ctx, tx, user, changes); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("profile update commit failed: %w", err)
+	}
+
+	return user, nil
+}
+
+// applyProfileUpdate writes the masked fields into user and lists the ones that actually changed.
+func applyProfileUpdate(user *domain.User, update domain.ProfileUpdate) ([]domain.ProfileChange, error) {
+	if update.ExpectedVersion != 0 && update.ExpectedVersion != user.Version {
+		return nil, fmt.Errorf("expected version %d, actual %d - %w", update.ExpectedVersion, user.Version, ErrVersionConflict)
+	}
+
+	changes := make([]domain.ProfileChange, 0, len(update.Mask))
+
+	if update.Has(domain.ProfileFieldFullName) && update.FullName != user.FullName {
+		changes = append(changes, domain.ProfileChange{Field: domain.ProfileFieldFullName, OldValue: user.FullName, NewValue: update.FullName})
+		user.FullName = update.FullName
+	}
+
+	if update.Has(domain.ProfileFieldPhone) && update.Phone != user.Phone {
+		changes = append(changes, domain.ProfileChange{Field: domain.ProfileFieldPhone, OldValue: user.Phone, NewValue: update.Phone})
+		user.Phone = update.Phone
+	}
+
+	if update.Has(domain.ProfileFieldBirthDate) {
+		oldDate, newDate := user.BirthDate.Format(time.DateOnly), update.BirthDate.Format(time.DateOnly)
+		if oldDate != newDate {
+			changes = append(changes, domain.ProfileChange{Field: domain.ProfileFieldBirthDate, OldValue: oldDate, NewValue: newDate})
+			user.BirthDate = update.BirthDate
+		}
+	}
+
+	return changes, nil
+}
+
+// UpdateEmail swaps the login email if it still equals oldEmail, so a confirmation
+// issued before another change can't overwrite it.
+func (r *UserRepository) UpdateEmail(ctx context.Context, userId uuid.UUID, oldEmail, newEmail string) (*domain.User, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("email update transaction start failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := "SELECT " + userColumns + " FROM users WHERE id=$1 AND deletedAt IS NULL FOR UPDATE;"
+
+	user, err := scanUser(tx.QueryRow(ctx, query, userId))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("can't find user with id=%s - %w", userId, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("email update operation failed: %w", err)
+	}
+
+	if user.Email != oldEmail {
+		return nil, fmt.Errorf("email changed since confirmation was requested - %w", ErrVersionConflict)
+	}
+
+	user.Email = newEmail
+	user.Version++
+	user.UpdatedAt = time.Now()
+
+	query = "UPDATE users SET email=$1, version=$2, updatedAt=$3 WHERE id=$4;"
+	if _, err = tx.Exec(ctx, query, user.Email, user.Version, user.UpdatedAt, user.Id); err != nil {
+		if werr := writeError(err, ErrEmailAlreadyTaken); werr != nil {
+			return nil, werr
+		}
+		return nil, fmt.Errorf("email update operation failed: %w", err)
+	}
+
+	changes := []domain.ProfileChange{{Field: domain.ProfileFieldEmail, OldValue: oldEmail, NewValue: newEmail}}
+	if err = saveProfileChanges(ctx, tx, user, changes); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("email update commit failed: %w", err)
+	}
+
+	return user, nil
+}
+
+// saveProfileChanges sends all change rows in one batch, a single round trip however many fields changed.
+func saveProfileChanges(ctx context.Context, tx pgx.Tx, user *domain.User, changes []domain.ProfileChange) error {
+	query := "INSERT INTO profile_changes(userId, field, oldValue, newValue, version, changedAt) VALUES ($1, $2, $3, $4, $5, $6);"
+
+	batch := &pgx.Batch{}
+	for _, change := range changes {
+		batch.Queue(query, user.Id, change.Field, change.OldValue, change.NewValue, user.Version, user.UpdatedAt)
+	}
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("profile change audit failed: %w", err)
+	}
+	return nil
+}
+
+// UpdatePasswordHash publishes EventPasswordChanged together with the new hash.
+func (r *UserRepository) UpdatePasswordHash(ctx context.Context, userId uuid.UUID, hash []byte) error {
+	query := "UPDATE users SET password=$1 WHERE id=$2;"
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("password hash update transaction start failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, hash, userId)
+	if err != nil {
+		return fmt.Errorf("password hash update operation failed: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	err = appendEvent(ctx, tx, domain.EventPasswordChanged, userId, domain.PasswordChangedPayload{ChangedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("password hash update commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// ListEmails returns id, email and registration date of every account, oldest first.
+func (r *UserRepository) ListEmails(ctx context.Context) ([]domain.UserPublic, error) {
+	query := "SELECT id, email, registerDate FROM users ORDER BY registerDate;"
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("email list operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.UserPublic, 0)
+	for rows.Next() {
+		user := domain.UserPublic{}
+		if err = rows.Scan(&user.Id, &user.Email, &user.RegisterDate); err != nil {
+			return nil, fmt.Errorf("email list operation failed: %w", err)
+		}
+		result = append(result, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("email list operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// BackfillEmail stores the normalised form of an address listed by ListEmails, deleted accounts
+// included. It is the same address, so neither the version nor the change history is touched.
+func (r *UserRepository) BackfillEmail(ctx context.Context, userId uuid.UUID, oldEmail, newEmail string) error {
+	query := "UPDATE users SET email=$1 WHERE id=$2 AND email=$3;"
+
+	result, err := r.pool.Exec(ctx, query, newEmail, userId, oldEmail)
+	if err != nil {
+		if werr := writeError(err, ErrEmailAlreadyTaken); werr != nil {
+			return werr
+		}
+		return fmt.Errorf("email backfill operation failed: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("email of user with id=%s changed since it was listed - %w", userId, ErrVersionConflict)
+	}
+
+	return nil
+}
+
+// ListProfileChanges returns the profile change history of the user, oldest first.
+func (r *UserRepository) ListProfileChanges(ctx context.Context, userId uuid.UUID) ([]domain.ProfileChangeRecord, error) {
+	query := `SELECT field, coalesce(oldValue, ''), coalesce(newValue, ''), version, changedAt
+		FROM profile_changes WHERE userId=$1 ORDER BY changedAt, id;`
+
+	rows, err := r.pool.Query(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("profile changes list operation failed: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]domain.ProfileChangeRecord, 0)
+	for rows.Next() {
+		change := domain.ProfileChangeRecord{}
+		if err = rows.Scan(&change.Field, &change.OldValue, &change.NewValue, &change.Version, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("profile changes list operation failed: %w", err)
+		}
+		result = append(result, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("profile changes list operation failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetDeleted finds an account that was unregistered but not purged yet.
+func (r *UserRepository) GetDeleted(ctx context.Context, email string) (*domain.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE lower(email)=lower($1) AND deletedAt IS NOT NULL;"
+
+	user, err := scanUser(r.pool.QueryRow(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("can't find deleted user with email=%s - %w", email, ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("deleted user retrieve operation failed: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) SoftDelete(ctx context.Context, userId uuid.UUID) error {
+	return r.setDeletedAt(ctx, userId, true)
+}
+
+func (r *UserRepository) Restore(ctx context.Context, userId uuid.UUID) error {
+	return r.setDeletedAt(ctx, userId, false)
+}
+
+// setDeletedAt marks or unmarks the account deleted and publishes the matching event.
+func (r *UserRepository) setDeletedAt(ctx context.Context, userId uuid.UUID, deleted bool) error {
+	operation, query := "user restore", "UPDATE users SET deletedAt=NULL WHERE id=$1 AND deletedAt IS NOT NULL;"
+	if deleted {
+		operation, query = "user soft delete", "UPDATE users SET deletedAt=now() WHERE id=$1 AND deletedAt IS NULL;"
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s transaction start failed: %w", operation, err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, query, userId)
+	if err != nil {
+		return fmt.Errorf("%s operation failed: %w", operation, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+
+	now := time.Now().UTC()
+	if deleted {
+		err = appendEvent(ctx, tx, domain.EventUserDeleted, userId, domain.UserDeletedPayload{DeletedAt: now})
+	} else {
+		err = appendEvent(ctx, tx, domain.EventUserRestored, userId, domain.UserRestoredPayload{RestoredAt: now})
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s commit failed: %w", operation, err)
+	}
+
+	return nil
+}
+
+// PurgeDeleted hard-deletes accounts soft-deleted before the given moment.
+func (r *UserRepository) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := "DELETE FROM users WHERE deletedAt IS NOT NULL AND deletedAt < $1;"
+
+	result, err := r.pool.Exec(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("deleted users purge operation failed: %w", err)
+	}
+
+	return result.RowsAffected(), nil
 }
 
 func (r *UserRepository) Delete(ctx context.Context, userId uuid.UUID) error {
 	query := "DELETE FROM users WHERE id=$1;"
 
-	result, err := r.db.ExecContext(ctx, query, userId)
+	result, err := r.pool.Exec(ctx, query, userId)
 	if err != nil {
 		return fmt.Errorf("user remove operation failed: %w", err)
 	}
 
-	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+	if result.RowsAffected() == 0 {
 		return ErrUserNotFound
 	}
 
@@ -140,7 +540,7 @@ func (r *UserRepository) Delete(ctx context.Context, userId uuid.UUID) error {
 }
 
 func (r *UserRepository) Exit() {
-	if r.db != nil {
-		r.db.Close()
+	if r.pool != nil {
+		r.pool.Close()
 	}
 }
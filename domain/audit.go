@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEvent records who did what to whom. Actor is zero for anonymous callers.
+// Id and Hash are assigned by the storage.
+type AuditEvent struct {
+	Id int64
+	Action string
+	Actor uuid.UUID
+	Target uuid.UUID
+	Source Source
+	Outcome string
+	Reason string
+	Metadata map[string]string
+	OccurredAt time.Time
+	Hash []byte
+}
+
+// AuditQuery selects events newest first. A set Subject matches events where
+// the user is either the actor or the target; BeforeId continues a previous page.
+type AuditQuery struct {
+	Subject uuid.UUID
+	Action string
+	BeforeId int64
+	Limit int
+}
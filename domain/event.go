@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventUserRegistered = "user.registered"
+	// EventUserDeleted is published when the owner unregisters. The account can still be
+	// restored within the grace period, EventUserRestored follows in that case.
+	EventUserDeleted = "user.deleted"
+	EventUserRestored = "user.restored"
+	// EventPasswordChanged is published whenever the stored password hash changes,
+	// a hash upgraded on login included.
+	EventPasswordChanged = "user.password_changed"
+	EventSessionRevoked = "session.revoked"
+)
+
+// Event is a domain event for other car_estimator services. Delivery is at least once,
+// so consumers drop repeated events by Id.
+type Event struct {
+	Id uuid.UUID `json:"id"`
+	Type string `json:"type"`
+	UserId uuid.UUID `json:"userId"`
+	Payload json.RawMessage `json:"payload"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// NewEvent assigns a fresh idempotency key and encodes the payload.
+func NewEvent(eventType string, userId uuid.UUID, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("%s event payload encoding failed: %w", eventType, err)
+	}
+
+	return Event{
+		Id: uuid.New(),
+		Type: eventType,
+		UserId: userId,
+		Payload: data,
+		OccurredAt: time.Now().UTC(),
+	}, nil
+}
+
+type UserRegisteredPayload struct {
+	Email string `json:"email"`
+	FullName string `json:"fullname"`
+	RegisterDate time.Time `json:"registerDate"`
+}
+
+type UserDeletedPayload struct {
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+type UserRestoredPayload struct {
+	RestoredAt time.Time `json:"restoredAt"`
+}
+
+type PasswordChangedPayload struct {
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// SessionRevokedPayload tells whether one session or every session of the user ended.
+type SessionRevokedPayload struct {
+	All bool `json:"all"`
+	Reason string `json:"reason"`
+}
+
+// OutboxEvent is an event waiting in the outbox together with its delivery state.
+type OutboxEvent struct {
+	Event
+	Attempts int
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataExportVersion is bumped whenever the layout of DataExport changes,
+// so consumers of old exports can tell the documents apart.
+const DataExportVersion = 3
+
+// DataExport is everything the service keeps about a single user, handed out on
+// a data subject access request. The password hash and refresh tokens are never included.
+type DataExport struct {
+	Version int `json:"version"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Profile ExportedProfile `json:"profile"`
+	Account ExportedAccount `json:"account"`
+	Roles ExportedRoles `json:"roles"`
+	Sessions []ExportedSession `json:"sessions"`
+	ProfileChanges []ProfileChangeRecord `json:"profileChanges"`
+	AuditEvents []ExportedAuditEvent `json:"auditEvents"`
+}
+
+type ExportedProfile struct {
+	Id uuid.UUID `json:"id"`
+	FullName string `json:"fullName"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+	BirthDate time.Time `json:"birthDate"`
+	RegisterDate time.Time `json:"registerDate"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Version int `json:"version"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// ExportedAccount is the account status. The status reason is internal everywhere else,
+// but the user is entitled to it on an access request.
+type ExportedAccount struct {
+	Status string `json:"status"`
+	StatusReason string `json:"statusReason,omitempty"`
+	StatusChangedAt *time.Time `json:"statusChangedAt,omitempty"`
+	SuspendedUntil *time.Time `json:"suspendedUntil,omitempty"`
+}
+
+// ExportedRoles are the role assignments of the user and the permissions they add up to.
+type ExportedRoles struct {
+	Assignments []ExportedRoleAssignment `json:"assignments"`
+	Permissions []string `json:"permissions"`
+}
+
+type ExportedRoleAssignment struct {
+	Role string `json:"role"`
+	GrantedBy *uuid.UUID `json:"grantedBy,omitempty"`
+	GrantedAt time.Time `json:"grantedAt"`
+}
+
+type ExportedSession struct {
+	IpAddress string `json:"ipAddress"`
+	UserAgent string `json:"userAgent"`
+	CreatedAt time.Time `json:"createdAt"`
+	AuthTime time.Time `json:"authTime"`
+}
+
+// ExportedAuditEvent is an audit log entry where the user is the actor or the target,
+// without the hash chain link.
+type ExportedAuditEvent struct {
+	Action string `json:"action"`
+	Actor uuid.UUID `json:"actor"`
+	Target uuid.UUID `json:"target"`
+	IpAddress string `json:"ipAddress"`
+	UserAgent string `json:"userAgent"`
+	Outcome string `json:"outcome"`
+	Reason string `json:"reason,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// ProfileChangeRecord is a stored row of the profile change history.
+type ProfileChangeRecord struct {
+	Field string `json:"field"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+	Version int `json:"version"`
+	ChangedAt time.Time `json:"changedAt"`
+}
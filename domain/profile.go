@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ProfileFieldFullName = "fullname"
+	ProfileFieldPhone = "phone"
+	ProfileFieldBirthDate = "birthdate"
+	ProfileFieldEmail = "email"
+)
+
+// ProfileUpdate is a partial profile change: only fields listed in Mask are written.
+// A zero ExpectedVersion skips the optimistic concurrency check.
+type ProfileUpdate struct {
+	Mask []string
+	FullName string
+	Phone string
+	BirthDate time.Time
+	ExpectedVersion int
+}
+
+func (u ProfileUpdate) Has(field string) bool {
+	for _, f := range u.Mask {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+type ProfileChange struct {
+	Field string
+	OldValue string
+	NewValue string
+}
+
+type EmailChange struct {
+	UserId uuid.UUID `json:"userId"`
+	OldEmail string `json:"oldEmail"`
+	NewEmail string `json:"newEmail"`
+	RequestedAt time.Time `json:"requestedAt"`
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PermissionUsersRead = "users:read"
+	PermissionUsersManage = "users:manage"
+	PermissionRolesRead = "roles:read"
+	PermissionRolesManage = "roles:manage"
+	PermissionAuditRead = "audit:read"
+)
+
+type Role struct {
+	Name string
+	Description string
+	Permissions []string
+}
+
+type RoleAssignment struct {
+	UserId uuid.UUID
+	Role string
+	GrantedBy uuid.UUID
+	GrantedAt time.Time
+}
+
+// Grants are the roles of a user and the permissions they add up to,
+// as embedded into access tokens.
+type Grants struct {
+	Roles []string
+	Permissions []string
+}
+
+func (g Grants) HasPermission(permission string) bool {
+	for _, p := range g.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -10,6 +10,7 @@ type Session struct {
 	UserId    uuid.UUID `json:"userId"`
 	Email 	  string `json:"email"`
 	CreatedAt time.Time `json:"createdAt"`
+	AuthTime  time.Time `json:"authTime"`
 	Source
 }
 
@@ -6,10 +6,45 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	UserStatusActive = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusDisabled = "disabled"
+	UserStatusPendingVerification = "pending_verification"
+)
+
 type User struct {
 	UserPublic
 	Password string
 	PasswordHash []byte
+	DeletedAt time.Time
+	Status string
+	StatusReason string
+	StatusChangedAt time.Time
+	// SuspendedUntil ends a suspension by itself, zero means until lifted by an admin.
+	SuspendedUntil time.Time
+}
+
+// StatusChange is written by administrators, Reason stays internal and is never shown to the user.
+type StatusChange struct {
+	Status string
+	Reason string
+	Until time.Time
+}
+
+// UserSearch filters users by prefixes, empty prefixes match everything.
+// Results are ordered by registration date and continue after the cursor.
+type UserSearch struct {
+	EmailPrefix string
+	FullNamePrefix string
+	PhonePrefix string
+	After *UserCursor
+	Limit int
+}
+
+type UserCursor struct {
+	RegisterDate time.Time
+	Id uuid.UUID
 }
 
 type UserPublic struct {
@@ -19,4 +54,6 @@ type UserPublic struct {
 	Phone string
 	BirthDate time.Time
 	RegisterDate time.Time
+	UpdatedAt time.Time
+	Version int
 }
@@ -0,0 +1,63 @@
+// Package events delivers domain events to a message broker. Every event is sent as its JSON
+// form with the idempotency key in "id", brokers that support deduplication get it natively too.
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+const (
+	DriverNATS = "nats"
+	DriverKafka = "kafka"
+	DriverMemory = "memory"
+)
+
+const (
+	DefaultTopic = "car_estimator.auth"
+	defaultTimeout = 10 * time.Second
+)
+
+type Config struct {
+	Driver string
+	// Addr is the NATS server url list or the Kafka seed broker list, both comma separated.
+	Addr string
+	Username string
+	Password string
+	// Topic is the subject prefix for NATS, events go to "<Topic>.<event type>",
+	// and the topic name for Kafka, where events are keyed by user id.
+	Topic string
+	// JetStream waits for the stream acknowledgement instead of the server flush,
+	// the stream deduplicates redeliveries by the Nats-Msg-Id header.
+	JetStream bool
+	Timeout time.Duration
+}
+
+type Broker interface {
+	Publish(ctx context.Context, event domain.Event) error
+	Close() error
+}
+
+func New(conf *Config) (Broker, error) {
+	settings := *conf
+	if settings.Topic == "" {
+		settings.Topic = DefaultTopic
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = defaultTimeout
+	}
+
+	switch settings.Driver {
+	case DriverNATS:
+		return NewNATSBroker(settings)
+	case DriverKafka:
+		return NewKafkaBroker(settings)
+	case DriverMemory:
+		return NewMemoryBroker(), nil
+	default:
+		return nil, fmt.Errorf("unknown event broker driver %q", settings.Driver)
+	}
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+// KafkaBroker produces with an idempotent producer, so the broker drops the duplicates of its
+// own retries, and acks from all in-sync replicas. Records are keyed by user id to keep the
+// events of a user in one partition, consumers deduplicate outbox redeliveries by the "id" header.
+type KafkaBroker struct {
+	client *kgo.Client
+}
+
+// NewKafkaBroker takes the comma separated seed brokers in Addr, a username enables SASL/PLAIN.
+func NewKafkaBroker(conf Config) (*KafkaBroker, error) {
+	options := []kgo.Opt{
+		kgo.SeedBrokers(strings.Split(conf.Addr, ",")...),
+		kgo.DefaultProduceTopic(conf.Topic),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.RecordDeliveryTimeout(conf.Timeout),
+		kgo.ProducerLinger(0),
+	}
+	if conf.Username != "" {
+		options = append(options, kgo.SASL(plain.Auth{User: conf.Username, Pass: conf.Password}.AsMechanism()))
+	}
+
+	client, err := kgo.NewClient(options...)
+	if err != nil {
+		return nil, fmt.Errorf("kafka client creation failed: %w", err)
+	}
+
+	return &KafkaBroker{client: client}, nil
+}
+
+func (b *KafkaBroker) Publish(ctx context.Context, event domain.Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("kafka record encoding failed: %w", err)
+	}
+
+	record := &kgo.Record{
+		Key: []byte(event.UserId.String()),
+		Value: value,
+		Headers: []kgo.RecordHeader{{Key: "id", Value: []byte(event.Id.String())}},
+	}
+
+	if err = b.client.ProduceSync(ctx, record).FirstErr(); err != nil {
+		return fmt.Errorf("kafka produce of %s failed: %w", event.Id, err)
+	}
+
+	return nil
+}
+
+func (b *KafkaBroker) Close() error {
+	b.client.Close()
+	return nil
+}
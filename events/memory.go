@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+// MemoryBroker hands events to in-process handlers and keeps every published event.
+// It is meant for tests and local runs without a broker.
+type MemoryBroker struct {
+	mu sync.Mutex
+	handlers []func(domain.Event)
+	published []domain.Event
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+// Subscribe registers a handler for events published from now on.
+func (b *MemoryBroker) Subscribe(handler func(domain.Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, event domain.Event) error {
+	b.mu.Lock()
+	b.published = append(b.published, event)
+	handlers := append([]func(domain.Event){}, b.handlers...)
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+
+	return nil
+}
+
+// Published returns the events in the order they were published, repeats included.
+func (b *MemoryBroker) Published() []domain.Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]domain.Event{}, b.published...)
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
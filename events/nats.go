@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+var ErrNoStream = errors.New("no jetstream stream captures the subject")
+
+// NATSBroker publishes with the official client, which keeps reconnecting in the background.
+// Publishes are not buffered while it is disconnected, the outbox relay retries them instead.
+type NATSBroker struct {
+	conf Config
+	conn *nats.Conn
+	js jetstream.JetStream
+}
+
+// NewNATSBroker accepts the server list in Addr as nats.Connect does, a tls:// url enables TLS.
+// The service starts even if the server is down, the first publishes fail until it is reachable.
+func NewNATSBroker(conf Config) (*NATSBroker, error) {
+	options := []nats.Option{
+		nats.Name("car_estimator_authorization"),
+		nats.Timeout(conf.Timeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectBufSize(-1),
+	}
+	if conf.Username != "" {
+		options = append(options, nats.UserInfo(conf.Username, conf.Password))
+	}
+
+	conn, err := nats.Connect(conf.Addr, options...)
+	if err != nil {
+		return nil, fmt.Errorf("nats connection failed: %w", err)
+	}
+
+	broker := &NATSBroker{conf: conf, conn: conn}
+	if conf.JetStream {
+		if broker.js, err = jetstream.New(conn); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("jetstream context creation failed: %w", err)
+		}
+	}
+
+	return broker, nil
+}
+
+// Publish sends the event to "<Topic>.<event type>" with its id in the Nats-Msg-Id header.
+// It returns once the server flushed it or, with JetStream, once the stream stored it.
+func (b *NATSBroker) Publish(ctx context.Context, event domain.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("nats message encoding failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.conf.Timeout)
+	defer cancel()
+
+	subject := b.conf.Topic + "." + event.Type
+
+	if b.js != nil {
+		// a duplicate ack is a success: the stream has the event already, which is what a redelivery wants
+		if _, err = b.js.Publish(ctx, subject, payload, jetstream.WithMsgID(event.Id.String())); err != nil {
+			if errors.Is(err, jetstream.ErrNoStreamResponse) {
+				err = ErrNoStream
+			}
+			return fmt.Errorf("nats publish of %s failed: %w", event.Id, err)
+		}
+		return nil
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Header.Set(nats.MsgIdHdr, event.Id.String())
+	msg.Data = payload
+
+	if err = b.conn.PublishMsg(msg); err != nil {
+		return fmt.Errorf("nats publish of %s failed: %w", event.Id, err)
+	}
+
+	// without JetStream the flush tells the server has processed the message
+	if err = b.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("nats publish of %s failed: %w", event.Id, err)
+	}
+
+	return nil
+}
+
+func (b *NATSBroker) Close() error {
+	b.conn.Close()
+	return nil
+}
@@ -0,0 +1,309 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/google/uuid"
+	pb "github.com/nikita-itmo-gh-acc/car_estimator_api_contracts/gen/profile_v1"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+type IProfileService interface {
+	GetProfile(ctx context.Context, userId uuid.UUID) (*domain.UserPublic, error)
+	UpdateProfile(ctx context.Context, userId uuid.UUID, update domain.ProfileUpdate) (*domain.UserPublic, error)
+}
+
+type IAccountRestoreService interface {
+	RestoreAccount(ctx context.Context, email, password string, source domain.Source) (*domain.TokenPair, *uuid.UUID, error)
+}
+
+type IExportService interface {
+	Export(ctx context.Context, userId uuid.UUID) (*domain.DataExport, error)
+}
+
+type IReauthenticationService interface {
+	Reauthenticate(ctx context.Context, userId uuid.UUID, password string, source domain.Source) (string, time.Time, error)
+}
+
+type IEmailChangeService interface {
+	RequestEmailChange(ctx context.Context, userId uuid.UUID, password, newEmail string, source domain.Source) error
+	ConfirmEmailChange(ctx context.Context, token string, source domain.Source) (*domain.UserPublic, error)
+}
+
+// AccountAPI is the self-service API of an authenticated user. The audited methods
+// may carry the source of the client.
+type AccountAPI struct {
+	Profile IProfileService
+	Email IEmailChangeService
+	Restorer IAccountRestoreService
+	Reauth IReauthenticationService
+	Export IExportService
+	pb.UnimplementedAccountServiceServer
+}
+
+func RegisterAccountServer(
+		srv *grpc.Server,
+		profile IProfileService,
+		email IEmailChangeService,
+		restorer IAccountRestoreService,
+		reauth IReauthenticationService,
+		export IExportService) {
+	pb.RegisterAccountServiceServer(srv, &AccountAPI{
+		Profile: profile,
+		Email: email,
+		Restorer: restorer,
+		Reauth: reauth,
+		Export: export,
+	})
+}
+
+func profileToProto(user *domain.UserPublic) *pb.Profile {
+	return &pb.Profile{
+		UserId: &pb.UUID{Value: user.Id.String()},
+		Fullname: user.FullName,
+		Email: user.Email,
+		Phone: user.Phone,
+		Birthdate: user.BirthDate.Unix(),
+		Registerdate: user.RegisterDate.Unix(),
+		UpdatedAt: user.UpdatedAt.Unix(),
+		Version: int64(user.Version),
+	}
+}
+
+func (s *AccountAPI) GetProfile(ctx context.Context, in *emptypb.Empty) (*pb.Profile, error) {
+	userId, err := GetUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.Profile.GetProfile(ctx, userId)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "profile retrieve op failed")
+	}
+
+	return profileToProto(user), nil
+}
+
+// UpdateProfile writes only the fields of updateMask: fullname, phone, birthdate.
+// The version is optional and enables the optimistic lock.
+func (s *AccountAPI) UpdateProfile(ctx context.Context, in *pb.UpdateProfileRequest) (*pb.Profile, error) {
+	userId, err := GetUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mask := in.GetUpdateMask().GetPaths()
+	if len(mask) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "updateMask is required")
+	}
+
+	update := domain.ProfileUpdate{
+		Mask: mask,
+		FullName: in.GetFullname(),
+		Phone: in.GetPhone(),
+		BirthDate: time.Unix(in.GetBirthdate(), 0),
+	}
+
+	if in.Version != nil {
+		if in.GetVersion() <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "version must be a positive integer")
+		}
+		update.ExpectedVersion = int(in.GetVersion())
+	}
+
+	user, err := s.Profile.UpdateProfile(ctx, userId, update)
+	if err != nil {
+		if stErr, ok := invalidArgument(err); ok {
+			return nil, stErr
+		}
+
+		switch {
+		case errors.Is(err, database.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		case errors.Is(err, database.ErrVersionConflict):
+			return nil, status.Error(codes.Aborted, "profile was modified concurrently, reload and retry")
+		case errors.Is(err, database.ErrPhoneAlreadyTaken):
+			return nil, status.Error(codes.AlreadyExists, "phone already used by another account")
+		default:
+			return nil, status.Error(codes.Internal, "profile update failed")
+		}
+	}
+
+	return profileToProto(user), nil
+}
+
+// RequestEmailChange checks the password and mails a confirmation token to the new address.
+func (s *AccountAPI) RequestEmailChange(ctx context.Context, in *pb.EmailChangeRequest) (*emptypb.Empty, error) {
+	userId, err := GetUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is required")
+	}
+
+	if in.GetNewEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "newEmail is required")
+	}
+
+	if err = s.Email.RequestEmailChange(ctx, userId, in.GetPassword(), in.GetNewEmail(), sourceOf(ctx, in.GetSource())); err != nil {
+		if stErr, ok := invalidArgument(err); ok {
+			return nil, stErr
+		}
+
+		if stErr, ok := accountStatus(err); ok {
+			return nil, stErr
+		}
+
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			return nil, status.Error(codes.PermissionDenied, "wrong password")
+		case errors.Is(err, database.ErrEmailAlreadyTaken):
+			return nil, status.Error(codes.AlreadyExists, "email already used by another account")
+		case errors.Is(err, database.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		default:
+			return nil, status.Error(codes.Internal, "email change request failed")
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ConfirmEmailChange takes the token from the confirmation mail, it needs no access token.
+func (s *AccountAPI) ConfirmEmailChange(ctx context.Context, in *pb.ConfirmEmailChangeRequest) (*pb.Profile, error) {
+	if in.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	user, err := s.Email.ConfirmEmailChange(ctx, in.GetToken(), sourceOf(ctx, in.GetSource()))
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrTokenNotFound):
+			return nil, status.Error(codes.NotFound, "confirmation token is invalid or expired")
+		case errors.Is(err, database.ErrEmailAlreadyTaken):
+			return nil, status.Error(codes.AlreadyExists, "email already used by another account")
+		case errors.Is(err, database.ErrVersionConflict):
+			return nil, status.Error(codes.FailedPrecondition, "email was changed after the confirmation was requested")
+		case errors.Is(err, database.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		default:
+			return nil, status.Error(codes.Internal, "email change confirmation failed")
+		}
+	}
+
+	return profileToProto(user), nil
+}
+
+// RestoreAccount cancels a pending deletion and logs the user in, it takes and answers what Login does.
+func (s *AccountAPI) RestoreAccount(ctx context.Context, in *pb.LoginRequest) (*pb.LoginResponse, error) {
+	if in.GetEmail() == "" || in.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	tokens, userId, err := s.Restorer.RestoreAccount(ctx, in.GetEmail(), in.GetPassword(), sourceOf(ctx, in.GetSource()))
+	if err != nil {
+		if stErr, ok := accountStatus(err); ok {
+			return nil, stErr
+		}
+
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			return nil, status.Error(codes.InvalidArgument, "wrong email or password")
+		case errors.Is(err, services.ErrAlreadyLoggedIn):
+			return nil, status.Error(codes.AlreadyExists, "user already logged in")
+		default:
+			return nil, status.Error(codes.Internal, "account restore failed")
+		}
+	}
+
+	return &pb.LoginResponse{
+		UserId: &pb.UUID{Value: userId.String()},
+		Tokens: &pb.TokenResponse{
+			AccessToken: tokens.Access,
+			RefreshToken: tokens.Refresh,
+		},
+	}, nil
+}
+
+// Reauthenticate confirms the password of a logged in user and answers an elevated token,
+// which is sent as the bearer token of sensitive operations such as Unregister or RequestEmailChange.
+func (s *AccountAPI) Reauthenticate(ctx context.Context, in *pb.ReauthenticateRequest) (*pb.ReauthenticateResponse, error) {
+	userId, err := GetUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is required")
+	}
+
+	token, expiresAt, err := s.Reauth.Reauthenticate(ctx, userId, in.GetPassword(), sourceOf(ctx, in.GetSource()))
+	if err != nil {
+		if stErr, ok := accountStatus(err); ok {
+			return nil, stErr
+		}
+
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			return nil, status.Error(codes.PermissionDenied, "wrong password")
+		case errors.Is(err, database.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		default:
+			return nil, status.Error(codes.Internal, "re-authentication failed")
+		}
+	}
+
+	return &pb.ReauthenticateResponse{
+		ElevatedToken: token,
+		ExpiresAt: expiresAt.Unix(),
+	}, nil
+}
+
+// ExportData answers the export document in the requested format, json by default.
+func (s *AccountAPI) ExportData(ctx context.Context, in *pb.ExportDataRequest) (*pb.ExportDataResponse, error) {
+	userId, err := GetUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	format := in.GetFormat()
+	if format == "" {
+		format = services.ExportFormatJSON
+	}
+
+	export, err := s.Export.Export(ctx, userId)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "data export failed")
+	}
+
+	data, err := services.EncodeExport(export, format)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedExportFormat) {
+			return nil, status.Error(codes.InvalidArgument, "format must be json or zip")
+		}
+		return nil, status.Error(codes.Internal, "data export encoding failed")
+	}
+
+	return &pb.ExportDataResponse{
+		Format: format,
+		Version: int32(export.Version),
+		Data: data,
+	}, nil
+}
@@ -0,0 +1,257 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/google/uuid"
+	pb "github.com/nikita-itmo-gh-acc/car_estimator_api_contracts/gen/profile_v1"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+type IAdminService interface {
+	SearchUsers(ctx context.Context, actorId uuid.UUID, search domain.UserSearch, pageToken string) ([]domain.User, string, error)
+	GetUser(ctx context.Context, actorId, userId uuid.UUID) (*domain.User, *domain.Grants, error)
+	DisableUser(ctx context.Context, actorId, userId uuid.UUID, reason string, source domain.Source) error
+	SuspendUser(ctx context.Context, actorId, userId uuid.UUID, reason string, until time.Time, source domain.Source) error
+	EnableUser(ctx context.Context, actorId, userId uuid.UUID, source domain.Source) error
+	ForceLogout(ctx context.Context, actorId, userId uuid.UUID, source domain.Source) error
+	ResetMFA(ctx context.Context, actorId, userId uuid.UUID, source domain.Source) error
+	UnlockUser(ctx context.Context, actorId, userId uuid.UUID, source domain.Source) error
+}
+
+// AdminAPI is the administrative API, every method requires an admin permission.
+// Requests may carry the source of the administrator for the audit log.
+type AdminAPI struct {
+	Admin IAdminService
+	pb.UnimplementedAdminServiceServer
+}
+
+// AdminPermissions declares the permission every AdminService method requires.
+var AdminPermissions = map[string][]string{
+	pb.AdminService_SearchUsers_FullMethodName: {domain.PermissionUsersRead},
+	pb.AdminService_GetUser_FullMethodName: {domain.PermissionUsersRead},
+	pb.AdminService_DisableUser_FullMethodName: {domain.PermissionUsersManage},
+	pb.AdminService_SuspendUser_FullMethodName: {domain.PermissionUsersManage},
+	pb.AdminService_EnableUser_FullMethodName: {domain.PermissionUsersManage},
+	pb.AdminService_ForceLogout_FullMethodName: {domain.PermissionUsersManage},
+	pb.AdminService_ResetMFA_FullMethodName: {domain.PermissionUsersManage},
+	pb.AdminService_UnlockUser_FullMethodName: {domain.PermissionUsersManage},
+}
+
+func RegisterAdminServer(srv *grpc.Server, admin IAdminService) {
+	pb.RegisterAdminServiceServer(srv, &AdminAPI{ Admin: admin })
+}
+
+func adminUserToProto(user *domain.User) *pb.AdminUser {
+	record := &pb.AdminUser{
+		UserId: &pb.UUID{Value: user.Id.String()},
+		Fullname: user.FullName,
+		Email: user.Email,
+		Phone: user.Phone,
+		Birthdate: user.BirthDate.Unix(),
+		Registerdate: user.RegisterDate.Unix(),
+		UpdatedAt: user.UpdatedAt.Unix(),
+		Version: int64(user.Version),
+		Status: user.Status,
+		StatusReason: user.StatusReason,
+	}
+
+	if !user.StatusChangedAt.IsZero() {
+		record.StatusChangedAt = user.StatusChangedAt.Unix()
+	}
+
+	if !user.SuspendedUntil.IsZero() {
+		record.SuspendedUntil = user.SuspendedUntil.Unix()
+	}
+
+	if !user.DeletedAt.IsZero() {
+		record.DeletedAt = user.DeletedAt.Unix()
+	}
+
+	return record
+}
+
+// SearchUsers filters by the given prefixes, every field of the request is optional.
+func (s *AdminAPI) SearchUsers(ctx context.Context, in *pb.SearchUsersRequest) (*pb.SearchUsersResponse, error) {
+	actorId, err := GetUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	search := domain.UserSearch{
+		EmailPrefix: in.GetEmailPrefix(),
+		FullNamePrefix: in.GetNamePrefix(),
+		PhonePrefix: in.GetPhonePrefix(),
+	}
+
+	if in.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "pageSize must be a positive integer")
+	}
+	search.Limit = min(int(in.GetPageSize()), services.MaxSearchPageSize)
+
+	users, nextPageToken, err := s.Admin.SearchUsers(ctx, actorId, search, in.GetPageToken())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPageToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid pageToken")
+		}
+		return nil, status.Error(codes.Internal, "user search failed")
+	}
+
+	resp := &pb.SearchUsersResponse{
+		Users: make([]*pb.AdminUser, 0, len(users)),
+		NextPageToken: nextPageToken,
+	}
+	for i := range users {
+		resp.Users = append(resp.Users, adminUserToProto(&users[i]))
+	}
+
+	return resp, nil
+}
+
+// GetUser answers the full record without the password hash, including roles and permissions.
+func (s *AdminAPI) GetUser(ctx context.Context, in *pb.AdminUserRequest) (*pb.AdminUserResponse, error) {
+	actorId, userId, err := adminTarget(ctx, in.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, grants, err := s.Admin.GetUser(ctx, actorId, userId)
+	if err != nil {
+		return nil, adminError(err, "user retrieve failed")
+	}
+
+	return &pb.AdminUserResponse{
+		User: adminUserToProto(user),
+		Roles: grants.Roles,
+		Permissions: grants.Permissions,
+	}, nil
+}
+
+// DisableUser requires a reason and ends all sessions of the user.
+func (s *AdminAPI) DisableUser(ctx context.Context, in *pb.DisableUserRequest) (*emptypb.Empty, error) {
+	actorId, userId, err := adminTarget(ctx, in.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetReason() == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+
+	if err = s.Admin.DisableUser(ctx, actorId, userId, in.GetReason(), sourceOf(ctx, in.GetSource())); err != nil {
+		return nil, adminError(err, "user disable failed")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// SuspendUser requires a reason, without until the suspension lasts until EnableUser.
+// Sessions of the user are ended.
+func (s *AdminAPI) SuspendUser(ctx context.Context, in *pb.SuspendUserRequest) (*emptypb.Empty, error) {
+	actorId, userId, err := adminTarget(ctx, in.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetReason() == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+
+	var until time.Time
+	if in.GetUntil() != 0 {
+		until = time.Unix(in.GetUntil(), 0)
+	}
+
+	if err = s.Admin.SuspendUser(ctx, actorId, userId, in.GetReason(), until, sourceOf(ctx, in.GetSource())); err != nil {
+		return nil, adminError(err, "user suspend failed")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// EnableUser lifts suspensions and disables alike.
+func (s *AdminAPI) EnableUser(ctx context.Context, in *pb.AdminUserRequest) (*emptypb.Empty, error) {
+	actorId, userId, err := adminTarget(ctx, in.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Admin.EnableUser(ctx, actorId, userId, sourceOf(ctx, in.GetSource())); err != nil {
+		return nil, adminError(err, "user enable failed")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ForceLogout drops every session of the user.
+func (s *AdminAPI) ForceLogout(ctx context.Context, in *pb.AdminUserRequest) (*emptypb.Empty, error) {
+	actorId, userId, err := adminTarget(ctx, in.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Admin.ForceLogout(ctx, actorId, userId, sourceOf(ctx, in.GetSource())); err != nil {
+		return nil, adminError(err, "force logout failed")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ResetMFA drops the one-time passwords issued to the user.
+func (s *AdminAPI) ResetMFA(ctx context.Context, in *pb.AdminUserRequest) (*emptypb.Empty, error) {
+	actorId, userId, err := adminTarget(ctx, in.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Admin.ResetMFA(ctx, actorId, userId, sourceOf(ctx, in.GetSource())); err != nil {
+		return nil, adminError(err, "mfa reset failed")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// UnlockUser lifts the lockout left by failed logins.
+func (s *AdminAPI) UnlockUser(ctx context.Context, in *pb.AdminUserRequest) (*emptypb.Empty, error) {
+	actorId, userId, err := adminTarget(ctx, in.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Admin.UnlockUser(ctx, actorId, userId, sourceOf(ctx, in.GetSource())); err != nil {
+		return nil, adminError(err, "user unlock failed")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func adminTarget(ctx context.Context, target *pb.UUID) (actorId, userId uuid.UUID, err error) {
+	if actorId, err = GetUserId(ctx); err != nil {
+		return
+	}
+
+	userId, err = parseUUID(target, "userId")
+	return
+}
+
+func adminError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, database.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, services.ErrSelfDisable):
+		return status.Error(codes.FailedPrecondition, "own account can't be disabled")
+	case errors.Is(err, services.ErrSuspensionInPast):
+		return status.Error(codes.InvalidArgument, "until must be in the future")
+	default:
+		return status.Error(codes.Internal, fallback)
+	}
+}
@@ -0,0 +1,119 @@
+package grpc_server
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/nikita-itmo-gh-acc/car_estimator_api_contracts/gen/profile_v1"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+type IAuditService interface {
+	RecentActivity(ctx context.Context, userId uuid.UUID, beforeId int64, limit int) ([]domain.AuditEvent, error)
+	List(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEvent, error)
+}
+
+// AuditAPI reads the audit log, RecentActivity is open to every user for their own account.
+type AuditAPI struct {
+	Audit IAuditService
+	pb.UnimplementedAuditServiceServer
+}
+
+// AuditPermissions declares the permission every AuditService method requires.
+var AuditPermissions = map[string][]string{
+	pb.AuditService_RecentActivity_FullMethodName: nil,
+	pb.AuditService_ListEvents_FullMethodName: {domain.PermissionAuditRead},
+}
+
+func RegisterAuditServer(srv *grpc.Server, audit IAuditService) {
+	pb.RegisterAuditServiceServer(srv, &AuditAPI{ Audit: audit })
+}
+
+// RecentActivity answers the events of the calling user, newest first.
+func (s *AuditAPI) RecentActivity(ctx context.Context, in *pb.RecentActivityRequest) (*pb.AuditEventsResponse, error) {
+	userId, err := GetUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := auditPage(in.GetPageSize(), in.GetBeforeId())
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.Audit.RecentActivity(ctx, userId, query.BeforeId, query.Limit)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "activity retrieve failed")
+	}
+
+	return auditEventsResponse(events), nil
+}
+
+// ListEvents filters by the subject and the action, every field of the request is optional.
+func (s *AuditAPI) ListEvents(ctx context.Context, in *pb.ListEventsRequest) (*pb.AuditEventsResponse, error) {
+	query, err := auditPage(in.GetPageSize(), in.GetBeforeId())
+	if err != nil {
+		return nil, err
+	}
+
+	if in.GetUserId() != nil {
+		if query.Subject, err = parseUUID(in.GetUserId(), "userId"); err != nil {
+			return nil, err
+		}
+	}
+
+	query.Action = in.GetAction()
+
+	events, err := s.Audit.List(ctx, query)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "audit events retrieve failed")
+	}
+
+	return auditEventsResponse(events), nil
+}
+
+func auditPage(pageSize int32, beforeId int64) (domain.AuditQuery, error) {
+	query := domain.AuditQuery{}
+
+	if pageSize < 0 {
+		return query, status.Error(codes.InvalidArgument, "pageSize must be a positive integer")
+	}
+	query.Limit = min(int(pageSize), services.MaxAuditPageSize)
+
+	if beforeId < 0 {
+		return query, status.Error(codes.InvalidArgument, "beforeId must be a positive integer")
+	}
+	query.BeforeId = beforeId
+
+	return query, nil
+}
+
+func auditEventsResponse(events []domain.AuditEvent) *pb.AuditEventsResponse {
+	resp := &pb.AuditEventsResponse{Events: make([]*pb.AuditEvent, 0, len(events))}
+	for _, event := range events {
+		resp.Events = append(resp.Events, &pb.AuditEvent{
+			Id: event.Id,
+			Action: event.Action,
+			Actor: &pb.UUID{Value: event.Actor.String()},
+			Target: &pb.UUID{Value: event.Target.String()},
+			IpAddress: event.Source.IpAddress,
+			UserAgent: event.Source.UserAgent,
+			Outcome: event.Outcome,
+			Reason: event.Reason,
+			Metadata: event.Metadata,
+			OccurredAt: event.OccurredAt.Unix(),
+		})
+	}
+
+	// the next page starts after the oldest returned event, an empty page ends the listing
+	if len(events) > 0 {
+		resp.NextBeforeId = events[len(events)-1].Id
+	}
+
+	return resp
+}
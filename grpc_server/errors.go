@@ -0,0 +1,104 @@
+package grpc_server
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+// invalidArgument converts service validation errors into InvalidArgument
+// with a BadRequest detail listing every rejected field.
+func invalidArgument(err error) (error, bool) {
+	var validationErr *services.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, false
+	}
+
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range validationErr.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field: v.Field,
+			Description: v.Reason,
+		})
+	}
+
+	st, detailErr := status.New(codes.InvalidArgument, validationErr.Error()).WithDetails(badRequest)
+	if detailErr != nil {
+		return status.Error(codes.InvalidArgument, validationErr.Error()), true
+	}
+
+	return st.Err(), true
+}
+
+const errorDomain = "auth.car_estimator"
+
+// withErrorInfo attaches a machine-readable reason so clients don't have to parse messages.
+func withErrorInfo(code codes.Code, msg, reason string, metadata map[string]string) error {
+	st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: errorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+
+	return st.Err()
+}
+
+func pendingDeletion(err error) (error, bool) {
+	var pendingErr *services.PendingDeletionError
+	if !errors.As(err, &pendingErr) {
+		return nil, false
+	}
+
+	return withErrorInfo(
+		codes.FailedPrecondition,
+		"account is scheduled for deletion, it can be restored with AccountService/RestoreAccount",
+		"ACCOUNT_PENDING_DELETION",
+		map[string]string{"purgeAt": pendingErr.PurgeAt.Format(time.RFC3339)},
+	), true
+}
+
+// accountStatus explains why a blocked account can't sign in. The reason an admin
+// gave for blocking stays internal, clients only get the status and its end.
+func accountStatus(err error) (error, bool) {
+	var statusErr *services.AccountStatusError
+	if !errors.As(err, &statusErr) {
+		return nil, false
+	}
+
+	metadata := map[string]string{"status": statusErr.Status}
+	if !statusErr.Until.IsZero() {
+		metadata["until"] = statusErr.Until.Format(time.RFC3339)
+	}
+
+	switch {
+	case errors.Is(err, services.ErrAccountSuspended):
+		return withErrorInfo(codes.PermissionDenied, "account is suspended", "ACCOUNT_SUSPENDED", metadata), true
+	case errors.Is(err, services.ErrAccountNotVerified):
+		return withErrorInfo(codes.PermissionDenied, "account is pending verification", "ACCOUNT_PENDING_VERIFICATION", metadata), true
+	default:
+		return withErrorInfo(codes.PermissionDenied, "account is disabled", "ACCOUNT_DISABLED", metadata), true
+	}
+}
+
+// accountLocked tells when a lockout left by failed logins ends.
+func accountLocked(err error) (error, bool) {
+	var lockedErr *services.AccountLockedError
+	if !errors.As(err, &lockedErr) {
+		return nil, false
+	}
+
+	return withErrorInfo(
+		codes.PermissionDenied,
+		"account is locked after too many failed logins",
+		"ACCOUNT_LOCKED",
+		map[string]string{"until": lockedErr.Until.Format(time.RFC3339)},
+	), true
+}
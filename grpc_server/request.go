@@ -0,0 +1,66 @@
+package grpc_server
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/nikita-itmo-gh-acc/car_estimator_api_contracts/gen/profile_v1"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+func GetUserId(ctx context.Context) (uuid.UUID, error) {
+	val := ctx.Value("userId")
+	if val == nil {
+		return uuid.UUID{}, status.Error(codes.Unauthenticated, "can't find authenticated user")
+	}
+
+	userId, ok := val.(uuid.UUID)
+	if !ok {
+		return uuid.UUID{}, status.Error(codes.Internal, "authenticated user id has wrong type")
+	}
+
+	return userId, nil
+}
+
+// requestSource describes the caller for the audit log. The ip and userAgent sent by the
+// gateway win, the peer address and the user-agent header of the call fill in the rest.
+func requestSource(ctx context.Context, ip, userAgent string) domain.Source {
+	if ip == "" {
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			ip = p.Addr.String()
+		}
+	}
+
+	if userAgent == "" {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("user-agent"); len(values) > 0 {
+				userAgent = values[0]
+			}
+		}
+	}
+
+	return domain.Source{IpAddress: ip, UserAgent: userAgent}
+}
+
+// sourceOf reads the optional source of a request.
+func sourceOf(ctx context.Context, source *pb.SourceData) domain.Source {
+	return requestSource(ctx, source.GetIp(), source.GetUserAgent())
+}
+
+func parseUUID(id *pb.UUID, name string) (uuid.UUID, error) {
+	if id.GetValue() == "" {
+		return uuid.UUID{}, status.Error(codes.InvalidArgument, name + " is required")
+	}
+
+	result, err := uuid.Parse(id.GetValue())
+	if err != nil {
+		return uuid.UUID{}, status.Error(codes.InvalidArgument, name + " must be a valid uuid")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,134 @@
+package grpc_server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/google/uuid"
+	pb "github.com/nikita-itmo-gh-acc/car_estimator_api_contracts/gen/profile_v1"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+type IRoleService interface {
+	ListRoles(ctx context.Context) ([]domain.Role, error)
+	GetUserGrants(ctx context.Context, userId uuid.UUID) (*domain.Grants, error)
+	AssignRole(ctx context.Context, actorId, userId uuid.UUID, role string, source domain.Source) error
+	RevokeRole(ctx context.Context, actorId, userId uuid.UUID, role string, source domain.Source) error
+}
+
+// RoleAPI manages role assignments, permissions are checked by the auth interceptor.
+type RoleAPI struct {
+	Roles IRoleService
+	pb.UnimplementedRoleServiceServer
+}
+
+// RolePermissions declares the permission every RoleService method requires.
+var RolePermissions = map[string][]string{
+	pb.RoleService_ListRoles_FullMethodName: {domain.PermissionRolesRead},
+	pb.RoleService_GetUserRoles_FullMethodName: {domain.PermissionRolesRead},
+	pb.RoleService_AssignRole_FullMethodName: {domain.PermissionRolesManage},
+	pb.RoleService_RevokeRole_FullMethodName: {domain.PermissionRolesManage},
+}
+
+func RegisterRoleServer(srv *grpc.Server, roles IRoleService) {
+	pb.RegisterRoleServiceServer(srv, &RoleAPI{ Roles: roles })
+}
+
+func (s *RoleAPI) ListRoles(ctx context.Context, in *emptypb.Empty) (*pb.ListRolesResponse, error) {
+	roles, err := s.Roles.ListRoles(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "role list failed")
+	}
+
+	resp := &pb.ListRolesResponse{Roles: make([]*pb.Role, 0, len(roles))}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, &pb.Role{
+			Name: role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+
+	return resp, nil
+}
+
+// GetUserRoles answers the roles of the user and the permissions they add up to.
+func (s *RoleAPI) GetUserRoles(ctx context.Context, in *pb.UserRequest) (*pb.UserRolesResponse, error) {
+	userId, err := parseUUID(in.GetUserId(), "userId")
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := s.Roles.GetUserGrants(ctx, userId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "user roles retrieve failed")
+	}
+
+	return &pb.UserRolesResponse{
+		Roles: grants.Roles,
+		Permissions: grants.Permissions,
+	}, nil
+}
+
+func (s *RoleAPI) AssignRole(ctx context.Context, in *pb.RoleChangeRequest) (*emptypb.Empty, error) {
+	actorId, userId, err := roleChangeArgs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Roles.AssignRole(ctx, actorId, userId, in.GetRole(), requestSource(ctx, "", "")); err != nil {
+		switch {
+		case errors.Is(err, database.ErrRoleNotFound):
+			return nil, status.Error(codes.NotFound, "role not found")
+		case errors.Is(err, database.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, "user not found")
+		default:
+			return nil, status.Error(codes.Internal, "role assign failed")
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *RoleAPI) RevokeRole(ctx context.Context, in *pb.RoleChangeRequest) (*emptypb.Empty, error) {
+	actorId, userId, err := roleChangeArgs(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Roles.RevokeRole(ctx, actorId, userId, in.GetRole(), requestSource(ctx, "", "")); err != nil {
+		switch {
+		case errors.Is(err, database.ErrRoleNotAssigned):
+			return nil, status.Error(codes.NotFound, "role is not assigned to the user")
+		case errors.Is(err, services.ErrSelfRevoke):
+			return nil, status.Error(codes.FailedPrecondition, "own roles can't be revoked")
+		default:
+			return nil, status.Error(codes.Internal, "role revoke failed")
+		}
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func roleChangeArgs(ctx context.Context, in *pb.RoleChangeRequest) (actorId, userId uuid.UUID, err error) {
+	if actorId, err = GetUserId(ctx); err != nil {
+		return
+	}
+
+	if userId, err = parseUUID(in.GetUserId(), "userId"); err != nil {
+		return
+	}
+
+	if in.GetRole() == "" {
+		err = status.Error(codes.InvalidArgument, "role is required")
+	}
+
+	return
+}
@@ -33,8 +33,8 @@ type IAuthService interface {
 }
 
 type IRegistrarSesvice interface {
-	Register(ctx context.Context, user domain.User) (userId uuid.UUID, err error)
-	Unregister(ctx context.Context, refreshToken string) error
+	Register(ctx context.Context, user domain.User, source domain.Source) (userId uuid.UUID, err error)
+	Unregister(ctx context.Context, userId uuid.UUID, refreshToken string, source domain.Source) error
 }
 
 func RegisterServer(srv *grpc.Server, auth IAuthService, reg IRegistrarSesvice) {
@@ -68,6 +68,18 @@ func (s *ServerAPI) Login(ctx context.Context, in *pb.LoginRequest) (t *pb.Login
 
 	tokens, userId, err := s.Auth.Login(ctx, in.GetEmail(), in.GetPassword(), domain.Source{IpAddress: data.Ip, UserAgent: data.UserAgent})
 	if err != nil {
+		if stErr, ok := pendingDeletion(err); ok {
+			return nil, stErr
+		}
+
+		if stErr, ok := accountStatus(err); ok {
+			return nil, stErr
+		}
+
+		if stErr, ok := accountLocked(err); ok {
+			return nil, stErr
+		}
+
 		switch {
 		case errors.Is(err, services.ErrInvalidCredentials):
 			return nil, status.Error(codes.InvalidArgument, "wrong email or password")
@@ -154,8 +166,11 @@ func (s *ServerAPI) Register(ctx context.Context, in *pb.RegisterRequest) (t *pb
 		Password: in.Password,
 	}
 
-	userId, err := s.Registrar.Register(ctx, newUser)
+	userId, err := s.Registrar.Register(ctx, newUser, requestSource(ctx, "", ""))
 	if err != nil {
+		if stErr, ok := invalidArgument(err); ok {
+			return nil, stErr
+		}
 		if errors.Is(err, database.ErrUserAlreadyExists) {
 			return nil, status.Error(codes.AlreadyExists, "user already exists")
 		}
@@ -172,10 +187,18 @@ func (s *ServerAPI) Unregister(ctx context.Context, in *emptypb.Empty) (*emptypb
 		return nil, err
 	}
 
-	if err = s.Registrar.Unregister(ctx, refreshToken); err != nil {
+	userId, err := GetUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.Registrar.Unregister(ctx, userId, refreshToken, requestSource(ctx, "", "")); err != nil {
 		log.Println(err)
-		if errors.Is(err, database.ErrSessionNotFound) {
+		switch {
+		case errors.Is(err, database.ErrSessionNotFound):
 			return nil, status.Error(codes.Unauthenticated, "user session not found")
+		case errors.Is(err, services.ErrSessionMismatch):
+			return nil, status.Error(codes.PermissionDenied, "refresh token belongs to another user")
 		}
 
 		return nil, status.Error(codes.Internal, "user unregister failed")
@@ -197,9 +220,15 @@ func (s *ServerAPI) Refresh(ctx context.Context, in *pb.SourceData) (t *pb.Token
 
 	tokens, err := s.Auth.Refresh(ctx, refreshToken, source)
 	if err != nil {
+		if stErr, ok := accountStatus(err); ok {
+			return nil, stErr
+		}
+
 		switch {
 		case errors.Is(err, database.ErrSessionNotFound):
 			return nil,	status.Error(codes.Unauthenticated, "user session not found")
+		case errors.Is(err, database.ErrUserNotFound):
+			return nil, status.Error(codes.Unauthenticated, "account no longer exists")
 		case errors.Is(err, services.ErrSourceChanged):
 			return nil, status.Error(codes.PermissionDenied, "attempt to enter from unknown device")
 		default:
@@ -2,15 +2,94 @@ package interceptors
 
 import (
 	"context"
+	"strings"
 
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
-	// "google.golang.org/grpc/codes"
-	// "google.golang.org/grpc/metadata"
-	// "google.golang.org/grpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
 )
 
-func AuthInterceptor(privateHandlers map[string]struct{}) grpc.UnaryServerInterceptor {
+// AuthInterceptor checks the access token passed as "authorization: Bearer <jwt>"
+// for the listed handlers and puts the caller id into the context under "userId"
+// and the token roles and permissions under "grants". Every handler maps to the
+// permissions it requires, an empty list lets any authenticated user through.
+func AuthInterceptor(protectedHandlers map[string][]string) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
-		return nil, nil
+		required, ok := protectedHandlers[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		ctx, token, err := authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		grants := services.GetTokenGrants(token)
+		if err = RequirePermissions(grants, required...); err != nil {
+			return nil, err
+		}
+
+		ctx = context.WithValue(ctx, "grants", grants)
+
+		return handler(ctx, req)
+	}
+}
+
+// RequirePermissions answers PermissionDenied naming the first permission the caller lacks.
+func RequirePermissions(grants domain.Grants, permissions ...string) error {
+	for _, permission := range permissions {
+		if grants.HasPermission(permission) {
+			continue
+		}
+
+		msg := "missing permission " + permission
+		st, err := status.New(codes.PermissionDenied, msg).WithDetails(&errdetails.ErrorInfo{
+			Reason: "PERMISSION_DENIED",
+			Domain: "auth.car_estimator",
+			Metadata: map[string]string{"permission": permission},
+		})
+		if err != nil {
+			return status.Error(codes.PermissionDenied, msg)
+		}
+		return st.Err()
+	}
+
+	return nil
+}
+
+// authenticate verifies the bearer token and stores its subject under "userId".
+func authenticate(ctx context.Context) (context.Context, *jwt.Token, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, nil, status.Error(codes.Unauthenticated, "metadata is missing")
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ctx, nil, status.Error(codes.Unauthenticated, "access token missing")
+	}
+
+	tokenString, found := strings.CutPrefix(values[0], "Bearer ")
+	if !found {
+		return ctx, nil, status.Error(codes.Unauthenticated, "authorization must use Bearer scheme")
 	}
-}
\ No newline at end of file
+
+	token, err := services.VerifyJWT(tokenString)
+	if err != nil {
+		return ctx, nil, status.Error(codes.Unauthenticated, "invalid access token")
+	}
+
+	userId, err := services.GetTokenSubject(token)
+	if err != nil {
+		return ctx, nil, status.Error(codes.Unauthenticated, "access token has no valid subject")
+	}
+
+	return context.WithValue(ctx, "userId", userId), token, nil
+}
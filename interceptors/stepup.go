@@ -0,0 +1,63 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+// StepUpInterceptor guards destructive operations. The access token of the caller must either
+// be an elevated one, issued by AccountService/Reauthenticate, or carry an "auth_time"
+// not older than maxAge. A refresh token alone is never enough.
+func StepUpInterceptor(sensitiveHandlers map[string]struct{}, maxAge time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := sensitiveHandlers[info.FullMethod]; !ok {
+			return handler(ctx, req)
+		}
+
+		ctx, token, err := authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !recentlyAuthenticated(token, maxAge) {
+			return nil, reauthenticationRequired()
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func recentlyAuthenticated(token *jwt.Token, maxAge time.Duration) bool {
+	if services.IsElevated(token) {
+		return true
+	}
+
+	authTime, ok := services.GetAuthTime(token)
+	if !ok {
+		return false
+	}
+
+	return time.Since(authTime) <= maxAge
+}
+
+func reauthenticationRequired() error {
+	msg := "recent authentication required, confirm the password with AccountService/Reauthenticate"
+
+	st, err := status.New(codes.Unauthenticated, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason: "REAUTHENTICATION_REQUIRED",
+		Domain: "auth.car_estimator",
+	})
+	if err != nil {
+		return status.Error(codes.Unauthenticated, msg)
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,85 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type Config struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// LogMailer writes messages to the log instead of delivering them.
+// It is meant for local runs where no SMTP relay is available. The body carries
+// single-use confirmation tokens, so only its size is logged.
+type LogMailer struct {
+	logger *slog.Logger
+}
+
+func NewLogMailer(logger *slog.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+func (m *LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.logger.InfoContext(ctx, "mail delivery skipped",
+		slog.String("to", to),
+		slog.String("subject", subject),
+		slog.Int("body_bytes", len(body)),
+	)
+	return nil
+}
+
+type SMTPMailer struct {
+	conf Config
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(conf *Config) (*SMTPMailer, error) {
+	host, _, err := net.SplitHostPort(conf.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	if conf.From == "" {
+		return nil, fmt.Errorf("smtp sender address is required")
+	}
+
+	var auth smtp.Auth
+	if conf.Username != "" {
+		auth = smtp.PlainAuth("", conf.Username, conf.Password, host)
+	}
+
+	return &SMTPMailer{
+		conf: *conf,
+		auth: auth,
+	}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return fmt.Errorf("mail headers must not contain line breaks")
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.conf.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := smtp.SendMail(m.conf.Addr, m.auth, m.conf.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("smtp delivery to %s failed: %w", to, err)
+	}
+
+	return nil
+}
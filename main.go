@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
-	"github.com/joho/godotenv"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/app"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/config"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
 )
 
 
@@ -37,48 +43,145 @@ func setupLogger(env string, filename string) (*slog.Logger, error) {
         log = slog.New(
 			slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelInfo}),
 		)
+	default:
+		return nil, fmt.Errorf("unknown mode %q", env)
     }
 
     return log, nil
 }
 
-func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatalln("error: can't find .env file")
+func applyMigrations(logger *slog.Logger, conf *database.Config) {
+	m, err := database.NewMigrator(conf)
+	if err != nil {
+		log.Fatalf("migrator creation error - %v\n", err)
+	}
+	defer m.Close()
+
+	if err = m.Apply(); err != nil {
+		log.Fatalf("migrations apply failure - %v\n", err)
+	}
+
+	logger.Info("migrations applied successfully!", slog.String("database", conf.DBName))
+}
+
+// bootstrapPostgres creates the database, migrates it and sets up the application role,
+// unless the bootstrap is turned off where the database is provisioned beforehand.
+func bootstrapPostgres(logger *slog.Logger, conf *config.Config, dbConf *database.Config) {
+	if !conf.Users.Bootstrap.Enabled {
+		logger.Info("database bootstrap is disabled", slog.String("database", dbConf.DBName))
+		return
+	}
+
+	bootstrapper := database.NewBootstrapper(dbConf, conf.BootstrapConfig())
+	if err := bootstrapper.Run(context.Background()); err != nil {
+		log.Fatalf("database bootstrap failure - %v\n", err)
 	}
 
-	logger, err := setupLogger(os.Getenv("MODE"), "")
+	logger.Info("database bootstrap complete", slog.String("database", dbConf.DBName))
+}
+
+// loadConfig loads the config of a command, -h prints the flags and exits.
+func loadConfig(name string, args []string) (*config.Config, []string) {
+	conf, rest, err := config.Load(name, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(exitOK)
+	}
 	if err != nil {
-		log.Fatalln("error: can't setup logger")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
 	}
+	return conf, rest
+}
 
-	pgConfig := &database.Config{
-		Driver: "postgres",
-		Addr: os.Getenv("PG_ADDR"),
-		User: os.Getenv("PG_USER"),
-		Password: os.Getenv("PG_PASSWORD"),
-		DBName: os.Getenv("PG_NAME"),
+func main() {
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	redisConfig := &database.Config{
-		Driver: "redis",
-		Addr: os.Getenv("REDIS_ADDR"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-		DBName: os.Getenv("REDIS_DB_NUM"),
+	switch command {
+	case "serve":
+		conf, rest := loadConfig(command, args)
+		if len(rest) != 0 {
+			fmt.Fprintf(os.Stderr, "serve takes no arguments\n\n%s\n", usage)
+			os.Exit(exitUsage)
+		}
+		serve(conf)
+	case "migrate":
+		conf, rest := loadConfig(command, args)
+		os.Exit(runMigrate(conf, rest))
+	case "config":
+		if len(args) == 0 || args[0] != "print" {
+			fmt.Fprintf(os.Stderr, "unknown config command\n\n%s\n", usage)
+			os.Exit(exitUsage)
+		}
+
+		conf, rest := loadConfig("config print", args[1:])
+		if len(rest) != 0 {
+			fmt.Fprintf(os.Stderr, "config print takes no arguments\n\n%s\n", usage)
+			os.Exit(exitUsage)
+		}
+		if err := conf.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(exitFailed)
+		}
+	case "help", "-h", "-help", "--help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", command, usage)
+		os.Exit(exitUsage)
 	}
+}
 
-	m, err := database.NewMigrator(pgConfig, os.Getenv("MIGRATIONS_DIR"))
+func serve(conf *config.Config) {
+	logger, err := setupLogger(conf.Mode, conf.LogFile)
 	if err != nil {
-		log.Fatalf("migrator creation error - %v\n", err)
+		log.Fatalf("error: can't setup logger - %v\n", err)
 	}
 
-	if err = m.Apply(); err != nil {
-		log.Fatalf("migrations apply failure - %v\n", err)
+	services.SetSigningKey([]byte(conf.JWTSecret))
+
+	userConfig := conf.UserDatabase()
+	switch userConfig.Driver {
+	case database.DriverMemory:
+		logger.Warn("user storage is in memory, all data is lost on exit")
+	case database.DriverSQLite:
+		applyMigrations(logger, userConfig)
+	default:
+		bootstrapPostgres(logger, conf, userConfig)
+		userConfig = conf.AsAppRole(userConfig)
 	}
 
-	logger.Info("migrations applied successfully!")
+	// postgres sessions live in the user database unless sessions.postgres_name names another one
+	sessionConfig := conf.SessionDatabase()
+	if sessionConfig.Driver == database.DriverPostgres {
+		if userConfig.Driver != database.DriverPostgres || sessionConfig.DBName != userConfig.DBName {
+			bootstrapPostgres(logger, conf, sessionConfig)
+		}
+		sessionConfig = conf.AsAppRole(sessionConfig)
+	}
+
+	// expvar serves the runtime and session index metrics at /debug/vars
+	if conf.MetricsAddr != "" {
+		go func() {
+			if err := http.ListenAndServe(conf.MetricsAddr, nil); err != nil {
+				logger.Error("metrics endpoint stopped", slog.Any("error", err))
+			}
+		}()
+	}
 
-	application, err := app.New(logger, pgConfig, redisConfig, 4444)
+	application, err := app.New(logger, &app.Config{
+		UserStorage: userConfig,
+		SessionStorage: sessionConfig,
+		Hasher: conf.Hasher(),
+		EmailNormalizer: conf.EmailNormalizer(),
+		AccountPolicy: conf.AccountPolicy(),
+		Mail: conf.MailConfig(),
+		LogMail: conf.Mode == config.ModeLocal,
+		Events: conf.EventsConfig(),
+		AuditHashChain: conf.AuditHashChain,
+		Port: conf.Port,
+	})
 	if err != nil {
 		log.Fatalf("application creation failed - %v\n", err)
 	}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/config"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+const usage = `usage:
+	serve [flags]                  run the service, the default
+	migrate [flags] up             apply every pending migration
+	migrate [flags] down N         revert the last N migrations
+	migrate [flags] goto V         migrate up or down to version V
+	migrate [flags] status         print the current version and whether it is dirty
+	migrate [flags] force V        set version V and clear the dirty flag, -1 for none
+	config print [flags]           print the effective config with the secrets redacted
+
+The settings come from the defaults, the -config YAML file, the environment and the flags,
+each overriding the ones before, "<command> -h" lists them.
+migrate works on the user database, sqlite3 included, as users.user.
+Exit codes: 0 - done, 1 - the migration failed or the database is dirty, 2 - invalid usage
+or the database is unreachable.`
+
+const (
+	exitOK = 0
+	exitFailed = 1
+	exitUsage = 2
+)
+
+// runMigrate runs the migrate subcommand and returns the exit code.
+func runMigrate(conf *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		return exitUsage
+	}
+
+	// the subcommands taking a number are checked before connecting
+	var number int
+	switch args[0] {
+	case "down", "goto", "force":
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "migrate %s needs a number\n\n%s\n", args[0], usage)
+			return exitUsage
+		}
+
+		value, err := strconv.Atoi(args[1])
+		if err != nil || value < -1 || (value < 0 && args[0] != "force") || (value == 0 && args[0] == "down") {
+			fmt.Fprintf(os.Stderr, "invalid number %q for migrate %s\n", args[1], args[0])
+			return exitUsage
+		}
+		number = value
+	case "up", "status":
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "migrate %s takes no arguments\n\n%s\n", args[0], usage)
+			return exitUsage
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q\n\n%s\n", args[0], usage)
+		return exitUsage
+	}
+
+	dbConf := conf.UserDatabase()
+	if dbConf.Driver == database.DriverMemory {
+		fmt.Fprintln(os.Stderr, "user storage is in memory, there is nothing to migrate")
+		return exitUsage
+	}
+
+	m, err := database.NewMigrator(dbConf)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't connect to user storage - %v\n", err)
+		return exitUsage
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		err = m.Apply()
+	case "down":
+		err = m.RollBack(number)
+	case "goto":
+		err = m.Goto(uint(number))
+	case "force":
+		err = m.Force(number)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	version, dirty, statusErr := m.Status()
+	if statusErr != nil {
+		fmt.Fprintln(os.Stderr, statusErr)
+		return exitFailed
+	}
+
+	switch {
+	case version == 0 && !dirty:
+		fmt.Println("no migrations applied")
+	case dirty:
+		fmt.Printf("version %d, dirty: the migration failed halfway, fix the database and run migrate force\n", version)
+	default:
+		fmt.Printf("version %d\n", version)
+	}
+
+	if err != nil || dirty {
+		return exitFailed
+	}
+	return exitOK
+}
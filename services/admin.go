@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+var (
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrSelfDisable = errors.New("can't disable own account")
+	ErrSuspensionInPast = errors.New("suspension must end in the future")
+)
+
+const (
+	DefaultSearchPageSize = 50
+	MaxSearchPageSize = 200
+)
+
+const (
+	AuditActionUserSearch = "admin.user.search"
+	AuditActionUserView = "admin.user.view"
+	AuditActionUserDisable = "admin.user.disable"
+	AuditActionUserSuspend = "admin.user.suspend"
+	AuditActionUserEnable = "admin.user.enable"
+	AuditActionForceLogout = "admin.user.force_logout"
+	AuditActionResetMFA = "admin.user.reset_mfa"
+	AuditActionUnlock = "admin.user.unlock"
+)
+
+type IUserAdministration interface {
+	GetIncludingDeleted(ctx context.Context, userId uuid.UUID) (*domain.User, error)
+	SearchUsers(ctx context.Context, search domain.UserSearch) ([]domain.User, error)
+	SetStatus(ctx context.Context, userId uuid.UUID, change domain.StatusChange) error
+}
+
+// AdminService backs the administrative API. Every action is written to the audit log.
+type AdminService struct {
+	users IUserAdministration
+	roleProvider IRoleProvider
+	sessionRemover ISessionRemover
+	lockout ILoginLockout
+	otps IOneTimePasswordRemover
+	audit IAuditLog
+	events IEventOutbox
+	logger *slog.Logger
+}
+
+func NewAdminService(
+		users IUserAdministration,
+		roleProvider IRoleProvider,
+		sessionRemover ISessionRemover,
+		lockout ILoginLockout,
+		otps IOneTimePasswordRemover,
+		audit IAuditLog,
+		events IEventOutbox,
+		logger *slog.Logger) *AdminService {
+	return &AdminService{
+		users: users,
+		roleProvider: roleProvider,
+		sessionRemover: sessionRemover,
+		lockout: lockout,
+		otps: otps,
+		audit: audit,
+		events: events,
+		logger: logger,
+	}
+}
+
+// SearchUsers returns a page of users matching the prefixes and the token of the next page,
+// which is empty on the last one.
+func (s *AdminService) SearchUsers(ctx context.Context, actorId uuid.UUID, search domain.UserSearch, pageToken string) ([]domain.User, string, error) {
+	if search.Limit <= 0 {
+		search.Limit = DefaultSearchPageSize
+	}
+	search.Limit = min(search.Limit, MaxSearchPageSize)
+
+	if pageToken != "" {
+		cursor, err := decodePageToken(pageToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("user search error - %w", err)
+		}
+		search.After = cursor
+	}
+
+	// one extra row tells whether another page exists
+	limit := search.Limit
+	search.Limit++
+
+	users, err := s.users.SearchUsers(ctx, search)
+	if err != nil {
+		return nil, "", fmt.Errorf("user search error - %w", err)
+	}
+
+	nextPageToken := ""
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[limit - 1]
+		nextPageToken = encodePageToken(domain.UserCursor{RegisterDate: last.RegisterDate, Id: last.Id})
+	}
+
+	s.record(ctx, domain.AuditEvent{
+		Action: AuditActionUserSearch,
+		Actor: actorId,
+		Outcome: domain.AuditOutcomeSuccess,
+		Metadata: map[string]string{
+			"emailPrefix": search.EmailPrefix,
+			"fullNamePrefix": search.FullNamePrefix,
+			"phonePrefix": search.PhonePrefix,
+			"results": strconv.Itoa(len(users)),
+		},
+	})
+
+	return users, nextPageToken, nil
+}
+
+func (s *AdminService) GetUser(ctx context.Context, actorId, userId uuid.UUID) (*domain.User, *domain.Grants, error) {
+	user, err := s.users.GetIncludingDeleted(ctx, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("admin get user error - %w", err)
+	}
+
+	grants, err := s.roleProvider.GetUserGrants(ctx, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("admin get user error - %w", err)
+	}
+
+	s.record(ctx, domain.AuditEvent{
+		Action: AuditActionUserView,
+		Actor: actorId,
+		Target: userId,
+		Outcome: domain.AuditOutcomeSuccess,
+	})
+
+	return user, grants, nil
+}
+
+// DisableUser blocks the account until it is enabled again and ends all its sessions.
+func (s *AdminService) DisableUser(ctx context.Context, actorId, userId uuid.UUID, reason string, source domain.Source) error {
+	err := s.block(ctx, AuditActionUserDisable, actorId, userId, source, domain.StatusChange{
+		Status: domain.UserStatusDisabled,
+		Reason: reason,
+	})
+	if err != nil {
+		return fmt.Errorf("disable user error - %w", err)
+	}
+
+	return nil
+}
+
+// SuspendUser blocks the account and ends all its sessions, a zero until suspends it indefinitely.
+func (s *AdminService) SuspendUser(ctx context.Context, actorId, userId uuid.UUID, reason string, until time.Time, source domain.Source) error {
+	if !until.IsZero() && !until.After(time.Now()) {
+		return fmt.Errorf("suspend user error - %w", ErrSuspensionInPast)
+	}
+
+	err := s.block(ctx, AuditActionUserSuspend, actorId, userId, source, domain.StatusChange{
+		Status: domain.UserStatusSuspended,
+		Reason: reason,
+		Until: until,
+	})
+	if err != nil {
+		return fmt.Errorf("suspend user error - %w", err)
+	}
+
+	return nil
+}
+
+func (s *AdminService) block(ctx context.Context, action string, actorId, userId uuid.UUID, source domain.Source, change domain.StatusChange) error {
+	if actorId == userId {
+		return ErrSelfDisable
+	}
+
+	err := s.users.SetStatus(ctx, userId, change)
+	if err == nil {
+		err = s.sessionRemover.DeleteUserSessions(ctx, userId)
+	}
+	if err == nil {
+		publishSessionRevoked(ctx, s.events, s.logger, userId, true, SessionRevokedAdmin)
+	}
+
+	event := domain.AuditEvent{
+		Action: action,
+		Actor: actorId,
+		Target: userId,
+		Source: source,
+		Reason: change.Reason,
+	}
+	if !change.Until.IsZero() {
+		event.Metadata = map[string]string{"until": change.Until.Format(time.RFC3339)}
+	}
+
+	s.record(ctx, outcome(event, err))
+	return err
+}
+
+func (s *AdminService) EnableUser(ctx context.Context, actorId, userId uuid.UUID, source domain.Source) error {
+	err := s.users.SetStatus(ctx, userId, domain.StatusChange{Status: domain.UserStatusActive})
+
+	s.record(ctx, outcome(domain.AuditEvent{
+		Action: AuditActionUserEnable,
+		Actor: actorId,
+		Target: userId,
+		Source: source,
+	}, err))
+
+	if err != nil {
+		return fmt.Errorf("enable user error - %w", err)
+	}
+
+	return nil
+}
+
+func (s *AdminService) ForceLogout(ctx context.Context, actorId, userId uuid.UUID, source domain.Source) error {
+	err := s.sessionRemover.DeleteUserSessions(ctx, userId)
+	if err == nil {
+		publishSessionRevoked(ctx, s.events, s.logger, userId, true, SessionRevokedAdmin)
+	}
+
+	s.record(ctx, outcome(domain.AuditEvent{
+		Action: AuditActionForceLogout,
+		Actor: actorId,
+		Target: userId,
+		Source: source,
+	}, err))
+
+	if err != nil {
+		return fmt.Errorf("force logout error - %w", err)
+	}
+
+	return nil
+}
+
+// ResetMFA drops the one-time passwords issued to the user, a login waiting for one has to start over.
+func (s *AdminService) ResetMFA(ctx context.Context, actorId, userId uuid.UUID, source domain.Source) error {
+	_, err := s.users.GetIncludingDeleted(ctx, userId)
+	if err == nil {
+		err = s.otps.DeleteOneTimePasswords(ctx, userId)
+	}
+
+	s.record(ctx, outcome(domain.AuditEvent{
+		Action: AuditActionResetMFA,
+		Actor: actorId,
+		Target: userId,
+		Source: source,
+	}, err))
+
+	if err != nil {
+		return fmt.Errorf("reset mfa error - %w", err)
+	}
+
+	return nil
+}
+
+// UnlockUser clears the failed login counter, so a user locked out by wrong passwords can sign in at once.
+func (s *AdminService) UnlockUser(ctx context.Context, actorId, userId uuid.UUID, source domain.Source) error {
+	_, err := s.users.GetIncludingDeleted(ctx, userId)
+	if err == nil {
+		err = s.lockout.ResetFailedLogins(ctx, userId)
+	}
+
+	s.record(ctx, outcome(domain.AuditEvent{
+		Action: AuditActionUnlock,
+		Actor: actorId,
+		Target: userId,
+		Source: source,
+	}, err))
+
+	if err != nil {
+		return fmt.Errorf("unlock user error - %w", err)
+	}
+
+	return nil
+}
+
+func (s *AdminService) record(ctx context.Context, event domain.AuditEvent) {
+	recordAudit(ctx, s.audit, s.logger, event)
+}
+
+// outcome keeps "user not found" readable in the audit log of admin actions.
+func outcome(event domain.AuditEvent, err error) domain.AuditEvent {
+	if errors.Is(err, database.ErrUserNotFound) && event.Reason == "" {
+		event.Reason = "user not found"
+	}
+	return auditOutcome(event, err)
+}
+
+func encodePageToken(cursor domain.UserCursor) string {
+	raw := strconv.FormatInt(cursor.RegisterDate.UnixNano(), 10) + ":" + cursor.Id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePageToken(token string) (*domain.UserCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	nanos, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return nil, ErrInvalidPageToken
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	userId, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	return &domain.UserCursor{RegisterDate: time.Unix(0, unixNano), Id: userId}, nil
+}
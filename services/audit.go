@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+const (
+	AuditActionLogin = "auth.login"
+	AuditActionRefresh = "auth.refresh"
+	AuditActionLogout = "auth.logout"
+	AuditActionReauthenticate = "auth.reauthenticate"
+	AuditActionRegister = "account.register"
+	AuditActionUnregister = "account.unregister"
+	AuditActionRestore = "account.restore"
+	AuditActionEmailChangeRequest = "account.email_change_request"
+	AuditActionEmailChange = "account.email_change"
+	AuditActionRoleAssign = "role.assign"
+	AuditActionRoleRevoke = "role.revoke"
+)
+
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize = 200
+)
+
+type IAuditLog interface {
+	Record(ctx context.Context, event domain.AuditEvent) error
+}
+
+type IAuditReader interface {
+	List(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEvent, error)
+}
+
+// recordAudit never fails the audited operation: by the time it is recorded it has already happened.
+func recordAudit(ctx context.Context, audit IAuditLog, logger *slog.Logger, event domain.AuditEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	if err := audit.Record(ctx, event); err != nil {
+		logger.ErrorContext(ctx, "audit event lost",
+			slog.String("action", event.Action),
+			slog.String("actor", event.Actor.String()),
+			slog.String("target", event.Target.String()),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// auditOutcome fills the outcome from the result of the operation, the reason of
+// a failure is its innermost error unless the caller has set one.
+func auditOutcome(event domain.AuditEvent, err error) domain.AuditEvent {
+	event.Outcome = domain.AuditOutcomeSuccess
+	if err != nil {
+		event.Outcome = domain.AuditOutcomeFailure
+		if event.Reason == "" {
+			event.Reason = rootCause(err).Error()
+		}
+	}
+	return event
+}
+
+func rootCause(err error) error {
+	for {
+		next, ok := err.(interface{ Unwrap() error })
+		if !ok || next.Unwrap() == nil {
+			return err
+		}
+		err = next.Unwrap()
+	}
+}
+
+// AuditService reads the audit log for users ("recent activity") and administrators.
+type AuditService struct {
+	reader IAuditReader
+}
+
+func NewAuditService(reader IAuditReader) *AuditService {
+	return &AuditService{reader: reader}
+}
+
+// RecentActivity returns events where the user is the actor or the target, newest first.
+func (s *AuditService) RecentActivity(ctx context.Context, userId uuid.UUID, beforeId int64, limit int) ([]domain.AuditEvent, error) {
+	return s.List(ctx, domain.AuditQuery{Subject: userId, BeforeId: beforeId, Limit: limit})
+}
+
+func (s *AuditService) List(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEvent, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultAuditPageSize
+	}
+	query.Limit = min(query.Limit, MaxAuditPageSize)
+
+	events, err := s.reader.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("audit list error - %w", err)
+	}
+
+	return events, nil
+}
@@ -10,21 +10,45 @@ import (
 	"github.com/google/uuid"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrSourceChanged = errors.New("source changed")
 	ErrAlreadyLoggedIn = errors.New("already logged in")
+	ErrAccountPendingDeletion = errors.New("account is scheduled for deletion")
 )
 
+type PendingDeletionError struct {
+	PurgeAt time.Time
+}
+
+func (e *PendingDeletionError) Error() string {
+	return fmt.Sprintf("%s, restorable until %s", ErrAccountPendingDeletion, e.PurgeAt.Format(time.RFC3339))
+}
+
+func (e *PendingDeletionError) Unwrap() error {
+	return ErrAccountPendingDeletion
+}
+
 type IUserProvider interface {
 	Get(ctx context.Context, email string) (*domain.User, error)
 	GetById(ctx context.Context, userId uuid.UUID) (*domain.User, error)
 }
 
+type IUserUpdater interface {
+	UpdatePasswordHash(ctx context.Context, userId uuid.UUID, hash []byte) error
+}
+
+type IAccountRestorer interface {
+	GetDeleted(ctx context.Context, email string) (*domain.User, error)
+	Restore(ctx context.Context, userId uuid.UUID) error
+}
+
+type IRoleProvider interface {
+	GetUserGrants(ctx context.Context, userId uuid.UUID) (*domain.Grants, error)
+}
+
 type ISessionProvider interface {
 	Get(ctx context.Context, token string) (*domain.Session, error)
 	GetUserSessions(ctx context.Context, userId uuid.UUID) ([]*domain.Session, error)
@@ -32,6 +56,9 @@ type ISessionProvider interface {
 
 type ISessionSaver interface {
 	Save(ctx context.Context, session *domain.Session, expiresIn time.Duration) (string, error)
+	// Rotate atomically replaces the token, it fails with database.ErrSessionNotFound
+	// when the token has already been used.
+	Rotate(ctx context.Context, token string, session *domain.Session, expiresIn time.Duration) (string, error)
 }
 
 type ISessionRemover interface {
@@ -41,28 +68,77 @@ type ISessionRemover interface {
 
 type AuthService struct {
 	userProvider IUserProvider
+	userUpdater IUserUpdater
+	accountRestorer IAccountRestorer
+	roleProvider IRoleProvider
 	sessionProvider ISessionProvider
 	sessionSaver ISessionSaver
 	sessionRemover ISessionRemover
+	lockout ILoginLockout
+	hasher IPasswordHasher
+	normalizer EmailNormalizer
+	policy AccountPolicy
+	audit IAuditLog
+	events IEventOutbox
 	logger *slog.Logger
 }
 
 func NewAuthService (
 		userProvider IUserProvider,
+		userUpdater IUserUpdater,
+		accountRestorer IAccountRestorer,
+		roleProvider IRoleProvider,
 		sessionProvider ISessionProvider, 
 		sessionSaver ISessionSaver,
 		sessionRemover ISessionRemover,
+		lockout ILoginLockout,
+		hasher IPasswordHasher,
+		normalizer EmailNormalizer,
+		policy AccountPolicy,
+		audit IAuditLog,
+		events IEventOutbox,
 		logger *slog.Logger) *AuthService {
 	return &AuthService{
 		userProvider: userProvider,
+		userUpdater: userUpdater,
+		accountRestorer: accountRestorer,
+		roleProvider: roleProvider,
 		sessionProvider: sessionProvider,
 		sessionSaver: sessionSaver,
 		sessionRemover: sessionRemover,
+		lockout: lockout,
+		hasher: hasher,
+		normalizer: normalizer,
+		policy: policy,
+		audit: audit,
+		events: events,
 		logger: logger,
 	}
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string, source domain.Source) (*domain.TokenPair, *uuid.UUID, error) {
+// auditAuth records an authentication event. The user is the target of every attempt
+// and becomes its actor once the attempt succeeds.
+func (s *AuthService) auditAuth(ctx context.Context, action string, userId uuid.UUID, source domain.Source, metadata map[string]string, err error) {
+	event := domain.AuditEvent{
+		Action: action,
+		Target: userId,
+		Source: source,
+		Metadata: metadata,
+	}
+
+	if err == nil {
+		event.Actor = userId
+	}
+
+	recordAudit(ctx, s.audit, s.logger, auditOutcome(event, err))
+}
+
+func (s *AuthService) Login(ctx context.Context, email, password string, source domain.Source) (tokens *domain.TokenPair, userId *uuid.UUID, err error) {
+	var target uuid.UUID
+	defer func() {
+		s.auditAuth(ctx, AuditActionLogin, target, source, map[string]string{"email": email}, err)
+	}()
+
 	log := s.logger.With(
 		slog.String("operation", "login"),
 		slog.String("email", email),
@@ -72,51 +148,258 @@ func (s *AuthService) Login(ctx context.Context, email, password string, source
 
 	log.Info("authorization attempt...")
 
+	email, err = s.normalizer.Normalize(email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("login error - %w", ErrInvalidCredentials)
+	}
+
 	user, err := s.userProvider.Get(ctx, email)
 	if err != nil {
 		if errors.Is(err, database.ErrUserNotFound) {
 			s.logger.WarnContext(ctx, "user not found", slog.Any("error", err))
+			if pendingErr := s.checkPendingDeletion(ctx, log, email, password); pendingErr != nil {
+				return nil, nil, fmt.Errorf("login error - %w", pendingErr)
+			}
 		}
 		return nil, nil, fmt.Errorf("login error - %w", ErrInvalidCredentials)
 	}
 
-	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)); err != nil {
-		return nil, nil, fmt.Errorf("login error - %w", ErrInvalidCredentials)
+	target = user.Id
+
+	failures, err := s.checkLockout(ctx, user.Id)
+	if err != nil {
+		log.Warn("login to a locked account", slog.Int("failures", failures))
+		return nil, nil, fmt.Errorf("login error - %w", err)
+	}
+
+	if err = s.verifyPassword(ctx, log, user, password); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			s.recordFailedLogin(ctx, log, user.Id)
+		}
+		return nil, nil, fmt.Errorf("login error - %w", err)
+	}
+
+	if failures > 0 {
+		if err = s.lockout.ResetFailedLogins(ctx, user.Id); err != nil {
+			log.Warn("failed to reset failed logins", slog.Any("error", err))
+		}
+	}
+
+	if err = checkAccountStatus(user, time.Now()); err != nil {
+		log.Warn("login to a blocked account", slog.String("status", user.Status), slog.String("reason", user.StatusReason))
+		return nil, nil, fmt.Errorf("login error - %w", err)
+	}
+
+	tokens, err = s.startSession(ctx, user, source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("login error - %w", err)
+	}
+
+	log.Info("successfully logged in!")
+	return tokens, &(user.Id), nil
+}
+
+// RestoreAccount cancels a pending deletion when the owner logs in within the grace period.
+func (s *AuthService) RestoreAccount(ctx context.Context, email, password string, source domain.Source) (tokens *domain.TokenPair, userId *uuid.UUID, err error) {
+	var target uuid.UUID
+	defer func() {
+		s.auditAuth(ctx, AuditActionRestore, target, source, map[string]string{"email": email}, err)
+	}()
+
+	log := s.logger.With(
+		slog.String("operation", "restore account"),
+		slog.String("email", email),
+		slog.String("ip address", source.IpAddress),
+		slog.String("user agent", source.UserAgent),
+	)
+
+	log.Info("account restore attempt...")
+
+	email, err = s.normalizer.Normalize(email)
+	if err != nil {
+		return nil, nil, fmt.Errorf("restore error - %w", ErrInvalidCredentials)
+	}
+
+	user, err := s.accountRestorer.GetDeleted(ctx, email)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			log.Warn("no deleted account found")
+			return nil, nil, fmt.Errorf("restore error - %w", ErrInvalidCredentials)
+		}
+		return nil, nil, fmt.Errorf("restore error - %w", err)
+	}
+
+	target = user.Id
+
+	if err = s.verifyPassword(ctx, log, user, password); err != nil {
+		return nil, nil, fmt.Errorf("restore error - %w", err)
+	}
+
+	if time.Now().After(s.policy.purgeAt(user.DeletedAt)) {
+		log.Warn("grace period is over, account awaits purge")
+		return nil, nil, fmt.Errorf("restore error - %w", ErrInvalidCredentials)
+	}
+
+	if err = checkAccountStatus(user, time.Now()); err != nil {
+		log.Warn("restore of a blocked account", slog.String("status", user.Status))
+		return nil, nil, fmt.Errorf("restore error - %w", err)
+	}
+
+	if err = s.accountRestorer.Restore(ctx, user.Id); err != nil {
+		return nil, nil, fmt.Errorf("restore error - %w", err)
+	}
+
+	log.Info("account restored", slog.String("userId", user.Id.String()))
+
+	tokens, err = s.startSession(ctx, user, source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("restore error - %w", err)
+	}
+
+	return tokens, &(user.Id), nil
+}
+
+// Reauthenticate checks the password of a logged in user once more and issues
+// a short-lived elevated token required by sensitive operations.
+func (s *AuthService) Reauthenticate(ctx context.Context, userId uuid.UUID, password string, source domain.Source) (token string, expiresAt time.Time, err error) {
+	defer func() {
+		s.auditAuth(ctx, AuditActionReauthenticate, userId, source, nil, err)
+	}()
+
+	log := s.logger.With(
+		slog.String("operation", "reauthenticate"),
+		slog.String("userId", userId.String()),
+	)
+
+	log.Info("re-authentication attempt...")
+
+	user, err := s.userProvider.GetById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			s.logger.WarnContext(ctx, "user not found", slog.Any("error", err))
+		}
+		return "", time.Time{}, fmt.Errorf("reauthenticate error - %w", err)
+	}
+
+	if err = s.verifyPassword(ctx, log, user, password); err != nil {
+		return "", time.Time{}, fmt.Errorf("reauthenticate error - %w", err)
+	}
+
+	if err = checkAccountStatus(user, time.Now()); err != nil {
+		return "", time.Time{}, fmt.Errorf("reauthenticate error - %w", err)
+	}
+
+	grants, err := s.roleProvider.GetUserGrants(ctx, user.Id)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("reauthenticate error - %w", err)
+	}
+
+	expiresAt = time.Now().Add(s.policy.ElevatedTokenTTL)
+
+	token, err = CreateElevatedJWT(user, *grants, s.policy.ElevatedTokenTTL)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("reauthenticate error - %w", err)
+	}
+
+	log.Info("elevated token issued")
+	return token, expiresAt, nil
+}
+
+// checkPendingDeletion tells the owner of a soft-deleted account that it still can be restored.
+// The password is checked first, so the state of an account is never revealed to strangers.
+func (s *AuthService) checkPendingDeletion(ctx context.Context, log *slog.Logger, email, password string) error {
+	user, err := s.accountRestorer.GetDeleted(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	if _, err = s.hasher.Verify(user.PasswordHash, password); err != nil {
+		return nil
+	}
+
+	purgeAt := s.policy.purgeAt(user.DeletedAt)
+	if time.Now().After(purgeAt) {
+		return nil
+	}
+
+	log.Info("login to an account pending deletion")
+	return &PendingDeletionError{PurgeAt: purgeAt}
+}
+
+func (s *AuthService) verifyPassword(ctx context.Context, log *slog.Logger, user *domain.User, password string) error {
+	needsRehash, err := s.hasher.Verify(user.PasswordHash, password)
+	if err != nil {
+		if !errors.Is(err, ErrPasswordMismatch) {
+			log.Error("password verification failed", slog.Any("error", err))
+		}
+		return ErrInvalidCredentials
+	}
+
+	if needsRehash {
+		s.upgradePasswordHash(ctx, log, user.Id, password)
 	}
 
+	return nil
+}
+
+func (s *AuthService) startSession(ctx context.Context, user *domain.User, source domain.Source) (*domain.TokenPair, error) {
 	userSessions, err := s.sessionProvider.GetUserSessions(ctx, user.Id)
 	if err != nil {
-		return nil, nil, fmt.Errorf("login error - user sessions search failure: %w", err)
+		return nil, fmt.Errorf("user sessions search failure: %w", err)
 	}
 
 	for _, session := range userSessions {
 		if source.IpAddress == session.IpAddress && source.UserAgent == session.UserAgent {
-			return nil, nil, fmt.Errorf("login error - %w", ErrAlreadyLoggedIn)
+			return nil, ErrAlreadyLoggedIn
 		}
 	}
 	
-	accessToken, err := CreateJWT(user)
+	grants, err := s.roleProvider.GetUserGrants(ctx, user.Id)
+	if err != nil {
+		return nil, fmt.Errorf("user grants retrieve failure: %w", err)
+	}
+
+	now := time.Now()
+
+	accessToken, err := CreateJWT(user, now, *grants)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	newSession := &domain.Session{
 		UserId: user.Id,
 		Email: user.Email,
 		Source: source,
-		CreatedAt: time.Now(),
+		CreatedAt: now,
+		AuthTime: now,
 	}
 
 	refreshToken, err := s.sessionSaver.Save(ctx, newSession, time.Hour * 24 * 30)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	log.Info("successfully logged in!")
 	return &domain.TokenPair{
 		Access: accessToken,
 		Refresh: refreshToken,
-	}, &(user.Id), nil
+	}, nil
+}
+
+// upgradePasswordHash stores a hash made with the current hasher settings.
+// Failures are only logged: the user already proved the password and the old hash stays valid.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, log *slog.Logger, userId uuid.UUID, password string) {
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Warn("failed to rehash password", slog.Any("error", err))
+		return
+	}
+
+	if err = s.userUpdater.UpdatePasswordHash(ctx, userId, hash); err != nil {
+		log.Warn("failed to store upgraded password hash", slog.Any("error", err))
+		return
+	}
+
+	log.Info("password hash upgraded")
 }
 
 func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
@@ -126,13 +409,22 @@ func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 
 	log.Info("exiting the system...")
 
-	if err := s.sessionRemover.Delete(ctx, refreshToken); err != nil {
+	// the session is read only to know whose logout it is
+	session, err := s.sessionProvider.Get(ctx, refreshToken)
+	if err != nil {
 		if errors.Is(err, database.ErrSessionNotFound) {
 			s.logger.WarnContext(ctx, "session is not found", slog.Any("error", err))
 		}
 		return fmt.Errorf("logout error - %w", err)
 	}
 
+	if err = s.sessionRemover.Delete(ctx, refreshToken); err != nil {
+		return fmt.Errorf("logout error - %w", err)
+	}
+
+	s.auditAuth(ctx, AuditActionLogout, session.UserId, session.Source, nil, nil)
+	publishSessionRevoked(ctx, s.events, s.logger, session.UserId, false, SessionRevokedLogout)
+
 	log.Info("successfully exited!")
 	return nil
 }
@@ -160,7 +452,15 @@ func (s *AuthService) GetUser(ctx context.Context, userId uuid.UUID) (*domain.Us
 	return &user.UserPublic, nil
 }
 
-func (s *AuthService) Refresh(ctx context.Context, refreshToken string, source domain.Source) (*domain.TokenPair, error) {
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, source domain.Source) (tokens *domain.TokenPair, err error) {
+	var target uuid.UUID
+	defer func() {
+		// a missing session is an expired or forged token, there is nobody to attribute it to
+		if target != uuid.Nil {
+			s.auditAuth(ctx, AuditActionRefresh, target, source, nil, err)
+		}
+	}()
+
 	log := s.logger.With(
 		slog.String("operation", "refresh"),
 		slog.String("ip address", source.IpAddress),
@@ -177,17 +477,48 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string, source d
 		return nil, fmt.Errorf("refresh error - %w", err)
 	}
 
-	if err = s.sessionRemover.Delete(ctx, refreshToken); err != nil {
-		return nil, fmt.Errorf("refresh error - %w", err)
-	}
+	target = session.UserId
+
+	// a presented refresh token is spent even when the refresh is refused
+	rotated := false
+	defer func() {
+		if err != nil && !rotated {
+			if revokeErr := s.sessionRemover.Delete(ctx, refreshToken); revokeErr != nil && !errors.Is(revokeErr, database.ErrSessionNotFound) {
+				log.Warn("failed to revoke refused refresh token", slog.Any("error", revokeErr))
+			}
+		}
+	}()
 
 	if session.IpAddress != source.IpAddress && session.UserAgent != source.UserAgent {
 		return nil, ErrSourceChanged
 	}
 
-	newRefreshToken, err := s.sessionSaver.Save(ctx, session, time.Hour * 24 * 30)
+	// the account is checked on every refresh, so blocking it stops live sessions
+	// once their access tokens expire
+	user, err := s.userProvider.GetById(ctx, session.UserId)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("refresh error - %w", err)
+	}
+
+	if err = checkAccountStatus(user, time.Now()); err != nil {
+		log.Warn("refresh for a blocked account", slog.String("userId", user.Id.String()), slog.String("status", user.Status))
+		return nil, fmt.Errorf("refresh error - %w", err)
+	}
+
+	// grants are read again, so role changes reach the user with the next refresh
+	grants, err := s.roleProvider.GetUserGrants(ctx, session.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("refresh error - %w", err)
+	}
+
+	// the old token is exchanged in one step, so of two concurrent refreshes only one succeeds
+	rotated = true
+	newRefreshToken, err := s.sessionSaver.Rotate(ctx, refreshToken, session, time.Hour * 24 * 30)
+	if err != nil {
+		if errors.Is(err, database.ErrSessionNotFound) {
+			log.Warn("refresh token was used concurrently", slog.String("userId", session.UserId.String()))
+		}
+		return nil, fmt.Errorf("refresh error - %w", err)
 	}
 
 	accessToken, err := CreateJWT(&domain.User{
@@ -195,7 +526,7 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string, source d
 			Id: session.UserId, 
 			Email: session.Email,
 		},
-	})
+	}, session.AuthTime, *grants)
 
 	if err != nil {
 		return nil, err
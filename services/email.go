@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+const (
+	emailChangePurpose = "email-change"
+	emailChangeTokenTTL = time.Hour * 24
+)
+
+type IMailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+type ITokenStorage interface {
+	Issue(ctx context.Context, purpose string, payload any, expiresIn time.Duration) (string, error)
+	// Peek reads the payload without redeeming the token.
+	Peek(ctx context.Context, purpose, token string, payload any) error
+	Consume(ctx context.Context, purpose, token string, payload any) error
+}
+
+type IEmailUpdater interface {
+	UpdateEmail(ctx context.Context, userId uuid.UUID, oldEmail, newEmail string) (*domain.User, error)
+}
+
+type ISessionUpdater interface {
+	UpdateUserEmail(ctx context.Context, userId uuid.UUID, email string) error
+}
+
+type EmailChangeService struct {
+	userProvider IUserProvider
+	emailUpdater IEmailUpdater
+	sessionUpdater ISessionUpdater
+	tokens ITokenStorage
+	mailer IMailer
+	hasher IPasswordHasher
+	normalizer EmailNormalizer
+	audit IAuditLog
+	logger *slog.Logger
+}
+
+func NewEmailChangeService(
+		userProvider IUserProvider,
+		emailUpdater IEmailUpdater,
+		sessionUpdater ISessionUpdater,
+		tokens ITokenStorage,
+		mailer IMailer,
+		hasher IPasswordHasher,
+		normalizer EmailNormalizer,
+		audit IAuditLog,
+		logger *slog.Logger) *EmailChangeService {
+	return &EmailChangeService{
+		userProvider: userProvider,
+		emailUpdater: emailUpdater,
+		sessionUpdater: sessionUpdater,
+		tokens: tokens,
+		mailer: mailer,
+		hasher: hasher,
+		normalizer: normalizer,
+		audit: audit,
+		logger: logger,
+	}
+}
+
+// RequestEmailChange checks the current password and the account status, then mails a
+// confirmation token to the new address. Nothing is changed until the token is confirmed.
+func (s *EmailChangeService) RequestEmailChange(ctx context.Context, userId uuid.UUID, password, newEmail string, source domain.Source) (err error) {
+	defer func() {
+		recordAudit(ctx, s.audit, s.logger, auditOutcome(domain.AuditEvent{
+			Action: AuditActionEmailChangeRequest,
+			Actor: userId,
+			Target: userId,
+			Source: source,
+			Metadata: map[string]string{"newEmail": newEmail},
+		}, err))
+	}()
+
+	log := s.logger.With(
+		slog.String("operation", "request email change"),
+		slog.String("userId", userId.String()),
+	)
+
+	log.Info("email change requested...")
+
+	user, err := s.userProvider.GetById(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("email change error - %w", err)
+	}
+
+	if _, err = s.hasher.Verify(user.PasswordHash, password); err != nil {
+		log.Warn("email change rejected: wrong password")
+		return fmt.Errorf("email change error - %w", ErrInvalidCredentials)
+	}
+
+	if err = checkAccountStatus(user, time.Now()); err != nil {
+		log.Warn("email change rejected: account is blocked", slog.String("status", user.Status))
+		return fmt.Errorf("email change error - %w", err)
+	}
+
+	if newEmail, err = s.normalizer.Normalize(newEmail); err != nil {
+		return fmt.Errorf("email change error - %w", err)
+	}
+
+	v := &validator{}
+	v.email(domain.ProfileFieldEmail, newEmail)
+	if newEmail == user.Email {
+		v.add(domain.ProfileFieldEmail, "must differ from the current email")
+	}
+	if err = v.err(); err != nil {
+		return fmt.Errorf("email change error - %w", err)
+	}
+
+	// lookups ignore case, so a different spelling of the own address is found here too
+	if owner, err := s.userProvider.Get(ctx, newEmail); err == nil {
+		if owner.Id != user.Id {
+			return fmt.Errorf("email change error - %w", database.ErrEmailAlreadyTaken)
+		}
+	} else if !errors.Is(err, database.ErrUserNotFound) {
+		return fmt.Errorf("email change error - %w", err)
+	}
+
+	change := domain.EmailChange{
+		UserId: user.Id,
+		OldEmail: user.Email,
+		NewEmail: newEmail,
+		RequestedAt: time.Now(),
+	}
+
+	token, err := s.tokens.Issue(ctx, emailChangePurpose, change, emailChangeTokenTTL)
+	if err != nil {
+		return fmt.Errorf("email change error - %w", err)
+	}
+
+	if err = s.mailer.Send(ctx, newEmail, "Confirm your new email",
+		fmt.Sprintf("Use this code to confirm the new email of your account: %s\nThe code expires in %s.", token, emailChangeTokenTTL),
+	); err != nil {
+		return fmt.Errorf("email change error - confirmation delivery failed: %w", err)
+	}
+
+	if err = s.mailer.Send(ctx, user.Email, "Email change requested",
+		fmt.Sprintf("Someone asked to move your account to %s. If it wasn't you, change your password now.", newEmail),
+	); err != nil {
+		log.Warn("failed to notify the current address", slog.Any("error", err))
+	}
+
+	log.Info("email change confirmation sent!")
+	return nil
+}
+
+// ConfirmEmailChange swaps the email for the one the token was issued for. The token is
+// redeemed only once the email is stored, so a failed attempt leaves it usable.
+func (s *EmailChangeService) ConfirmEmailChange(ctx context.Context, token string, source domain.Source) (*domain.UserPublic, error) {
+	log := s.logger.With(
+		slog.String("operation", "confirm email change"),
+	)
+
+	change := domain.EmailChange{}
+	if err := s.tokens.Peek(ctx, emailChangePurpose, token, &change); err != nil {
+		if errors.Is(err, database.ErrTokenNotFound) {
+			log.Warn("unknown or expired email change token")
+		}
+		return nil, fmt.Errorf("email change confirm error - %w", err)
+	}
+
+	log = log.With(slog.String("userId", change.UserId.String()))
+
+	user, err := s.emailUpdater.UpdateEmail(ctx, change.UserId, change.OldEmail, change.NewEmail)
+	recordAudit(ctx, s.audit, s.logger, auditOutcome(domain.AuditEvent{
+		Action: AuditActionEmailChange,
+		Actor: change.UserId,
+		Target: change.UserId,
+		Source: source,
+		Metadata: map[string]string{"oldEmail": change.OldEmail, "newEmail": change.NewEmail},
+	}, err))
+	if err != nil {
+		if errors.Is(err, database.ErrEmailAlreadyTaken) {
+			log.Warn("new email was taken before confirmation")
+		}
+		return nil, fmt.Errorf("email change confirm error - %w", err)
+	}
+
+	// a token left behind can't change anything, UpdateEmail fails once the old email is gone
+	if err = s.tokens.Consume(ctx, emailChangePurpose, token, &domain.EmailChange{}); err != nil {
+		log.Warn("failed to redeem the email change token", slog.Any("error", err))
+	}
+
+	if err = s.sessionUpdater.UpdateUserEmail(ctx, user.Id, user.Email); err != nil {
+		log.Warn("failed to update email in active sessions", slog.Any("error", err))
+	}
+
+	if err = s.mailer.Send(ctx, change.OldEmail, "Your email was changed",
+		fmt.Sprintf("The email of your account was changed to %s.", change.NewEmail),
+	); err != nil {
+		log.Warn("failed to notify the previous address", slog.Any("error", err))
+	}
+
+	log.Info("email changed!")
+	return &user.UserPublic, nil
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZip = "zip"
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// IUserDataProvider reads the stored user data, accounts pending deletion included.
+type IUserDataProvider interface {
+	GetIncludingDeleted(ctx context.Context, userId uuid.UUID) (*domain.User, error)
+	ListProfileChanges(ctx context.Context, userId uuid.UUID) ([]domain.ProfileChangeRecord, error)
+	ListRoleAssignments(ctx context.Context, userId uuid.UUID) ([]domain.RoleAssignment, error)
+	GetUserGrants(ctx context.Context, userId uuid.UUID) (*domain.Grants, error)
+}
+
+// ExportService collects the data of a user for data subject access requests.
+type ExportService struct {
+	userData IUserDataProvider
+	sessionProvider ISessionProvider
+	auditReader IAuditReader
+	logger *slog.Logger
+}
+
+func NewExportService(userData IUserDataProvider, sessionProvider ISessionProvider, auditReader IAuditReader, logger *slog.Logger) *ExportService {
+	return &ExportService{
+		userData: userData,
+		sessionProvider: sessionProvider,
+		auditReader: auditReader,
+		logger: logger,
+	}
+}
+
+func (s *ExportService) Export(ctx context.Context, userId uuid.UUID) (*domain.DataExport, error) {
+	log := s.logger.With(
+		slog.String("operation", "export user data"),
+		slog.String("userId", userId.String()),
+	)
+
+	log.Info("collecting user data...")
+
+	user, err := s.userData.GetIncludingDeleted(ctx, userId)
+	if err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			s.logger.WarnContext(ctx, "user not found", slog.Any("error", err))
+		}
+		return nil, fmt.Errorf("export error - %w", err)
+	}
+
+	changes, err := s.userData.ListProfileChanges(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("export error - %w", err)
+	}
+
+	assignments, err := s.userData.ListRoleAssignments(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("export error - %w", err)
+	}
+
+	grants, err := s.userData.GetUserGrants(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("export error - %w", err)
+	}
+
+	sessions, err := s.sessionProvider.GetUserSessions(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("export error - %w", err)
+	}
+
+	auditEvents, err := s.auditEvents(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("export error - %w", err)
+	}
+
+	export := &domain.DataExport{
+		Version: domain.DataExportVersion,
+		GeneratedAt: time.Now().UTC(),
+		Profile: domain.ExportedProfile{
+			Id: user.Id,
+			FullName: user.FullName,
+			Email: user.Email,
+			Phone: user.Phone,
+			BirthDate: user.BirthDate,
+			RegisterDate: user.RegisterDate,
+			UpdatedAt: user.UpdatedAt,
+			Version: user.Version,
+		},
+		Account: domain.ExportedAccount{
+			Status: user.Status,
+			StatusReason: user.StatusReason,
+		},
+		Roles: domain.ExportedRoles{
+			Assignments: make([]domain.ExportedRoleAssignment, 0, len(assignments)),
+			Permissions: grants.Permissions,
+		},
+		Sessions: make([]domain.ExportedSession, 0, len(sessions)),
+		ProfileChanges: changes,
+		AuditEvents: auditEvents,
+	}
+
+	if !user.DeletedAt.IsZero() {
+		export.Profile.DeletedAt = &user.DeletedAt
+	}
+
+	if !user.StatusChangedAt.IsZero() {
+		export.Account.StatusChangedAt = &user.StatusChangedAt
+	}
+
+	if !user.SuspendedUntil.IsZero() {
+		export.Account.SuspendedUntil = &user.SuspendedUntil
+	}
+
+	for _, assignment := range assignments {
+		exported := domain.ExportedRoleAssignment{Role: assignment.Role, GrantedAt: assignment.GrantedAt}
+		if grantedBy := assignment.GrantedBy; grantedBy != uuid.Nil {
+			exported.GrantedBy = &grantedBy
+		}
+		export.Roles.Assignments = append(export.Roles.Assignments, exported)
+	}
+
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, domain.ExportedSession{
+			IpAddress: session.IpAddress,
+			UserAgent: session.UserAgent,
+			CreatedAt: session.CreatedAt,
+			AuthTime: session.AuthTime,
+		})
+	}
+
+	log.Info("user data collected",
+		slog.Int("roles", len(export.Roles.Assignments)),
+		slog.Int("sessions", len(export.Sessions)),
+		slog.Int("profile changes", len(export.ProfileChanges)),
+		slog.Int("audit events", len(export.AuditEvents)),
+	)
+
+	return export, nil
+}
+
+// auditEvents pages through every event where the user is the actor or the target.
+func (s *ExportService) auditEvents(ctx context.Context, userId uuid.UUID) ([]domain.ExportedAuditEvent, error) {
+	result := []domain.ExportedAuditEvent{}
+	query := domain.AuditQuery{Subject: userId, Limit: MaxAuditPageSize}
+
+	for {
+		events, err := s.auditReader.List(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(events) == 0 {
+			return result, nil
+		}
+
+		for _, event := range events {
+			result = append(result, domain.ExportedAuditEvent{
+				Action: event.Action,
+				Actor: event.Actor,
+				Target: event.Target,
+				IpAddress: event.Source.IpAddress,
+				UserAgent: event.Source.UserAgent,
+				Outcome: event.Outcome,
+				Reason: event.Reason,
+				Metadata: event.Metadata,
+				OccurredAt: event.OccurredAt,
+			})
+		}
+
+		query.BeforeId = events[len(events) - 1].Id
+	}
+}
+
+// EncodeExport renders the export as a single JSON document or as a zip archive
+// with one file per section and a manifest.json listing their checksums.
+func EncodeExport(export *domain.DataExport, format string) ([]byte, error) {
+	switch format {
+	case ExportFormatJSON:
+		return json.MarshalIndent(export, "", "  ")
+	case ExportFormatZip:
+		return encodeExportZip(export)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedExportFormat, format)
+	}
+}
+
+type exportManifest struct {
+	Version int `json:"version"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	UserId uuid.UUID `json:"userId"`
+	Files []exportManifestFile `json:"files"`
+}
+
+type exportManifestFile struct {
+	Name string `json:"name"`
+	Size int `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func encodeExportZip(export *domain.DataExport) ([]byte, error) {
+	sections := []struct {
+		name string
+		value any
+	}{
+		{"profile.json", export.Profile},
+		{"account.json", export.Account},
+		{"roles.json", export.Roles},
+		{"sessions.json", export.Sessions},
+		{"profile_changes.json", export.ProfileChanges},
+		{"audit_events.json", export.AuditEvents},
+	}
+
+	manifest := exportManifest{
+		Version: export.Version,
+		GeneratedAt: export.GeneratedAt,
+		UserId: export.Profile.Id,
+	}
+
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+
+	for _, section := range sections {
+		content, err := json.MarshalIndent(section.value, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("export section %s encoding failed: %w", section.name, err)
+		}
+
+		if err = writeZipFile(archive, section.name, export.GeneratedAt, content); err != nil {
+			return nil, err
+		}
+
+		sum := sha256.Sum256(content)
+		manifest.Files = append(manifest.Files, exportManifestFile{
+			Name: section.name,
+			Size: len(content),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("export manifest encoding failed: %w", err)
+	}
+
+	if err = writeZipFile(archive, "manifest.json", export.GeneratedAt, content); err != nil {
+		return nil, err
+	}
+
+	if err = archive.Close(); err != nil {
+		return nil, fmt.Errorf("export archive finalization failed: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeZipFile(archive *zip.Writer, name string, modified time.Time, content []byte) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name: name,
+		Method: zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return fmt.Errorf("export archive entry %s failed: %w", name, err)
+	}
+
+	if _, err = w.Write(content); err != nil {
+		return fmt.Errorf("export archive entry %s failed: %w", name, err)
+	}
+
+	return nil
+}
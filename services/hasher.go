@@ -0,0 +1,217 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var (
+	ErrPasswordMismatch = errors.New("password does not match hash")
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+	ErrUnsupportedAlgorithm = errors.New("unsupported hashing algorithm")
+)
+
+type IPasswordHasher interface {
+	Hash(password string) ([]byte, error)
+	Verify(hash []byte, password string) (needsRehash bool, err error)
+}
+
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type HasherConfig struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+func DefaultHasherConfig() *HasherConfig {
+	return &HasherConfig{
+		Algorithm: AlgorithmBcrypt,
+		BcryptCost: bcrypt.DefaultCost,
+		Argon2: Argon2Params{
+			Memory: 64 * 1024,
+			Iterations: 3,
+			Parallelism: 2,
+			SaltLength: 16,
+			KeyLength: 32,
+		},
+	}
+}
+
+// PasswordHasher produces PHC formatted hashes with the configured algorithm
+// and reports hashes made with another algorithm or weaker parameters as outdated.
+// Bcrypt hashes keep their native modular crypt form ($2a$...), which is what
+// all the previously stored passwords look like.
+type PasswordHasher struct {
+	conf HasherConfig
+}
+
+func NewPasswordHasher(conf *HasherConfig) (*PasswordHasher, error) {
+	if conf == nil {
+		conf = DefaultHasherConfig()
+	}
+
+	switch conf.Algorithm {
+	case AlgorithmBcrypt:
+		if conf.BcryptCost < bcrypt.MinCost || conf.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be in [%d, %d] range", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		p := conf.Argon2
+		if p.Memory == 0 || p.Iterations == 0 || p.Parallelism == 0 || p.SaltLength == 0 || p.KeyLength == 0 {
+			return nil, errors.New("argon2id parameters must be positive")
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, conf.Algorithm)
+	}
+
+	return &PasswordHasher{conf: *conf}, nil
+}
+
+func (h *PasswordHasher) Hash(password string) ([]byte, error) {
+	if h.conf.Algorithm == AlgorithmArgon2id {
+		return h.hashArgon2id(password)
+	}
+
+	return bcrypt.GenerateFromPassword([]byte(password), h.conf.BcryptCost)
+}
+
+func (h *PasswordHasher) Verify(hash []byte, password string) (bool, error) {
+	encoded := string(hash)
+
+	switch {
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return h.verifyBcrypt(hash, password)
+	case strings.HasPrefix(encoded, "$"+AlgorithmArgon2id+"$"):
+		return h.verifyArgon2id(encoded, password)
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+func (h *PasswordHasher) verifyBcrypt(hash []byte, password string) (bool, error) {
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, ErrPasswordMismatch
+		}
+		return false, fmt.Errorf("bcrypt verification failed: %w", err)
+	}
+
+	if h.conf.Algorithm != AlgorithmBcrypt {
+		return true, nil
+	}
+
+	cost, err := bcrypt.Cost(hash)
+	if err != nil {
+		return false, fmt.Errorf("bcrypt verification failed: %w", err)
+	}
+
+	return cost < h.conf.BcryptCost, nil
+}
+
+func (h *PasswordHasher) hashArgon2id(password string) ([]byte, error) {
+	p := h.conf.Argon2
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("salt generation failed: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return []byte(encodeArgon2id(p, salt, key)), nil
+}
+
+func (h *PasswordHasher) verifyArgon2id(encoded, password string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, candidate) != 1 {
+		return false, ErrPasswordMismatch
+	}
+
+	if h.conf.Algorithm != AlgorithmArgon2id {
+		return true, nil
+	}
+
+	target := h.conf.Argon2
+	outdated := p.Memory < target.Memory ||
+		p.Iterations < target.Iterations ||
+		p.Parallelism < target.Parallelism ||
+		uint32(len(salt)) < target.SaltLength ||
+		uint32(len(key)) < target.KeyLength
+
+	return outdated, nil
+}
+
+// encodeArgon2id builds a PHC string: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func encodeArgon2id(p Argon2Params, salt, key []byte) string {
+	return fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgorithmArgon2id,
+		argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var (
+		p Argon2Params
+		version int
+	)
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, fmt.Errorf("%w: bad version segment", ErrUnknownHashFormat)
+	}
+
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("%w: argon2 version %d", ErrUnsupportedAlgorithm, version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("%w: bad parameters segment", ErrUnknownHashFormat)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("%w: bad salt encoding", ErrUnknownHashFormat)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("%w: bad key encoding", ErrUnknownHashFormat)
+	}
+
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	return p, salt, key, nil
+}
@@ -3,22 +3,62 @@ package services
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
 )
 
 var secret = []byte(os.Getenv("SECRET_KEY"))
 
-func CreateJWT(user *domain.User) (string, error) {
+// SetSigningKey replaces the key read from SECRET_KEY, it is called before any token is issued.
+func SetSigningKey(key []byte) {
+	secret = key
+}
+
+// CreateJWT issues an access token. authTime is the moment the user last proved
+// the password and is carried over unchanged when tokens are refreshed.
+// Roles go to the "roles" claim, permissions to the space separated "scope" claim.
+func CreateJWT(user *domain.User, authTime time.Time, grants domain.Grants) (string, error) {
 	payload := jwt.MapClaims{
         "sub":  user.Id,
 		"email": user.Email,
 		"iss": time.Now().Unix(),
         "exp":  time.Now().Add(time.Hour).Unix(),
+		"auth_time": authTime.Unix(),
+		"roles": nonNil(grants.Roles),
+		"scope": strings.Join(grants.Permissions, " "),
     }
 
+	return signClaims(payload)
+}
+
+// CreateElevatedJWT issues a short-lived access token that allows sensitive operations.
+func CreateElevatedJWT(user *domain.User, grants domain.Grants, ttl time.Duration) (string, error) {
+	payload := jwt.MapClaims{
+		"sub": user.Id,
+		"email": user.Email,
+		"iss": time.Now().Unix(),
+		"exp": time.Now().Add(ttl).Unix(),
+		"auth_time": time.Now().Unix(),
+		"elevated": true,
+		"roles": nonNil(grants.Roles),
+		"scope": strings.Join(grants.Permissions, " "),
+	}
+
+	return signClaims(payload)
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+func signClaims(payload jwt.MapClaims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
 
 	tokenString, err := token.SignedString(secret)
@@ -45,3 +85,61 @@ func VerifyJWT(tokenString string) (*jwt.Token, error) {
 
 	return token, nil
 }
+
+func GetTokenSubject(token *jwt.Token) (uuid.UUID, error) {
+	subject, err := token.Claims.GetSubject()
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return uuid.Parse(subject)
+}
+
+// GetAuthTime returns the "auth_time" claim, tokens issued before it existed have none.
+func GetAuthTime(token *jwt.Token) (time.Time, bool) {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	authTime, ok := claims["auth_time"].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(authTime), 0), true
+}
+
+func IsElevated(token *jwt.Token) bool {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+
+	elevated, _ := claims["elevated"].(bool)
+	return elevated
+}
+
+// GetTokenGrants reads roles and permissions back from the access token claims.
+func GetTokenGrants(token *jwt.Token) domain.Grants {
+	grants := domain.Grants{Roles: []string{}, Permissions: []string{}}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return grants
+	}
+
+	if roles, ok := claims["roles"].([]any); ok {
+		for _, role := range roles {
+			if name, ok := role.(string); ok {
+				grants.Roles = append(grants.Roles, name)
+			}
+		}
+	}
+
+	if scope, ok := claims["scope"].(string); ok {
+		grants.Permissions = append(grants.Permissions, strings.Fields(scope)...)
+	}
+
+	return grants
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrAccountLocked = errors.New("account is locked after too many failed logins")
+
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("%s, locked until %s", ErrAccountLocked, e.Until.Format(time.RFC3339))
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// ILoginLockout counts failed logins of a user. The counter starts with the first failure
+// and expires a window later, a successful login or an admin unlock resets it earlier.
+type ILoginLockout interface {
+	FailedLogins(ctx context.Context, userId uuid.UUID) (failures int, expiresAt time.Time, err error)
+	RecordFailedLogin(ctx context.Context, userId uuid.UUID, window time.Duration) (failures int, expiresAt time.Time, err error)
+	ResetFailedLogins(ctx context.Context, userId uuid.UUID) error
+}
+
+// IOneTimePasswordRemover drops the one-time passwords issued to a user, which resets
+// the second factor the user is in the middle of.
+type IOneTimePasswordRemover interface {
+	DeleteOneTimePasswords(ctx context.Context, userId uuid.UUID) error
+}
+
+// checkLockout refuses the login before the password is looked at, otherwise a locked
+// account would still tell a right password from a wrong one.
+func (s *AuthService) checkLockout(ctx context.Context, userId uuid.UUID) (failures int, err error) {
+	if s.policy.MaxFailedLogins <= 0 {
+		return 0, nil
+	}
+
+	failures, expiresAt, err := s.lockout.FailedLogins(ctx, userId)
+	if err != nil {
+		return 0, fmt.Errorf("failed logins retrieve failure: %w", err)
+	}
+
+	if failures >= s.policy.MaxFailedLogins {
+		return failures, &AccountLockedError{Until: expiresAt}
+	}
+
+	return failures, nil
+}
+
+// recordFailedLogin counts a wrong password. Failures to count it are only logged,
+// the login is refused either way.
+func (s *AuthService) recordFailedLogin(ctx context.Context, log *slog.Logger, userId uuid.UUID) {
+	if s.policy.MaxFailedLogins <= 0 {
+		return
+	}
+
+	failures, expiresAt, err := s.lockout.RecordFailedLogin(ctx, userId, s.policy.LockoutDuration)
+	if err != nil {
+		log.Warn("failed to count failed login", slog.Any("error", err))
+		return
+	}
+
+	if failures == s.policy.MaxFailedLogins {
+		log.Warn("account locked after failed logins", slog.Int("failures", failures), slog.Time("until", expiresAt))
+	}
+}
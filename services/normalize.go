@@ -0,0 +1,41 @@
+package services
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// EmailNormalizer brings an address to the form used as the account identity:
+// surrounding spaces are trimmed, the domain is lowercased and converted to its
+// ASCII (punycode) form. The local part is case sensitive by RFC 5321, so it is
+// lowercased only when LowercaseLocal is set; lookups are case-insensitive anyway.
+type EmailNormalizer struct {
+	LowercaseLocal bool
+}
+
+func (n EmailNormalizer) Normalize(email string) (string, error) {
+	email = strings.TrimSpace(email)
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email) - 1 {
+		return "", &ValidationError{Violations: []FieldViolation{
+			{Field: "email", Reason: "must be a valid email address"},
+		}}
+	}
+
+	local, domain := email[:at], email[at+1:]
+
+	domain, err := idna.Lookup.ToASCII(strings.ToLower(domain))
+	if err != nil {
+		return "", &ValidationError{Violations: []FieldViolation{
+			{Field: "email", Reason: "domain is not a valid host name"},
+		}}
+	}
+
+	if n.LowercaseLocal {
+		local = strings.ToLower(local)
+	}
+
+	return local + "@" + domain, nil
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+const (
+	SessionRevokedLogout = "logout"
+	SessionRevokedUnregister = "unregister"
+	SessionRevokedAdmin = "admin"
+)
+
+const outboxCleanupInterval = time.Hour
+
+// IEventOutbox takes events whose change isn't stored in Postgres, events of user
+// changes are written by the repository in the transaction of the change itself.
+type IEventOutbox interface {
+	AppendEvent(ctx context.Context, event domain.Event) error
+}
+
+type IOutboxStore interface {
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error)
+	MarkPublished(ctx context.Context, ids []uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error
+	DeletePublished(ctx context.Context, publishedBefore time.Time) (int64, error)
+}
+
+// IEventBroker delivers one event. A nil error means the broker has accepted it.
+type IEventBroker interface {
+	Publish(ctx context.Context, event domain.Event) error
+}
+
+type RelayConfig struct {
+	BatchSize int
+	PollInterval time.Duration
+	// Lease hides claimed events from other relays, it must exceed the time a batch takes to publish.
+	Lease time.Duration
+	MaxBackoff time.Duration
+	// Retention keeps published events around for investigations.
+	Retention time.Duration
+}
+
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		BatchSize: 100,
+		PollInterval: time.Second,
+		Lease: 30 * time.Second,
+		MaxBackoff: 5 * time.Minute,
+		Retention: 7 * 24 * time.Hour,
+	}
+}
+
+// OutboxRelay publishes outbox events to the broker. Delivery is at least once: an event is
+// marked published only after the broker accepted it, so a crash in between repeats it.
+type OutboxRelay struct {
+	store IOutboxStore
+	broker IEventBroker
+	conf RelayConfig
+	logger *slog.Logger
+}
+
+func NewOutboxRelay(store IOutboxStore, broker IEventBroker, conf RelayConfig, logger *slog.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		store: store,
+		broker: broker,
+		conf: conf,
+		logger: logger,
+	}
+}
+
+// Run relays on every PollInterval tick until ctx is cancelled, full batches are followed right away.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.conf.PollInterval)
+	defer ticker.Stop()
+
+	var cleanedAt time.Time
+
+	for {
+		for ctx.Err() == nil {
+			relayed, err := r.RelayOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.logger.Error("outbox relay failed", slog.Any("error", err))
+			}
+			if err != nil || relayed < r.conf.BatchSize {
+				break
+			}
+		}
+
+		if time.Since(cleanedAt) > outboxCleanupInterval {
+			deleted, err := r.store.DeletePublished(ctx, time.Now().Add(-r.conf.Retention))
+			if err != nil && ctx.Err() == nil {
+				r.logger.Error("outbox cleanup failed", slog.Any("error", err))
+			} else if deleted > 0 {
+				r.logger.Info("published events removed from outbox", slog.Int64("count", deleted))
+			}
+			cleanedAt = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes one batch and returns how many events it claimed. Events the broker
+// rejected are retried with an exponential backoff.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	events, err := r.store.ClaimEvents(ctx, r.conf.BatchSize, r.conf.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	published := make([]uuid.UUID, 0, len(events))
+	for _, event := range events {
+		if err = r.broker.Publish(ctx, event.Event); err != nil {
+			retryAt := time.Now().Add(r.backoff(event.Attempts))
+
+			r.logger.Warn("event publish failed",
+				slog.String("eventId", event.Id.String()),
+				slog.String("type", event.Type),
+				slog.Int("attempts", event.Attempts),
+				slog.Time("retryAt", retryAt),
+				slog.Any("error", err),
+			)
+
+			// the lease runs out by itself if this fails, so the event is retried anyway
+			if markErr := r.store.MarkFailed(ctx, event.Id, err.Error(), retryAt); markErr != nil {
+				r.logger.Error("event retry schedule failed", slog.String("eventId", event.Id.String()), slog.Any("error", markErr))
+			}
+			continue
+		}
+
+		published = append(published, event.Id)
+	}
+
+	if err = r.store.MarkPublished(ctx, published); err != nil {
+		return len(events), err
+	}
+
+	return len(events), nil
+}
+
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.conf.PollInterval
+	for i := 1; i < attempts && delay < r.conf.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.conf.MaxBackoff)
+}
+
+// publishSessionRevoked never fails the revocation: the sessions are already gone from Redis,
+// which doesn't share a transaction with the outbox.
+func publishSessionRevoked(ctx context.Context, outbox IEventOutbox, logger *slog.Logger, userId uuid.UUID, all bool, reason string) {
+	event, err := domain.NewEvent(domain.EventSessionRevoked, userId, domain.SessionRevokedPayload{All: all, Reason: reason})
+	if err == nil {
+		err = outbox.AppendEvent(ctx, event)
+	}
+
+	if err != nil {
+		logger.ErrorContext(ctx, "session revoked event lost",
+			slog.String("userId", userId.String()),
+			slog.String("reason", reason),
+			slog.Any("error", err),
+		)
+	}
+}
@@ -0,0 +1,37 @@
+package services
+
+import "time"
+
+// AccountPolicy holds account lifecycle settings shared by the services.
+type AccountPolicy struct {
+	// DeletionGracePeriod is how long an unregistered account can be restored before it is purged.
+	DeletionGracePeriod time.Duration
+	// PurgeInterval is how often the purge job looks for accounts past the grace period.
+	PurgeInterval time.Duration
+	// StepUpMaxAge is how long after a password login sensitive operations are allowed without re-authentication.
+	StepUpMaxAge time.Duration
+	// ElevatedTokenTTL is the lifetime of the token issued by re-authentication.
+	ElevatedTokenTTL time.Duration
+	// SessionSweepInterval is how often expired tokens are pruned from the per-user session indexes.
+	SessionSweepInterval time.Duration
+	// MaxFailedLogins is how many wrong passwords within LockoutDuration lock the account, zero turns the lockout off.
+	MaxFailedLogins int
+	// LockoutDuration is the window failed logins are counted in, a locked account opens when it ends.
+	LockoutDuration time.Duration
+}
+
+func DefaultAccountPolicy() AccountPolicy {
+	return AccountPolicy{
+		DeletionGracePeriod: time.Hour * 24 * 30,
+		PurgeInterval: time.Hour,
+		StepUpMaxAge: time.Minute * 10,
+		ElevatedTokenTTL: time.Minute * 5,
+		SessionSweepInterval: time.Minute * 15,
+		MaxFailedLogins: 5,
+		LockoutDuration: time.Minute * 15,
+	}
+}
+
+func (p AccountPolicy) purgeAt(deletedAt time.Time) time.Time {
+	return deletedAt.Add(p.DeletionGracePeriod)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+type IProfileUpdater interface {
+	UpdateProfile(ctx context.Context, userId uuid.UUID, update domain.ProfileUpdate) (*domain.User, error)
+}
+
+type ProfileService struct {
+	userProvider IUserProvider
+	profileUpdater IProfileUpdater
+	logger *slog.Logger
+}
+
+func NewProfileService(
+		userProvider IUserProvider,
+		profileUpdater IProfileUpdater,
+		logger *slog.Logger) *ProfileService {
+	return &ProfileService{
+		userProvider: userProvider,
+		profileUpdater: profileUpdater,
+		logger: logger,
+	}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, userId uuid.UUID) (*domain.UserPublic, error) {
+	user, err := s.userProvider.GetById(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("get profile error - %w", err)
+	}
+
+	return &user.UserPublic, nil
+}
+
+func (s *ProfileService) UpdateProfile(ctx context.Context, userId uuid.UUID, update domain.ProfileUpdate) (*domain.UserPublic, error) {
+	log := s.logger.With(
+		slog.String("operation", "update profile"),
+		slog.String("userId", userId.String()),
+		slog.Any("fields", update.Mask),
+	)
+
+	log.Info("updating user profile...")
+
+	if err := validateProfileUpdate(&update); err != nil {
+		log.Warn("invalid profile update", slog.Any("error", err))
+		return nil, fmt.Errorf("update profile error - %w", err)
+	}
+
+	user, err := s.profileUpdater.UpdateProfile(ctx, userId, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrVersionConflict):
+			log.Warn("concurrent profile modification", slog.Any("error", err))
+		case errors.Is(err, database.ErrPhoneAlreadyTaken):
+			log.Warn("phone already used by another account")
+		}
+		return nil, fmt.Errorf("update profile error - %w", fromConstraintError(err))
+	}
+
+	log.Info("profile updated!", slog.Int("version", user.Version))
+	return &user.UserPublic, nil
+}
+
+func validateProfileUpdate(update *domain.ProfileUpdate) error {
+	v := &validator{}
+
+	if len(update.Mask) == 0 {
+		v.add("updateMask", "must list at least one field")
+	}
+
+	for _, field := range update.Mask {
+		switch field {
+		case domain.ProfileFieldFullName:
+			update.FullName = strings.TrimSpace(update.FullName)
+			v.fullName(field, update.FullName)
+		case domain.ProfileFieldPhone:
+			v.phone(field, update.Phone)
+		case domain.ProfileFieldBirthDate:
+			v.birthDate(field, update.BirthDate)
+		default:
+			v.add("updateMask", fmt.Sprintf("unknown field %q", field))
+		}
+	}
+
+	return v.err()
+}
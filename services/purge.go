@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type IUserPurger interface {
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// AccountPurger periodically hard-deletes accounts whose deletion grace period is over.
+type AccountPurger struct {
+	purger IUserPurger
+	policy AccountPolicy
+	logger *slog.Logger
+}
+
+func NewAccountPurger(purger IUserPurger, policy AccountPolicy, logger *slog.Logger) *AccountPurger {
+	return &AccountPurger{
+		purger: purger,
+		policy: policy,
+		logger: logger,
+	}
+}
+
+// Run purges once right away and then on every PurgeInterval tick until ctx is cancelled.
+func (p *AccountPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.policy.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.PurgeOnce(ctx); err != nil && ctx.Err() == nil {
+			p.logger.Error("deleted accounts purge failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *AccountPurger) PurgeOnce(ctx context.Context) (int64, error) {
+	purged, err := p.purger.PurgeDeleted(ctx, time.Now().Add(-p.policy.DeletionGracePeriod))
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		p.logger.Info("purged deleted accounts", slog.Int64("count", purged))
+	}
+
+	return purged, nil
+}
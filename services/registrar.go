@@ -5,20 +5,23 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
-	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrSessionMismatch = errors.New("session belongs to another user")
+
 type IUserSaver interface {
 	Save(ctx context.Context, user domain.User) error
 }
 
+// IUserRemover only marks the account deleted, rows are purged after the grace period.
 type IUserRemover interface {
-	Delete(ctx context.Context, userId uuid.UUID) error
+	SoftDelete(ctx context.Context, userId uuid.UUID) error
 }
 
 type RegistrarService struct {
@@ -26,6 +29,10 @@ type RegistrarService struct {
 	userRemover IUserRemover
 	sessionRemover ISessionRemover
 	sessionProvider ISessionProvider
+	hasher IPasswordHasher
+	normalizer EmailNormalizer
+	audit IAuditLog
+	events IEventOutbox
 	logger *slog.Logger
 }
 
@@ -34,17 +41,36 @@ func NewRegistrarService (
 		userRemover IUserRemover,
 		sessionRemover ISessionRemover,
 		sessionProvider ISessionProvider,
+		hasher IPasswordHasher,
+		normalizer EmailNormalizer,
+		audit IAuditLog,
+		events IEventOutbox,
 		logger *slog.Logger) *RegistrarService {
 	return &RegistrarService{
 		userSaver: userSaver,
 		userRemover: userRemover,
 		sessionRemover: sessionRemover,
 		sessionProvider: sessionProvider,
+		hasher: hasher,
+		normalizer: normalizer,
+		audit: audit,
+		events: events,
 		logger: logger,
 	}
 }
 
-func (s *RegistrarService) Register(ctx context.Context, user domain.User) (userId uuid.UUID, err error) {
+func (s *RegistrarService) Register(ctx context.Context, user domain.User, source domain.Source) (userId uuid.UUID, err error) {
+	defer func() {
+		// a rejected registration has no account, the attempt is only known by its email
+		recordAudit(ctx, s.audit, s.logger, auditOutcome(domain.AuditEvent{
+			Action: AuditActionRegister,
+			Actor: userId,
+			Target: userId,
+			Source: source,
+			Metadata: map[string]string{"email": user.Email},
+		}, err))
+	}()
+
 	log := s.logger.With(
 		slog.String("operation", "register"),
 		slog.String("name", user.FullName),
@@ -53,10 +79,15 @@ func (s *RegistrarService) Register(ctx context.Context, user domain.User) (user
 
 	log.Info("proceeding registration...")
 
+	if err = s.validateRegistration(&user); err != nil {
+		log.Warn("invalid registration data", slog.Any("error", err))
+		return uuid.UUID{}, err
+	}
+
 	user.Id = uuid.New()
 	user.RegisterDate = time.Now()
 
-	user.PasswordHash, err = bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	user.PasswordHash, err = s.hasher.Hash(user.Password)
 	if err != nil {
 		s.logger.ErrorContext(ctx, "failed to generate password hash", slog.Any("error", err))
 		return uuid.UUID{}, err
@@ -67,14 +98,46 @@ func (s *RegistrarService) Register(ctx context.Context, user domain.User) (user
 			s.logger.WarnContext(ctx, "user already exists")
 		}
 		s.logger.ErrorContext(ctx, "failed to register user", slog.Any("error", err))
-		return uuid.UUID{}, err
+		return uuid.UUID{}, fromConstraintError(err)
 	}
 
 	log.Info("registration complete!")
 	return user.Id, nil
 }
 
-func (s *RegistrarService) Unregister(ctx context.Context, refreshToken string) error {
+// validateRegistration normalises the email in place and checks every field,
+// field names match the ones of RegisterRequest.
+func (s *RegistrarService) validateRegistration(user *domain.User) error {
+	v := &validator{}
+
+	email, err := s.normalizer.Normalize(user.Email)
+	if err != nil {
+		v.add("email", "must be a valid email address")
+	} else {
+		user.Email = email
+		v.email("email", user.Email)
+	}
+
+	user.FullName = strings.TrimSpace(user.FullName)
+	v.fullName("fullname", user.FullName)
+	v.phone("phone", user.Phone)
+	v.password("password", user.Password)
+	v.birthDate("birthdate", user.BirthDate)
+
+	return v.err()
+}
+
+// Unregister deletes the account of the re-authenticated user, the refresh token must belong to the same user.
+func (s *RegistrarService) Unregister(ctx context.Context, userId uuid.UUID, refreshToken string, source domain.Source) (err error) {
+	defer func() {
+		recordAudit(ctx, s.audit, s.logger, auditOutcome(domain.AuditEvent{
+			Action: AuditActionUnregister,
+			Actor: userId,
+			Target: userId,
+			Source: source,
+		}, err))
+	}()
+
 	log := s.logger.With(
 		slog.String("operation", "unregister"),
 	)
@@ -91,17 +154,24 @@ func (s *RegistrarService) Unregister(ctx context.Context, refreshToken string)
 
 	log = log.With(slog.String("userId", session.UserId.String()))
 
+	if session.UserId != userId {
+		log.Warn("refresh token presented with credentials of another user")
+		return fmt.Errorf("unregister error - %w", ErrSessionMismatch)
+	}
+
 	if err = s.sessionRemover.DeleteUserSessions(ctx, session.UserId); err != nil {
 		return fmt.Errorf("unregister error - %w", err)
 	}
 
-	if err = s.userRemover.Delete(ctx, session.UserId); err != nil {
+	publishSessionRevoked(ctx, s.events, s.logger, session.UserId, true, SessionRevokedUnregister)
+
+	if err = s.userRemover.SoftDelete(ctx, session.UserId); err != nil {
 		if errors.Is(err, database.ErrUserNotFound) {
 			s.logger.WarnContext(ctx, "user not found", slog.Any("error", err))
 		}
 		return fmt.Errorf("unregister error - %w", err)
 	}
 
-	log.Info("successfully unregistered user!")
+	log.Info("successfully unregistered user, account scheduled for deletion!")
 	return nil
 }
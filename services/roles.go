@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+var ErrSelfRevoke = errors.New("can't revoke own role")
+
+type IRoleManager interface {
+	IRoleProvider
+	ListRoles(ctx context.Context) ([]domain.Role, error)
+	AssignRole(ctx context.Context, assignment domain.RoleAssignment) error
+	RevokeRole(ctx context.Context, userId uuid.UUID, role string) error
+}
+
+// RoleService manages role assignments. Changes reach access tokens on the next login or refresh.
+type RoleService struct {
+	roles IRoleManager
+	audit IAuditLog
+	logger *slog.Logger
+}
+
+func NewRoleService(roles IRoleManager, audit IAuditLog, logger *slog.Logger) *RoleService {
+	return &RoleService{
+		roles: roles,
+		audit: audit,
+		logger: logger,
+	}
+}
+
+func (s *RoleService) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	roles, err := s.roles.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list roles error - %w", err)
+	}
+	return roles, nil
+}
+
+func (s *RoleService) GetUserGrants(ctx context.Context, userId uuid.UUID) (*domain.Grants, error) {
+	grants, err := s.roles.GetUserGrants(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("get user roles error - %w", err)
+	}
+	return grants, nil
+}
+
+func (s *RoleService) AssignRole(ctx context.Context, actorId, userId uuid.UUID, role string, source domain.Source) (err error) {
+	defer func() {
+		s.auditRoleChange(ctx, AuditActionRoleAssign, actorId, userId, role, source, err)
+	}()
+
+	log := s.logger.With(
+		slog.String("operation", "assign role"),
+		slog.String("actorId", actorId.String()),
+		slog.String("userId", userId.String()),
+		slog.String("role", role),
+	)
+
+	err = s.roles.AssignRole(ctx, domain.RoleAssignment{
+		UserId: userId,
+		Role: role,
+		GrantedBy: actorId,
+		GrantedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("assign role error - %w", err)
+	}
+
+	log.Info("role assigned")
+	return nil
+}
+
+// RevokeRole refuses to take roles away from the caller, so the last admin can't lock everybody out.
+func (s *RoleService) RevokeRole(ctx context.Context, actorId, userId uuid.UUID, role string, source domain.Source) (err error) {
+	defer func() {
+		s.auditRoleChange(ctx, AuditActionRoleRevoke, actorId, userId, role, source, err)
+	}()
+
+	log := s.logger.With(
+		slog.String("operation", "revoke role"),
+		slog.String("actorId", actorId.String()),
+		slog.String("userId", userId.String()),
+		slog.String("role", role),
+	)
+
+	if actorId == userId {
+		return fmt.Errorf("revoke role error - %w", ErrSelfRevoke)
+	}
+
+	if err = s.roles.RevokeRole(ctx, userId, role); err != nil {
+		return fmt.Errorf("revoke role error - %w", err)
+	}
+
+	log.Info("role revoked")
+	return nil
+}
+
+func (s *RoleService) auditRoleChange(ctx context.Context, action string, actorId, userId uuid.UUID, role string, source domain.Source, err error) {
+	recordAudit(ctx, s.audit, s.logger, auditOutcome(domain.AuditEvent{
+		Action: action,
+		Actor: actorId,
+		Target: userId,
+		Source: source,
+		Metadata: map[string]string{"role": role},
+	}, err))
+}
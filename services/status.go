@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+var (
+	ErrAccountDisabled = errors.New("account is disabled")
+	ErrAccountSuspended = errors.New("account is suspended")
+	ErrAccountNotVerified = errors.New("account is pending verification")
+)
+
+// AccountStatusError tells why an account may not sign in.
+// Until is set for suspensions that end by themselves.
+type AccountStatusError struct {
+	Status string
+	Until time.Time
+}
+
+func (e *AccountStatusError) Error() string {
+	if e.Until.IsZero() {
+		return e.Unwrap().Error()
+	}
+	return fmt.Sprintf("%s until %s", e.Unwrap(), e.Until.Format(time.RFC3339))
+}
+
+func (e *AccountStatusError) Unwrap() error {
+	switch e.Status {
+	case domain.UserStatusSuspended:
+		return ErrAccountSuspended
+	case domain.UserStatusPendingVerification:
+		return ErrAccountNotVerified
+	default:
+		return ErrAccountDisabled
+	}
+}
+
+// checkAccountStatus lets through active accounts and suspensions that are already over.
+func checkAccountStatus(user *domain.User, now time.Time) error {
+	switch user.Status {
+	case domain.UserStatusActive, "":
+		return nil
+	case domain.UserStatusSuspended:
+		if !user.SuspendedUntil.IsZero() && now.After(user.SuspendedUntil) {
+			return nil
+		}
+		return &AccountStatusError{Status: user.Status, Until: user.SuspendedUntil}
+	default:
+		return &AccountStatusError{Status: user.Status}
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type ISessionPruner interface {
+	PruneExpiredSessions(ctx context.Context) (int64, error)
+}
+
+// SessionSweeper periodically prunes expired tokens from the session indexes of users
+// who don't come back, reads prune the indexes of active users anyway.
+type SessionSweeper struct {
+	pruner ISessionPruner
+	policy AccountPolicy
+	logger *slog.Logger
+}
+
+func NewSessionSweeper(pruner ISessionPruner, policy AccountPolicy, logger *slog.Logger) *SessionSweeper {
+	return &SessionSweeper{
+		pruner: pruner,
+		policy: policy,
+		logger: logger,
+	}
+}
+
+// Run sweeps once right away and then on every SessionSweepInterval tick until ctx is cancelled.
+func (s *SessionSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.policy.SessionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.SweepOnce(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("session index sweep failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *SessionSweeper) SweepOnce(ctx context.Context) (int64, error) {
+	pruned, err := s.pruner.PruneExpiredSessions(ctx)
+	if err != nil {
+		return pruned, err
+	}
+
+	if pruned > 0 {
+		s.logger.Info("pruned expired sessions from user indexes", slog.Int64("count", pruned))
+	}
+
+	return pruned, nil
+}
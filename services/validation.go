@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+var ErrValidation = errors.New("validation failed")
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+
+const (
+	maxFullNameLen = 127
+	maxEmailLen = 254
+	maxEmailLocalLen = 64
+	minPasswordLen = 6
+	// bcrypt ignores everything past 72 bytes
+	maxPasswordBytes = 72
+	minUserAge = 14
+	maxUserAge = 120
+)
+
+type FieldViolation struct {
+	Field string
+	Reason string
+}
+
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", v.Field, v.Reason))
+	}
+	return fmt.Sprintf("%s - %s", ErrValidation, strings.Join(reasons, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// validator collects violations so that a client sees every bad field at once.
+type validator struct {
+	violations []FieldViolation
+}
+
+func (v *validator) add(field, reason string) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Reason: reason})
+}
+
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+func (v *validator) fullName(field, value string) {
+	switch trimmed := strings.TrimSpace(value); {
+	case trimmed == "":
+		v.add(field, "must not be empty")
+	case utf8.RuneCountInString(trimmed) > maxFullNameLen:
+		v.add(field, fmt.Sprintf("must be at most %d characters", maxFullNameLen))
+	}
+}
+
+// email accepts a bare RFC 5322 addr-spec: no display name, no angle brackets.
+func (v *validator) email(field, value string) {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Name != "" || addr.Address != value {
+		v.add(field, "must be a valid email address")
+		return
+	}
+
+	local := value[:strings.LastIndex(value, "@")]
+	switch {
+	case len(value) > maxEmailLen:
+		v.add(field, fmt.Sprintf("must be at most %d characters", maxEmailLen))
+	case len(local) > maxEmailLocalLen:
+		v.add(field, fmt.Sprintf("local part must be at most %d characters", maxEmailLocalLen))
+	}
+}
+
+func (v *validator) password(field, value string) {
+	switch {
+	case utf8.RuneCountInString(value) < minPasswordLen:
+		v.add(field, fmt.Sprintf("must be at least %d characters", minPasswordLen))
+	case len(value) > maxPasswordBytes:
+		v.add(field, fmt.Sprintf("must be at most %d bytes", maxPasswordBytes))
+	}
+}
+
+func (v *validator) phone(field, value string) {
+	if !phonePattern.MatchString(value) {
+		v.add(field, "must contain 10 to 15 digits with an optional leading +")
+	}
+}
+
+func (v *validator) birthDate(field string, value time.Time) {
+	now := time.Now()
+
+	switch {
+	case value.After(now):
+		v.add(field, "must not be in the future")
+	case value.After(now.AddDate(-minUserAge, 0, 0)):
+		v.add(field, fmt.Sprintf("user must be at least %d years old", minUserAge))
+	case value.Before(now.AddDate(-maxUserAge, 0, 0)):
+		v.add(field, fmt.Sprintf("must be within the last %d years", maxUserAge))
+	}
+}
+
+// fromConstraintError reports a write rejected by a storage constraint as a validation
+// error on the offending column; other errors are returned unchanged.
+func fromConstraintError(err error) error {
+	var cerr *database.ConstraintError
+	if !errors.As(err, &cerr) {
+		return err
+	}
+
+	field := cerr.Column
+	if field == "" {
+		field = "unknown"
+	}
+
+	reason := "has invalid value"
+	switch {
+	case errors.Is(cerr, database.ErrNullViolation):
+		reason = "is required"
+	case errors.Is(cerr, database.ErrValueTooLong):
+		reason = "is too long"
+	}
+
+	return &ValidationError{Violations: []FieldViolation{{Field: field, Reason: reason}}}
+}
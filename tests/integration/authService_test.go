@@ -50,13 +50,14 @@ func TestLoginAndRefresh(t *testing.T) {
 		},
 	}
 
-	pgConn := TestPGConn(t)
+	pgConn := TestUserDBConn(t)
 	redisConn := TestRedisConn(t)
 
 	userToCreate := &domain.User{
 		UserPublic: domain.UserPublic{
 			FullName: "Ananiev Nikita",
 			Email: "nikita-ananiev@mail.ru",
+			Phone: "79111111111",
 			BirthDate: time.Date(2004, time.June, 24, 0, 0, 0, 0, time.Local),
 		},
 		Password: "qwertty",
@@ -123,3 +124,55 @@ func TestLoginAndRefresh(t *testing.T) {
 		fmt.Println("PASSED!")
 	}
 }
+
+func TestConcurrentRefreshWithSameToken(t *testing.T) {
+	pgConn := TestUserDBConn(t)
+	redisConn := TestRedisConn(t)
+
+	CreateTestUser(t, pgConn, &domain.User{
+		UserPublic: domain.UserPublic{
+			FullName: "Ananiev Nikita",
+			Email: "concurrent-refresh@mail.ru",
+			Phone: "79111111112",
+			BirthDate: time.Date(2004, time.June, 24, 0, 0, 0, 0, time.Local),
+		},
+		Password: "qwertty",
+	})
+	defer CleanUpTestStorages(t, pgConn, redisConn)
+
+	ctx, client := NewClient(t, os.Getenv("TEST_APP_ADDR"))
+	source := &pb.SourceData{Ip: "localhost:9999", UserAgent: "Chrome/137.0.0.0"}
+
+	resp, err := client.Login(ctx, &pb.LoginRequest{
+		Email: "concurrent-refresh@mail.ru",
+		Password: "qwertty",
+		Source: source,
+	})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	const attempts = 8
+	results := make(chan error, attempts)
+	refreshCtx := metadata.NewOutgoingContext(ctx, metadata.Pairs("refreshToken", resp.GetTokens().GetRefreshToken()))
+
+	for i := 0; i < attempts; i++ {
+		go func() {
+			_, err := client.Refresh(refreshCtx, source)
+			results <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < attempts; i++ {
+		if err := <-results; err == nil {
+			succeeded++
+		} else if code := status.Code(err); code != codes.Unauthenticated {
+			t.Errorf("unexpected status of a lost refresh: %v", err)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Errorf("one refresh token was exchanged %d times", succeeded)
+	}
+}
@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+func TestConcurrentBootstrap(t *testing.T) {
+	if testUserDBConf.Driver != database.DriverPostgres {
+		t.Skip("bootstrap needs the postgres test database")
+	}
+
+	ctx := context.Background()
+	conf := *testUserDBConf
+	// a name that breaks unquoted SQL
+	conf.DBName = `bootstrap "test"; db`
+	boot := database.BootstrapConfig{
+		CreateDatabase: true,
+		Migrate: true,
+		AppRole: "bootstrap_test_app",
+		AppRolePassword: "it's secret",
+	}
+
+	admin, err := pgx.Connect(ctx, testUserDBConf.GetPgConnString(true))
+	if err != nil {
+		t.Fatalf("admin connection failed: %v", err)
+	}
+	defer func() {
+		admin.Exec(ctx, "DROP DATABASE IF EXISTS " + pgx.Identifier{conf.DBName}.Sanitize() + " WITH (FORCE);")
+		admin.Exec(ctx, "DROP OWNED BY bootstrap_test_app;")
+		admin.Exec(ctx, "DROP ROLE IF EXISTS bootstrap_test_app;")
+		admin.Close(ctx)
+	}()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = database.NewBootstrapper(&conf, boot).Run(ctx)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent bootstrap failed: %v", err)
+		}
+	}
+
+	appConf := conf
+	appConf.User, appConf.Password = boot.AppRole, boot.AppRolePassword
+	app, err := pgx.Connect(ctx, appConf.GetPgConnString(false))
+	if err != nil {
+		t.Fatalf("application role connection failed: %v", err)
+	}
+	defer app.Close(ctx)
+
+	if _, err = app.Exec(ctx, "SELECT count(*) FROM users;"); err != nil {
+		t.Errorf("application role can't read users: %v", err)
+	}
+
+	if _, err = app.Exec(ctx, "CREATE TABLE bootstrap_escape(id int);"); err == nil {
+		t.Errorf("application role can create tables")
+	}
+
+	if _, err = app.Exec(ctx, "DELETE FROM schema_migrations;"); err == nil {
+		t.Errorf("application role can rewrite the migration history")
+	}
+}
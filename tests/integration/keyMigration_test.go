@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+func TestKeySchemaRefusesUnmarkedLegacyKeys(t *testing.T) {
+	redisConn := TestRedisConn(t)
+	defer CleanUpTestStorages(t, nil, redisConn)
+
+	ctx := context.Background()
+	keys := database.NewKeySchema(testSessionDBConf.KeyNamespace)
+
+	// a deployment of the unprefixed layout that never had a marker
+	redisConn.FlushDB(ctx)
+	redisConn.Set(ctx, strings.Repeat("b", 64), `{"userId":"` + uuid.NewString() + `"}`, time.Hour)
+
+	if _, err := database.NewSessionRepository(testSessionDBConf); !errors.Is(err, database.ErrKeySchemaOutdated) {
+		t.Fatalf("unmarked legacy keyspace accepted: %v", err)
+	}
+
+	if redisConn.Exists(ctx, keys.Version()).Val() != 0 {
+		t.Errorf("legacy keyspace marked as current")
+	}
+
+	// keys of other applications don't block a fresh namespace
+	redisConn.FlushDB(ctx)
+	redisConn.Set(ctx, "unrelated", "value", 0)
+
+	repository, err := database.NewSessionRepository(testSessionDBConf)
+	if err != nil {
+		t.Fatalf("fresh keyspace rejected: %v", err)
+	}
+	repository.Exit()
+
+	if version, _ := redisConn.Get(ctx, keys.Version()).Int(); version != database.KeySchemaVersion {
+		t.Errorf("fresh keyspace not marked, version %d", version)
+	}
+}
+
+func TestKeyMigrationMovesLegacyKeys(t *testing.T) {
+	redisConn := TestRedisConn(t)
+	defer CleanUpTestStorages(t, nil, redisConn)
+
+	ctx := context.Background()
+	keys := database.NewKeySchema(testSessionDBConf.KeyNamespace)
+	userId := uuid.New()
+	token := strings.Repeat("a", 64)
+	confirmation := strings.Repeat("0f", 32)
+
+	// the unprefixed layout with an outdated marker
+	redisConn.FlushDB(ctx)
+	redisConn.Set(ctx, token, `{"userId":"` + userId.String() + `","email":"keys@mail.ru"}`, time.Hour)
+	redisConn.SAdd(ctx, userId.String(), token)
+	redisConn.Set(ctx, "email-change:" + confirmation, `{}`, time.Hour)
+	redisConn.Set(ctx, "unrelated", "value", 0)
+	redisConn.Set(ctx, keys.Version(), 1, 0)
+
+	if _, err := database.NewSessionRepository(testSessionDBConf); !errors.Is(err, database.ErrKeySchemaOutdated) {
+		t.Fatalf("outdated key schema accepted: %v", err)
+	}
+
+	migrator, err := database.NewKeyMigrator(testSessionDBConf, 2)
+	if err != nil {
+		t.Fatalf("key migrator creation failed: %v", err)
+	}
+	defer migrator.Exit()
+
+	report, err := migrator.Migrate(ctx, false)
+	if err != nil {
+		t.Fatalf("key migration failed: %v", err)
+	}
+
+	if report.Sessions != 1 || report.Indexes != 1 || report.Tokens != 1 || report.Conflicts != 0 {
+		t.Errorf("unexpected migration report: %+v", report)
+	}
+
+	if ttl := redisConn.PTTL(ctx, keys.Session(token)).Val(); ttl < 59 * time.Minute {
+		t.Errorf("session ttl lost on migration: %v", ttl)
+	}
+
+	if redisConn.Exists(ctx, "unrelated", keys.Token("email-change", confirmation)).Val() != 1 {
+		t.Errorf("unexpected keys after migration")
+	}
+
+	tokens, err := database.NewTokenRepository(testSessionDBConf)
+	if err != nil {
+		t.Fatalf("token repository creation failed after migration: %v", err)
+	}
+	defer tokens.Exit()
+
+	var payload struct{}
+	if err = tokens.Peek(ctx, "email-change", confirmation, &payload); err != nil {
+		t.Errorf("migrated confirmation token not found: %v", err)
+	}
+
+	repository, err := database.NewSessionRepository(testSessionDBConf)
+	if err != nil {
+		t.Fatalf("session repository creation failed after migration: %v", err)
+	}
+	defer repository.Exit()
+
+	sessions, err := repository.GetUserSessions(ctx, userId)
+	if err != nil || len(sessions) != 1 {
+		t.Errorf("migrated sessions not found: %v, %v", sessions, err)
+	}
+
+	if session, err := repository.Get(ctx, token); err != nil || session.Email != "keys@mail.ru" {
+		t.Errorf("migrated session not found by token: %v, %v", session, err)
+	}
+}
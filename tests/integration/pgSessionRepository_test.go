@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+func testPgSessionRepository(t *testing.T) *database.PgSessionRepository {
+	t.Helper()
+
+	if testUserDBConf.Driver != database.DriverPostgres {
+		t.Skip("postgres session store needs the postgres test database")
+	}
+
+	pool, err := database.NewPgPool(testUserDBConf)
+	if err != nil {
+		t.Fatalf("session repository creation failed: %v", err)
+	}
+
+	repository := database.NewPgSessionRepository(pool)
+	t.Cleanup(repository.Exit)
+
+	return repository
+}
+
+func TestPgSessionExpiry(t *testing.T) {
+	repository := testPgSessionRepository(t)
+
+	ctx := context.Background()
+	userId := uuid.New()
+	session := &domain.Session{UserId: userId, Email: "pgsession@mail.ru", CreatedAt: time.Now(), AuthTime: time.Now()}
+	defer repository.DeleteUserSessions(ctx, userId)
+
+	short, err := repository.Save(ctx, session, 100 * time.Millisecond)
+	if err != nil {
+		t.Fatalf("short session save failed: %v", err)
+	}
+
+	if _, err = repository.Save(ctx, session, time.Hour); err != nil {
+		t.Fatalf("long session save failed: %v", err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	if _, err = repository.Get(ctx, short); !errors.Is(err, database.ErrSessionNotFound) {
+		t.Errorf("expired session returned: %v", err)
+	}
+
+	sessions, err := repository.GetUserSessions(ctx, userId)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("unexpected sessions after expiry: %v, %v", sessions, err)
+	}
+
+	if pruned, err := repository.PruneExpiredSessions(ctx); err != nil || pruned < 1 {
+		t.Errorf("expired session not pruned: %d, %v", pruned, err)
+	}
+}
+
+func TestPgSessionConcurrentRotation(t *testing.T) {
+	repository := testPgSessionRepository(t)
+
+	ctx := context.Background()
+	userId := uuid.New()
+	session := &domain.Session{UserId: userId, Email: "pgsession@mail.ru", CreatedAt: time.Now(), AuthTime: time.Now()}
+	defer repository.DeleteUserSessions(ctx, userId)
+
+	token, err := repository.Save(ctx, session, time.Hour)
+	if err != nil {
+		t.Fatalf("session save failed: %v", err)
+	}
+
+	const attempts = 8
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+		rotated int
+	)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := repository.Rotate(ctx, token, session, time.Hour)
+			if err != nil && !errors.Is(err, database.ErrSessionNotFound) {
+				t.Errorf("unexpected rotation error: %v", err)
+				return
+			}
+
+			if err == nil {
+				mu.Lock()
+				rotated++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if rotated != 1 {
+		t.Errorf("token rotated %d times", rotated)
+	}
+
+	if sessions, err := repository.GetUserSessions(ctx, userId); err != nil || len(sessions) != 1 {
+		t.Errorf("unexpected sessions after rotation: %v, %v", sessions, err)
+	}
+}
@@ -57,7 +57,7 @@ func TestRegister(t *testing.T) {
 		},
 	}
 
-	defer CleanUpTestStorages(t, TestPGConn(t), nil)
+	defer CleanUpTestStorages(t, TestUserDBConn(t), nil)
 
 	ctx, client := NewClient(t, os.Getenv("TEST_APP_ADDR"))
 
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+func TestSessionIndexPrunesExpiredTokens(t *testing.T) {
+	redisConn := TestRedisConn(t)
+	defer CleanUpTestStorages(t, nil, redisConn)
+
+	repository, err := database.NewSessionRepository(testSessionDBConf)
+	if err != nil {
+		t.Fatalf("session repository creation failed: %v", err)
+	}
+	defer repository.Exit()
+
+	ctx := context.Background()
+	keys := database.NewKeySchema(testSessionDBConf.KeyNamespace)
+	userId := uuid.New()
+	session := &domain.Session{UserId: userId, Email: "index@mail.ru"}
+
+	if _, err = repository.Save(ctx, session, 100 * time.Millisecond); err != nil {
+		t.Fatalf("short session save failed: %v", err)
+	}
+
+	if _, err = repository.Save(ctx, session, time.Hour); err != nil {
+		t.Fatalf("long session save failed: %v", err)
+	}
+
+	// the index lives as long as its latest session
+	if ttl := redisConn.PTTL(ctx, keys.UserSessions(userId)).Val(); ttl < 59 * time.Minute {
+		t.Errorf("unexpected index ttl: %v", ttl)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	sessions, err := repository.GetUserSessions(ctx, userId)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("unexpected sessions after expiry: %v, %v", sessions, err)
+	}
+
+	if members := redisConn.ZCard(ctx, keys.UserSessions(userId)).Val(); members != 1 {
+		t.Errorf("expired token kept in the index, %d members", members)
+	}
+}
+
+func TestSessionIndexConvertsPlainSets(t *testing.T) {
+	redisConn := TestRedisConn(t)
+	defer CleanUpTestStorages(t, nil, redisConn)
+
+	repository, err := database.NewSessionRepository(testSessionDBConf)
+	if err != nil {
+		t.Fatalf("session repository creation failed: %v", err)
+	}
+	defer repository.Exit()
+
+	ctx := context.Background()
+	keys := database.NewKeySchema(testSessionDBConf.KeyNamespace)
+	userId := uuid.New()
+
+	// the index layout before expiry scores: a plain set with one live and one dead token
+	redisConn.Set(ctx, keys.Session("live-token"), `{"userId":"` + userId.String() + `","email":"index@mail.ru"}`, time.Hour)
+	redisConn.SAdd(ctx, keys.UserSessions(userId), "live-token", "dead-token")
+
+	pruned, err := repository.PruneExpiredSessions(ctx)
+	if err != nil {
+		t.Fatalf("sweep failed: %v", err)
+	}
+
+	if keyType := redisConn.Type(ctx, keys.UserSessions(userId)).Val(); keyType != "zset" {
+		t.Fatalf("index not converted, type %s", keyType)
+	}
+
+	sessions, err := repository.GetUserSessions(ctx, userId)
+	if err != nil || len(sessions) != 1 || pruned != 0 {
+		t.Errorf("unexpected state after conversion: %v, %d, %v", sessions, pruned, err)
+	}
+
+	if err = repository.DeleteUserSessions(ctx, userId); err != nil || redisConn.Exists(ctx, keys.Session("live-token")).Val() != 0 {
+		t.Errorf("converted index doesn't revoke sessions: %v", err)
+	}
+}
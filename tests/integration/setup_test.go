@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"log/slog"
@@ -12,16 +13,13 @@ import (
 
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/app"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/events"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
 )
 
 var (
-	testUserDBConf = &database.Config{
-		Driver: "postgres",
-		Addr: os.Getenv("PG_ADDR"),
-		User: os.Getenv("PG_USER"),
-		Password: os.Getenv("PG_PASSWORD"),
-		DBName: os.Getenv("TEST_DB_NAME"),
-	}
+	// TEST_USER_STORAGE=sqlite3 runs the suite against SQLite in the file TEST_SQLITE_PATH
+	testUserDBConf = testUserStorage()
 
 	testSessionDBConf = &database.Config{
 		Driver: "redis",
@@ -31,6 +29,23 @@ var (
 	}
 )
 
+func testUserStorage() *database.Config {
+	if os.Getenv("TEST_USER_STORAGE") == database.DriverSQLite {
+		return &database.Config{
+			Driver: database.DriverSQLite,
+			DBName: os.Getenv("TEST_SQLITE_PATH"),
+		}
+	}
+
+	return &database.Config{
+		Driver: database.DriverPostgres,
+		Addr: os.Getenv("PG_ADDR"),
+		User: os.Getenv("PG_USER"),
+		Password: os.Getenv("PG_PASSWORD"),
+		DBName: os.Getenv("TEST_DB_NAME"),
+	}
+}
+
 type TestCase struct {
 	name string
 	args any
@@ -56,14 +71,26 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
-func makeMigrations(pgConfig *database.Config) {
-	m, err := database.NewMigrator(pgConfig, os.Getenv("MIGRATIONS_DIR"))
-	if err != nil {
-		log.Fatalf("migrator creation error - %v\n", err)
-	}
+func makeMigrations(conf *database.Config) {
+	if conf.Driver == database.DriverSQLite {
+		m, err := database.NewMigrator(conf)
+		if err != nil {
+			log.Fatalf("migrator creation error - %v\n", err)
+		}
+		defer m.Close()
 
-	if err = m.Apply(); err != nil {
-		log.Fatalf("migrations apply failure - %v\n", err)
+		if err = m.Apply(); err != nil {
+			log.Fatalf("migrations apply failure - %v\n", err)
+		}
+	} else {
+		bootstrapper := database.NewBootstrapper(conf, database.BootstrapConfig{
+			CreateDatabase: true,
+			Migrate: true,
+		})
+
+		if err := bootstrapper.Run(context.Background()); err != nil {
+			log.Fatalf("database bootstrap failure - %v\n", err)
+		}
 	}
 
 	log.Println("INFO: migrations applied successfully!")
@@ -72,12 +99,15 @@ func makeMigrations(pgConfig *database.Config) {
 func runTestApp(port int) error {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	testApp, err := app.New(
-		logger,
-		testUserDBConf,
-		testSessionDBConf,
-		port,
-	)
+	testApp, err := app.New(logger, &app.Config{
+		UserStorage: testUserDBConf,
+		SessionStorage: testSessionDBConf,
+		Hasher: services.DefaultHasherConfig(),
+		AccountPolicy: services.DefaultAccountPolicy(),
+		LogMail: true,
+		Events: &events.Config{Driver: events.DriverMemory},
+		Port: port,
+	})
 
 	fmt.Println("TEST DATABASE HAS BEEN CREATED!!!")
 
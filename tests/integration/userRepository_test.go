@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+func TestUserRepositoryErrorMapping(t *testing.T) {
+	if testUserDBConf.Driver != database.DriverPostgres {
+		t.Skip("the pgx repository needs the postgres test database")
+	}
+	defer CleanUpTestStorages(t, TestUserDBConn(t), nil)
+
+	repository, err := database.NewUserRepository(testUserDBConf)
+	if err != nil {
+		t.Fatalf("user repository creation failed: %v", err)
+	}
+	defer repository.Exit()
+
+	ctx := context.Background()
+	user := domain.User{
+		UserPublic: domain.UserPublic{
+			Id: uuid.New(),
+			FullName: "Repository User",
+			Email: "repository@mail.ru",
+			Phone: "79222222222",
+			BirthDate: time.Date(2001, time.March, 3, 0, 0, 0, 0, time.UTC),
+			RegisterDate: time.Now(),
+		},
+		PasswordHash: []byte("hash"),
+	}
+
+	if err = repository.Save(ctx, user); err != nil {
+		t.Fatalf("user save failed: %v", err)
+	}
+
+	duplicate := user
+	duplicate.Id = uuid.New()
+	duplicate.Phone = "79333333333"
+	if err = repository.Save(ctx, duplicate); !errors.Is(err, database.ErrUserAlreadyExists) {
+		t.Errorf("duplicate email accepted: %v", err)
+	}
+
+	var constraintErr *database.ConstraintError
+	_, err = repository.UpdateProfile(ctx, user.Id, domain.ProfileUpdate{Mask: []string{domain.ProfileFieldFullName}, FullName: "  "})
+	if !errors.As(err, &constraintErr) || constraintErr.Column != "fullname" {
+		t.Errorf("blank name accepted: %v", err)
+	}
+
+	update := domain.ProfileUpdate{
+		Mask: []string{domain.ProfileFieldFullName, domain.ProfileFieldPhone},
+		FullName: "Renamed User",
+		Phone: "79444444444",
+	}
+	if _, err = repository.UpdateProfile(ctx, user.Id, update); err != nil {
+		t.Fatalf("profile update failed: %v", err)
+	}
+
+	changes, err := repository.ListProfileChanges(ctx, user.Id)
+	if err != nil || len(changes) != 2 {
+		t.Errorf("unexpected profile changes: %v, %v", changes, err)
+	}
+}
+
+func TestUserRepositoryBackfillEmail(t *testing.T) {
+	if testUserDBConf.Driver != database.DriverPostgres {
+		t.Skip("the pgx repository needs the postgres test database")
+	}
+	defer CleanUpTestStorages(t, TestUserDBConn(t), nil)
+
+	repository, err := database.NewUserRepository(testUserDBConf)
+	if err != nil {
+		t.Fatalf("user repository creation failed: %v", err)
+	}
+	defer repository.Exit()
+
+	ctx := context.Background()
+	user := domain.User{
+		UserPublic: domain.UserPublic{
+			Id: uuid.New(),
+			FullName: "Unicode User",
+			Email: "user@пример.рф",
+			Phone: "79555555555",
+			BirthDate: time.Date(2001, time.March, 3, 0, 0, 0, 0, time.UTC),
+			RegisterDate: time.Now(),
+		},
+		PasswordHash: []byte("hash"),
+	}
+
+	if err = repository.Save(ctx, user); err != nil {
+		t.Fatalf("user save failed: %v", err)
+	}
+
+	if err = repository.BackfillEmail(ctx, user.Id, user.Email, "user@xn--e1afmkfd.xn--p1ai"); err != nil {
+		t.Fatalf("email backfill failed: %v", err)
+	}
+
+	stored, err := repository.Get(ctx, "user@xn--e1afmkfd.xn--p1ai")
+	if err != nil || stored.Id != user.Id || stored.Version != 1 {
+		t.Errorf("unexpected account after the backfill: %+v, %v", stored, err)
+	}
+
+	if err = repository.BackfillEmail(ctx, user.Id, user.Email, "user@xn--e1afmkfd.xn--p1ai"); !errors.Is(err, database.ErrVersionConflict) {
+		t.Errorf("stale backfill applied: %v", err)
+	}
+}
@@ -13,12 +13,13 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func TestPGConn(t *testing.T) *sql.DB {
+// TestUserDBConn opens the user database of the backend under test, Postgres or SQLite.
+func TestUserDBConn(t *testing.T) *sql.DB {
 	t.Helper()
 
     db, err := sql.Open(
-		testUserDBConf.Driver, 
-		testUserDBConf.GetPgConnString(false),
+		testUserDBConf.GetSQLDriverName(), 
+		testUserDBConf.GetSQLConnString(),
 	)
 	
     if err != nil {
@@ -88,12 +89,13 @@ func CreateTestUser(t *testing.T, db *sql.DB, user *domain.User) {
 
 	var (
 		id = uuid.New()
-		now = time.Now()
+		// UTC keeps timestamps comparable on SQLite, where they are stored as text
+		now = time.Now().UTC()
 		hash, _ = bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
-		query = "INSERT INTO users (id, fullName, email, phone, password, birthDate, registerDate) VALUES ($1, $2, $3, $4, $5, $6, $7);"
+		query = "INSERT INTO users (id, fullName, email, phone, password, birthDate, registerDate, updatedAt) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);"
 	)
 	
-	_, err := db.Exec(query, id, user.FullName, user.Email, user.Phone, hash, user.BirthDate, now)
+	_, err := db.Exec(query, id, user.FullName, user.Email, user.Phone, hash, user.BirthDate.UTC(), now, now)
 	if err != nil {
 		t.Fatalf("Failed to create test user: %v", err)
 	}
@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestLoginAccountStatus(t *testing.T) {
+	// arrange
+	testUUID := uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+
+	type Args struct {
+		status string
+		suspendedUntil time.Time
+	}
+
+	tests := []struct {
+		TestCase
+		wantErrIs error
+	}{
+		{
+			TestCase: TestCase{name: "Active account", args: Args{status: domain.UserStatusActive}, wantErr: false},
+		},
+		{
+			TestCase: TestCase{name: "Suspension is over", args: Args{status: domain.UserStatusSuspended, suspendedUntil: time.Now().Add(-time.Hour)}, wantErr: false},
+		},
+		{
+			TestCase: TestCase{name: "Suspended account", args: Args{status: domain.UserStatusSuspended, suspendedUntil: time.Now().Add(time.Hour)}, wantErr: true},
+			wantErrIs: services.ErrAccountSuspended,
+		},
+		{
+			TestCase: TestCase{name: "Indefinitely suspended account", args: Args{status: domain.UserStatusSuspended}, wantErr: true},
+			wantErrIs: services.ErrAccountSuspended,
+		},
+		{
+			TestCase: TestCase{name: "Disabled account", args: Args{status: domain.UserStatusDisabled}, wantErr: true},
+			wantErrIs: services.ErrAccountDisabled,
+		},
+		{
+			TestCase: TestCase{name: "Unverified account", args: Args{status: domain.UserStatusPendingVerification}, wantErr: true},
+			wantErrIs: services.ErrAccountNotVerified,
+		},
+	}
+
+	fmt.Println("========== Run login account status unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		args, ok := tt.args.(Args)
+		if !ok {
+			t.Errorf("unexpected args for the test")
+			continue
+		}
+
+		user := CreateTestUser("test@test.ru", "123")
+		user.Id = testUUID
+		user.Status = args.status
+		user.SuspendedUntil = args.suspendedUntil
+
+		md := &MockDependencies{
+			userProvider: mocks.NewIUserProvider(t),
+			roleProvider: mocks.NewIRoleProvider(t),
+			sessionProvider: mocks.NewISessionProvider(t),
+			sessionSaver: mocks.NewISessionSaver(t),
+		}
+
+		md.userProvider.On("Get", mock.Anything, "test@test.ru").Return(user, nil)
+		if !tt.wantErr {
+			md.sessionProvider.On("GetUserSessions", mock.Anything, testUUID).Return([]*domain.Session{}, nil)
+			md.roleProvider.On("GetUserGrants", mock.Anything, testUUID).Return(&domain.Grants{}, nil)
+			md.sessionSaver.On("Save", mock.Anything, mock.Anything, mock.Anything).Return("refresh", nil)
+		}
+
+		authService := services.NewAuthService(
+			md.userProvider, mocks.NewIUserUpdater(t), mocks.NewIAccountRestorer(t), md.roleProvider,
+			md.sessionProvider, md.sessionSaver, mocks.NewISessionRemover(t),
+			NullLockout(), TestHasher(nil), services.EmailNormalizer{}, services.DefaultAccountPolicy(), NullAuditLog(), NullOutbox(), NullLogger(),
+		)
+
+		// act
+		_, _, err := authService.Login(context.Background(), "test@test.ru", "123", domain.Source{})
+
+		// assert
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+			t.Errorf("unexpected error kind: want %v, have %v", tt.wantErrIs, err)
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
+
+func TestRefreshStopsForSuspendedAccount(t *testing.T) {
+	// arrange
+	var (
+		testUUID = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+		source = domain.Source{IpAddress: "127.0.0.1:8000", UserAgent: "Chrome/137.0.0.0"}
+		until = time.Now().Add(time.Hour * 24).Truncate(time.Second)
+	)
+
+	user := CreateTestUser("test@test.ru", "123")
+	user.Id = testUUID
+	user.Status = domain.UserStatusSuspended
+	user.SuspendedUntil = until
+
+	var (
+		userProvider = mocks.NewIUserProvider(t)
+		sessionProvider = mocks.NewISessionProvider(t)
+		sessionRemover = mocks.NewISessionRemover(t)
+	)
+
+	sessionProvider.On("Get", mock.Anything, "refresh").Return(&domain.Session{UserId: testUUID, Email: user.Email, Source: source}, nil)
+	sessionRemover.On("Delete", mock.Anything, "refresh").Return(nil).Once()
+	userProvider.On("GetById", mock.Anything, testUUID).Return(user, nil)
+
+	authService := services.NewAuthService(
+		userProvider, mocks.NewIUserUpdater(t), mocks.NewIAccountRestorer(t), mocks.NewIRoleProvider(t),
+		sessionProvider, mocks.NewISessionSaver(t), sessionRemover,
+		NullLockout(), TestHasher(nil), services.EmailNormalizer{}, services.DefaultAccountPolicy(), NullAuditLog(), NullOutbox(), NullLogger(),
+	)
+
+	// act
+	_, err := authService.Refresh(context.Background(), "refresh", source)
+
+	// assert
+	var statusErr *services.AccountStatusError
+	if !errors.As(err, &statusErr) || !errors.Is(err, services.ErrAccountSuspended) {
+		t.Fatalf("expected suspended account error, have %v", err)
+	}
+
+	if !statusErr.Until.Equal(until) {
+		t.Errorf("unexpected suspension end: want %v, have %v", until, statusErr.Until)
+	}
+}
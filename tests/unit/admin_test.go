@@ -0,0 +1,215 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+type adminMocks struct {
+	users *mocks.IUserAdministration
+	sessionRemover *mocks.ISessionRemover
+	lockout *mocks.ILoginLockout
+	otps *mocks.IOneTimePasswordRemover
+	audit *mocks.IAuditLog
+}
+
+func newAdminTestService(t *testing.T) (*services.AdminService, adminMocks) {
+	m := adminMocks{
+		users: mocks.NewIUserAdministration(t),
+		sessionRemover: mocks.NewISessionRemover(t),
+		lockout: mocks.NewILoginLockout(t),
+		otps: mocks.NewIOneTimePasswordRemover(t),
+		audit: mocks.NewIAuditLog(t),
+	}
+
+	service := services.NewAdminService(m.users, mocks.NewIRoleProvider(t), m.sessionRemover, m.lockout, m.otps, m.audit, NullOutbox(), NullLogger())
+	return service, m
+}
+
+func TestAdminSearchPagination(t *testing.T) {
+	// arrange
+	service, m := newAdminTestService(t)
+	actorId := uuid.New()
+
+	page := make([]domain.User, 3)
+	for i := range page {
+		page[i].Id = uuid.New()
+		page[i].RegisterDate = time.Now().Add(time.Duration(i) * time.Minute).Truncate(time.Microsecond)
+	}
+
+	m.users.
+		On("SearchUsers", mock.Anything, mock.MatchedBy(func(s domain.UserSearch) bool {
+			return s.After == nil && s.Limit == 3 && s.EmailPrefix == "dealer"
+		})).
+		Return(page, nil).
+		Once()
+	m.users.
+		On("SearchUsers", mock.Anything, mock.MatchedBy(func(s domain.UserSearch) bool {
+			return s.After != nil && s.After.Id == page[1].Id && s.After.RegisterDate.Equal(page[1].RegisterDate)
+		})).
+		Return(page[2:], nil).
+		Once()
+	m.audit.On("Record", mock.Anything, mock.AnythingOfType("domain.AuditEvent")).Return(nil).Times(2)
+
+	// act
+	first, token, err := service.SearchUsers(context.Background(), actorId, domain.UserSearch{EmailPrefix: "dealer", Limit: 2}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, lastToken, err := service.SearchUsers(context.Background(), actorId, domain.UserSearch{EmailPrefix: "dealer", Limit: 2}, token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// assert
+	if len(first) != 2 || token == "" {
+		t.Errorf("unexpected first page: %d users, token %q", len(first), token)
+	}
+
+	if len(second) != 1 || lastToken != "" {
+		t.Errorf("unexpected last page: %d users, token %q", len(second), lastToken)
+	}
+
+	if _, _, err = service.SearchUsers(context.Background(), actorId, domain.UserSearch{}, "garbage"); !errors.Is(err, services.ErrInvalidPageToken) {
+		t.Errorf("expected invalid page token, have %v", err)
+	}
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	// arrange
+	service, m := newAdminTestService(t)
+
+	var (
+		actorId = uuid.New()
+		userId = uuid.New()
+		adminSource = domain.Source{IpAddress: "10.0.0.1:4000", UserAgent: "admin-console"}
+	)
+
+	m.users.On("SetStatus", mock.Anything, userId, domain.StatusChange{Status: domain.UserStatusDisabled, Reason: "fraud"}).Return(nil).Once()
+	m.sessionRemover.On("DeleteUserSessions", mock.Anything, userId).Return(nil).Once()
+	m.audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(e domain.AuditEvent) bool {
+			return e.Action == services.AuditActionUserDisable && e.Actor == actorId && e.Target == userId &&
+				e.Outcome == domain.AuditOutcomeSuccess && e.Reason == "fraud" && e.Source == adminSource
+		})).
+		Return(nil).
+		Once()
+
+	// act
+	err := service.DisableUser(context.Background(), actorId, userId, "fraud", adminSource)
+	selfErr := service.DisableUser(context.Background(), actorId, actorId, "oops", adminSource)
+
+	// assert
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if !errors.Is(selfErr, services.ErrSelfDisable) {
+		t.Errorf("expected self disable error, have %v", selfErr)
+	}
+}
+
+func TestAdminUnlockUser(t *testing.T) {
+	// arrange
+	service, m := newAdminTestService(t)
+
+	var (
+		actorId = uuid.New()
+		userId = uuid.New()
+		unknownId = uuid.New()
+	)
+
+	m.users.On("GetIncludingDeleted", mock.Anything, userId).Return(&domain.User{}, nil).Once()
+	m.users.On("GetIncludingDeleted", mock.Anything, unknownId).Return(nil, database.ErrUserNotFound).Once()
+	m.lockout.On("ResetFailedLogins", mock.Anything, userId).Return(nil).Once()
+	m.audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(e domain.AuditEvent) bool {
+			return e.Action == services.AuditActionUnlock && e.Actor == actorId && e.Target == userId &&
+				e.Outcome == domain.AuditOutcomeSuccess
+		})).
+		Return(nil).
+		Once()
+	m.audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(e domain.AuditEvent) bool {
+			return e.Action == services.AuditActionUnlock && e.Target == unknownId &&
+				e.Outcome == domain.AuditOutcomeFailure && e.Reason == "user not found"
+		})).
+		Return(nil).
+		Once()
+
+	// act
+	err := service.UnlockUser(context.Background(), actorId, userId, domain.Source{})
+	unknownErr := service.UnlockUser(context.Background(), actorId, unknownId, domain.Source{})
+
+	// assert
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if !errors.Is(unknownErr, database.ErrUserNotFound) {
+		t.Errorf("expected user not found error, have %v", unknownErr)
+	}
+}
+
+func TestAdminResetMFA(t *testing.T) {
+	// arrange
+	service, m := newAdminTestService(t)
+
+	var (
+		actorId = uuid.New()
+		userId = uuid.New()
+	)
+
+	m.users.On("GetIncludingDeleted", mock.Anything, userId).Return(&domain.User{}, nil).Once()
+	m.otps.On("DeleteOneTimePasswords", mock.Anything, userId).Return(nil).Once()
+	m.audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(e domain.AuditEvent) bool {
+			return e.Action == services.AuditActionResetMFA && e.Actor == actorId && e.Target == userId &&
+				e.Outcome == domain.AuditOutcomeSuccess
+		})).
+		Return(nil).
+		Once()
+
+	// act
+	err := service.ResetMFA(context.Background(), actorId, userId, domain.Source{})
+
+	// assert
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoginToDisabledAccount(t *testing.T) {
+	// arrange
+	user := CreateTestUser("fraud@test.ru", "123")
+	user.Status = domain.UserStatusDisabled
+
+	userProvider := mocks.NewIUserProvider(t)
+	userProvider.On("Get", mock.Anything, "fraud@test.ru").Return(user, nil)
+
+	authService := services.NewAuthService(
+		userProvider, mocks.NewIUserUpdater(t), mocks.NewIAccountRestorer(t), mocks.NewIRoleProvider(t),
+		mocks.NewISessionProvider(t), mocks.NewISessionSaver(t), mocks.NewISessionRemover(t),
+		NullLockout(), TestHasher(nil), services.EmailNormalizer{}, services.DefaultAccountPolicy(), NullAuditLog(), NullOutbox(), NullLogger(),
+	)
+
+	// act
+	_, _, err := authService.Login(context.Background(), "fraud@test.ru", "123", domain.Source{})
+
+	// assert
+	if !errors.Is(err, services.ErrAccountDisabled) {
+		t.Errorf("expected disabled account error, have %v", err)
+	}
+}
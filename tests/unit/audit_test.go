@@ -0,0 +1,173 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func newAuditedAuthService(t *testing.T, userProvider *mocks.IUserProvider, audit services.IAuditLog) *services.AuthService {
+	return services.NewAuthService(
+		userProvider, mocks.NewIUserUpdater(t), mocks.NewIAccountRestorer(t), mocks.NewIRoleProvider(t),
+		mocks.NewISessionProvider(t), mocks.NewISessionSaver(t), mocks.NewISessionRemover(t),
+		NullLockout(), TestHasher(nil), services.EmailNormalizer{}, services.DefaultAccountPolicy(), audit, NullOutbox(), NullLogger(),
+	)
+}
+
+func TestFailedLoginIsAudited(t *testing.T) {
+	// arrange
+	user := CreateTestUser("test@test.ru", "123")
+	user.Id = uuid.New()
+	source := domain.Source{IpAddress: "127.0.0.1:8000", UserAgent: "Chrome/137.0.0.0"}
+
+	userProvider := mocks.NewIUserProvider(t)
+	audit := mocks.NewIAuditLog(t)
+
+	userProvider.On("Get", mock.Anything, "test@test.ru").Return(user, nil)
+	audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(event domain.AuditEvent) bool {
+			return event.Action == services.AuditActionLogin &&
+				event.Outcome == domain.AuditOutcomeFailure &&
+				event.Reason == services.ErrInvalidCredentials.Error() &&
+				event.Actor == uuid.Nil &&
+				event.Target == user.Id &&
+				event.Source == source &&
+				!event.OccurredAt.IsZero()
+		})).
+		Return(nil).
+		Once()
+
+	authService := newAuditedAuthService(t, userProvider, audit)
+
+	// act
+	_, _, err := authService.Login(context.Background(), "test@test.ru", "wrong", source)
+
+	// assert
+	if !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAuditFailureKeepsLoginResult(t *testing.T) {
+	// arrange
+	userProvider := mocks.NewIUserProvider(t)
+	audit := mocks.NewIAuditLog(t)
+
+	userProvider.On("Get", mock.Anything, "test@test.ru").Return(CreateTestUser("test@test.ru", "123"), nil)
+	audit.On("Record", mock.Anything, mock.Anything).Return(errors.New("audit storage is down")).Once()
+
+	authService := newAuditedAuthService(t, userProvider, audit)
+
+	// act
+	_, _, err := authService.Login(context.Background(), "test@test.ru", "wrong", domain.Source{})
+
+	// assert
+	if !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Errorf("login result changed by the audit failure: %v", err)
+	}
+}
+
+func TestRecentActivityClampsPageSize(t *testing.T) {
+	// arrange
+	userId := uuid.New()
+	reader := mocks.NewIAuditReader(t)
+
+	reader.
+		On("List", mock.Anything, domain.AuditQuery{Subject: userId, BeforeId: 42, Limit: services.MaxAuditPageSize}).
+		Return([]domain.AuditEvent{{Id: 41, Action: services.AuditActionLogin}}, nil).
+		Once()
+
+	service := services.NewAuditService(reader)
+
+	// act
+	events, err := service.RecentActivity(context.Background(), userId, 42, services.MaxAuditPageSize*10)
+
+	// assert
+	if err != nil || len(events) != 1 {
+		t.Errorf("unexpected activity: %v, %v", events, err)
+	}
+}
+
+func TestFailedRegistrationIsAudited(t *testing.T) {
+	// arrange
+	user := CreateTestUser("taken@test.ru", "qwerty")
+	user.Phone = "+79999999999"
+	user.BirthDate = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	source := domain.Source{IpAddress: "127.0.0.1:8000", UserAgent: "grpc-go/1.73.0"}
+
+	userSaver := mocks.NewIUserSaver(t)
+	audit := mocks.NewIAuditLog(t)
+
+	userSaver.On("Save", mock.Anything, mock.AnythingOfType("domain.User")).Return(database.ErrUserAlreadyExists).Once()
+	audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(event domain.AuditEvent) bool {
+			return event.Action == services.AuditActionRegister &&
+				event.Outcome == domain.AuditOutcomeFailure &&
+				event.Reason == database.ErrUserAlreadyExists.Error() &&
+				event.Actor == uuid.Nil &&
+				event.Source == source &&
+				event.Metadata["email"] == "taken@test.ru"
+		})).
+		Return(nil).
+		Once()
+
+	registrar := services.NewRegistrarService(
+		userSaver, mocks.NewIUserRemover(t), mocks.NewISessionRemover(t), mocks.NewISessionProvider(t),
+		TestHasher(nil), services.EmailNormalizer{}, audit, NullOutbox(), NullLogger(),
+	)
+
+	// act
+	_, err := registrar.Register(context.Background(), *user, source)
+
+	// assert
+	if !errors.Is(err, database.ErrUserAlreadyExists) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWrongPasswordEmailChangeIsAudited(t *testing.T) {
+	// arrange
+	user := CreateTestUser("old@test.ru", "123")
+	user.Id = uuid.New()
+	source := domain.Source{IpAddress: "127.0.0.1:8000", UserAgent: "Chrome/137.0.0.0"}
+
+	userProvider := mocks.NewIUserProvider(t)
+	audit := mocks.NewIAuditLog(t)
+
+	userProvider.On("GetById", mock.Anything, user.Id).Return(user, nil)
+	audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(event domain.AuditEvent) bool {
+			return event.Action == services.AuditActionEmailChangeRequest &&
+				event.Outcome == domain.AuditOutcomeFailure &&
+				event.Reason == services.ErrInvalidCredentials.Error() &&
+				event.Actor == user.Id &&
+				event.Target == user.Id &&
+				event.Source == source &&
+				event.Metadata["newEmail"] == "new@test.ru"
+		})).
+		Return(nil).
+		Once()
+
+	emailService := services.NewEmailChangeService(
+		userProvider, mocks.NewIEmailUpdater(t), mocks.NewISessionUpdater(t), mocks.NewITokenStorage(t), mocks.NewIMailer(t),
+		TestHasher(nil), services.EmailNormalizer{}, audit, NullLogger(),
+	)
+
+	// act
+	err := emailService.RequestEmailChange(context.Background(), user.Id, "wrong", "new@test.ru", source)
+
+	// assert
+	if !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/config"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(name, []byte(content), 0600); err != nil {
+		t.Fatalf("config file writing failed: %v", err)
+	}
+	return name
+}
+
+func TestConfigLayersPrecedence(t *testing.T) {
+	// arrange
+	file := writeConfigFile(t, `
+mode: local
+port: 5000
+users:
+  addr: file-db:5432
+  name: file_auth
+sessions:
+  addr: redis:6379
+  cache_ttl: 3s
+`)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("PORT", "6000")
+	t.Setenv("PG_NAME", "env_auth")
+	t.Setenv("PG_MAX_OPEN_CONNS", "12")
+
+	// act
+	conf, rest, err := config.Load("test", []string{"-port", "7000", "-users.bootstrap.migrate=false", "status"})
+
+	// assert
+	if err != nil {
+		t.Fatalf("config loading failed: %v", err)
+	}
+
+	if conf.Port != 7000 {
+		t.Errorf("flag didn't override the environment, port is %d", conf.Port)
+	}
+	if conf.Users.Name != "env_auth" || conf.Users.Addr != "file-db:5432" {
+		t.Errorf("environment didn't override the file: %+v", conf.Users)
+	}
+	if conf.Users.Connection.MaxOpenConns != 12 || conf.UserDatabase().MaxOpenConns != 12 {
+		t.Errorf("prefixed variable not applied: %+v", conf.Users.Connection)
+	}
+	if conf.Sessions.CacheTTL != 3 * time.Second || conf.Users.Bootstrap.Migrate || !conf.Users.Bootstrap.Enabled {
+		t.Errorf("unexpected settings: %+v", conf)
+	}
+	if conf.Policy.PurgeInterval != time.Hour {
+		t.Errorf("default lost, purge interval is %s", conf.Policy.PurgeInterval)
+	}
+	if len(rest) != 1 || rest[0] != "status" {
+		t.Errorf("unexpected remaining arguments %v", rest)
+	}
+}
+
+func TestConfigLoadFlagsWithCommandFlags(t *testing.T) {
+	// arrange
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("MODE", "local")
+	t.Setenv("USER_STORAGE", "memory")
+	t.Setenv("REDIS_ADDR", "redis:6379")
+	flags := flag.NewFlagSet("tool", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the user")
+
+	// act
+	conf, rest, err := config.LoadFlags(flags, []string{"-email", "admin@test.ru", "-sessions.key_namespace", "tenant"})
+
+	// assert
+	if err != nil {
+		t.Fatalf("config loading failed: %v", err)
+	}
+
+	if *email != "admin@test.ru" {
+		t.Errorf("command flag not parsed, email is %q", *email)
+	}
+	if conf.SessionDatabase().KeyNamespace != "tenant" {
+		t.Errorf("setting flag not applied: %+v", conf.Sessions)
+	}
+	if len(rest) != 0 {
+		t.Errorf("unexpected remaining arguments %v", rest)
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	// arrange
+	t.Setenv("MODE", "staging")
+	t.Setenv("PORT", "")
+	t.Setenv("USER_STORAGE", "sqlite3")
+	t.Setenv("SQLITE_PATH", "")
+	t.Setenv("SESSION_STORAGE", "memory")
+	t.Setenv("REDIS_TLS_MODE", "sometimes")
+
+	// act
+	_, _, err := config.Load("test", nil)
+
+	// assert
+	if err == nil {
+		t.Fatal("expected the config to be rejected")
+	}
+
+	for _, want := range []string{"mode:", "jwt_secret:", "users.sqlite_path:", "sessions.connection.tls_mode:", "mail.addr:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q not reported in %v", want, err)
+		}
+	}
+}
+
+func TestConfigRejectsUnknownFileKeys(t *testing.T) {
+	// arrange
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "users:\n  adress: db:5432\n"))
+
+	// act
+	_, _, err := config.Load("test", nil)
+
+	// assert
+	if err == nil || !strings.Contains(err.Error(), "adress") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConfigPrintRedactsSecrets(t *testing.T) {
+	// arrange
+	conf := config.Default()
+	conf.JWTSecret = "jwt-secret-value"
+	conf.Users.Password = "pg-secret-value"
+	conf.Mail.Password = "smtp-secret-value"
+	conf.Users.Addr = "db:5432"
+
+	// act
+	var out bytes.Buffer
+	err := conf.Print(&out)
+
+	// assert
+	if err != nil {
+		t.Fatalf("printing failed: %v", err)
+	}
+
+	if strings.Contains(out.String(), "secret-value") {
+		t.Errorf("secret printed:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "addr: db:5432") || !strings.Contains(out.String(), "purge_interval: 1h0m0s") {
+		t.Errorf("settings missing from the output:\n%s", out.String())
+	}
+	if conf.JWTSecret != "jwt-secret-value" {
+		t.Error("printing changed the config")
+	}
+}
@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+func TestPgConnStringSettings(t *testing.T) {
+	// arrange
+	plain := &database.Config{Driver: database.DriverPostgres, User: "u", Password: "p", Addr: "db:5432", DBName: "auth"}
+	secured := *plain
+	secured.TLSMode = database.TLSVerifyFull
+	secured.TLSCAFile = "/etc/ssl/ca.pem"
+	secured.DialTimeout = 500 * time.Millisecond
+	secured.StatementTimeout = 3 * time.Second
+
+	// act
+	plainURL, plainErr := url.Parse(plain.GetPgConnString(false))
+	securedURL, securedErr := url.Parse(secured.GetPgConnString(false))
+
+	// assert
+	if plainErr != nil || securedErr != nil {
+		t.Fatalf("unparsable connection strings: %v, %v", plainErr, securedErr)
+	}
+
+	if plainURL.Query().Get("sslmode") != database.TLSDisable || plainURL.Path != "/auth" {
+		t.Errorf("unexpected default connection string: %s", plainURL)
+	}
+
+	want := map[string]string{
+		"sslmode": "verify-full",
+		"sslrootcert": "/etc/ssl/ca.pem",
+		"connect_timeout": "1",
+		"statement_timeout": "3000",
+	}
+	for param, value := range want {
+		if got := securedURL.Query().Get(param); got != value {
+			t.Errorf("%s is %q, want %q", param, got, value)
+		}
+	}
+}
+
+func TestRedisRejectsUnknownTLSMode(t *testing.T) {
+	// arrange
+	conf := &database.Config{Driver: "redis", Addr: "localhost:6379", TLSMode: "sometimes"}
+
+	// act
+	_, err := database.NewSessionRepository(conf)
+
+	// assert
+	if !errors.Is(err, database.ErrInvalidTLSMode) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
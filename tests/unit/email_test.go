@@ -0,0 +1,270 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/mailer"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func newEmailChangeTestService(t *testing.T, setupMocks func(*MockDependencies)) *services.EmailChangeService {
+	md := &MockDependencies{
+		userProvider: mocks.NewIUserProvider(t),
+		emailUpdater: mocks.NewIEmailUpdater(t),
+		sessionUpdater: mocks.NewISessionUpdater(t),
+		tokenStorage: mocks.NewITokenStorage(t),
+		mailer: mocks.NewIMailer(t),
+	}
+
+	setupMocks(md)
+
+	return services.NewEmailChangeService(
+		md.userProvider, md.emailUpdater, md.sessionUpdater, md.tokenStorage, md.mailer, TestHasher(nil), services.EmailNormalizer{}, NullAuditLog(), NullLogger(),
+	)
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	// arrange
+	var (
+		testUUID = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+		user = CreateTestUser("old@test.ru", "123")
+		disabledUser = CreateTestUser("old@test.ru", "123")
+		testToken = "c0nf1rm"
+	)
+
+	user.Id = testUUID
+	disabledUser.Id = testUUID
+	disabledUser.Status = domain.UserStatusDisabled
+
+	type Args struct {
+		password string
+		newEmail string
+	}
+
+	tests := []struct {
+		TestCase
+		wantErrIs error
+	}{
+		{
+			TestCase: TestCase{
+				name: "Confirmation sent to the new address",
+				args: Args{password: "123", newEmail: "new@test.ru"},
+				setupMocks: func(md *MockDependencies) {
+					md.userProvider.On("GetById", mock.Anything, testUUID).Return(user, nil)
+					md.userProvider.On("Get", mock.Anything, "new@test.ru").Return(nil, database.ErrUserNotFound)
+					md.tokenStorage.
+						On("Issue", mock.Anything, mock.Anything, mock.MatchedBy(func(c domain.EmailChange) bool {
+							return c.OldEmail == "old@test.ru" && c.NewEmail == "new@test.ru"
+						}), mock.Anything).
+						Return(testToken, nil)
+					md.mailer.On("Send", mock.Anything, "new@test.ru", mock.Anything, mock.AnythingOfType("string")).Return(nil).Once()
+					md.mailer.On("Send", mock.Anything, "old@test.ru", mock.Anything, mock.AnythingOfType("string")).Return(nil).Once()
+				},
+				wantErr: false,
+			},
+		},
+		{
+			TestCase: TestCase{
+				name: "Wrong current password",
+				args: Args{password: "321", newEmail: "new@test.ru"},
+				setupMocks: func(md *MockDependencies) {
+					md.userProvider.On("GetById", mock.Anything, testUUID).Return(user, nil)
+				},
+				wantErr: true,
+			},
+			wantErrIs: services.ErrInvalidCredentials,
+		},
+		{
+			TestCase: TestCase{
+				name: "Disabled account",
+				args: Args{password: "123", newEmail: "new@test.ru"},
+				setupMocks: func(md *MockDependencies) {
+					md.userProvider.On("GetById", mock.Anything, testUUID).Return(disabledUser, nil)
+				},
+				wantErr: true,
+			},
+			wantErrIs: services.ErrAccountDisabled,
+		},
+		{
+			TestCase: TestCase{
+				name: "Malformed new address",
+				args: Args{password: "123", newEmail: "not an email"},
+				setupMocks: func(md *MockDependencies) {
+					md.userProvider.On("GetById", mock.Anything, testUUID).Return(user, nil)
+				},
+				wantErr: true,
+			},
+			wantErrIs: services.ErrValidation,
+		},
+		{
+			TestCase: TestCase{
+				name: "New address already registered",
+				args: Args{password: "123", newEmail: "taken@test.ru"},
+				setupMocks: func(md *MockDependencies) {
+					md.userProvider.On("GetById", mock.Anything, testUUID).Return(user, nil)
+					md.userProvider.On("Get", mock.Anything, "taken@test.ru").Return(CreateTestUser("taken@test.ru", "1"), nil)
+				},
+				wantErr: true,
+			},
+			wantErrIs: database.ErrEmailAlreadyTaken,
+		},
+	}
+
+	fmt.Println("========== Run request email change unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		args, ok := tt.args.(Args)
+		if !ok {
+			t.Errorf("unexpected args for the test")
+			continue
+		}
+
+		service := newEmailChangeTestService(t, tt.setupMocks)
+
+		// act
+		err := service.RequestEmailChange(context.Background(), testUUID, args.password, args.newEmail, domain.Source{})
+
+		// assert
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+			t.Errorf("unexpected error kind: want %v, have %v", tt.wantErrIs, err)
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	// arrange
+	var (
+		testUUID = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+		updatedUser = CreateTestUser("new@test.ru", "123")
+		change = domain.EmailChange{UserId: testUUID, OldEmail: "old@test.ru", NewEmail: "new@test.ru"}
+	)
+
+	updatedUser.Id = testUUID
+
+	peekToken := func(md *MockDependencies) {
+		md.tokenStorage.
+			On("Peek", mock.Anything, mock.Anything, "c0nf1rm", mock.Anything).
+			Run(func(args mock.Arguments) {
+				*(args.Get(3).(*domain.EmailChange)) = change
+			}).
+			Return(nil)
+	}
+
+	tests := []struct {
+		TestCase
+		wantErrIs error
+	}{
+		{
+			TestCase: TestCase{
+				name: "Email swapped and sessions updated",
+				args: "c0nf1rm",
+				setupMocks: func(md *MockDependencies) {
+					peekToken(md)
+					md.emailUpdater.On("UpdateEmail", mock.Anything, testUUID, "old@test.ru", "new@test.ru").Return(updatedUser, nil)
+					md.tokenStorage.On("Consume", mock.Anything, mock.Anything, "c0nf1rm", mock.Anything).Return(nil).Once()
+					md.sessionUpdater.On("UpdateUserEmail", mock.Anything, testUUID, "new@test.ru").Return(nil).Once()
+					md.mailer.On("Send", mock.Anything, "old@test.ru", mock.Anything, mock.AnythingOfType("string")).Return(nil).Once()
+				},
+				wantErr: false,
+			},
+		},
+		{
+			TestCase: TestCase{
+				name: "Expired token",
+				args: "3xp1red",
+				setupMocks: func(md *MockDependencies) {
+					md.tokenStorage.
+						On("Peek", mock.Anything, mock.Anything, "3xp1red", mock.Anything).
+						Return(database.ErrTokenNotFound)
+				},
+				wantErr: true,
+			},
+			wantErrIs: database.ErrTokenNotFound,
+		},
+		{
+			TestCase: TestCase{
+				name: "Address registered by someone else meanwhile, the token is kept",
+				args: "c0nf1rm",
+				setupMocks: func(md *MockDependencies) {
+					peekToken(md)
+					md.emailUpdater.On("UpdateEmail", mock.Anything, testUUID, "old@test.ru", "new@test.ru").Return(nil, database.ErrEmailAlreadyTaken)
+				},
+				wantErr: true,
+			},
+			wantErrIs: database.ErrEmailAlreadyTaken,
+		},
+	}
+
+	fmt.Println("========== Run confirm email change unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		token, ok := tt.args.(string)
+		if !ok {
+			t.Errorf("unexpected args for the test")
+			continue
+		}
+
+		service := newEmailChangeTestService(t, tt.setupMocks)
+
+		// act
+		user, err := service.ConfirmEmailChange(context.Background(), token, domain.Source{})
+
+		// assert
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+			t.Errorf("unexpected error kind: want %v, have %v", tt.wantErrIs, err)
+		}
+
+		if !tt.wantErr && user.Email != change.NewEmail {
+			t.Errorf("unexpected email: want %s, have %s", change.NewEmail, user.Email)
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
+
+func TestLogMailerKeepsBodyOutOfLog(t *testing.T) {
+	// arrange
+	var out bytes.Buffer
+	sender := mailer.NewLogMailer(slog.New(slog.NewTextHandler(&out, nil)))
+
+	// act
+	err := sender.Send(context.Background(), "user@example.com", "Confirm your new email", "token: secret-confirmation-token")
+
+	// assert
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if strings.Contains(out.String(), "secret-confirmation-token") {
+		t.Errorf("confirmation token logged: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "user@example.com") {
+		t.Errorf("recipient missing from the log: %s", out.String())
+	}
+}
@@ -0,0 +1,193 @@
+package unit
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestExportUserData(t *testing.T) {
+	// arrange
+	testUUID := uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+
+	user := CreateTestUser("test@test.ru", "123")
+	user.Id = testUUID
+	user.DeletedAt = time.Now().Add(-time.Hour)
+	user.Status = domain.UserStatusSuspended
+	user.StatusReason = "chargeback"
+	user.SuspendedUntil = time.Now().Add(24 * time.Hour)
+	adminUUID := uuid.New()
+
+	var (
+		userData = mocks.NewIUserDataProvider(t)
+		sessionProvider = mocks.NewISessionProvider(t)
+		auditReader = mocks.NewIAuditReader(t)
+	)
+
+	userData.On("GetIncludingDeleted", mock.Anything, testUUID).Return(user, nil)
+	userData.On("ListProfileChanges", mock.Anything, testUUID).Return([]domain.ProfileChangeRecord{
+		{Field: domain.ProfileFieldPhone, OldValue: "+79990000000", NewValue: "+79991111111", Version: 2},
+	}, nil)
+	userData.On("ListRoleAssignments", mock.Anything, testUUID).Return([]domain.RoleAssignment{
+		{UserId: testUUID, Role: "admin", GrantedBy: adminUUID, GrantedAt: time.Now()},
+		{UserId: testUUID, Role: "support"},
+	}, nil)
+	userData.On("GetUserGrants", mock.Anything, testUUID).Return(&domain.Grants{
+		Roles: []string{"admin", "support"},
+		Permissions: []string{domain.PermissionUsersManage, domain.PermissionUsersRead},
+	}, nil)
+	sessionProvider.On("GetUserSessions", mock.Anything, testUUID).Return([]*domain.Session{
+		{UserId: testUUID, Email: user.Email, Source: domain.Source{IpAddress: "127.0.0.1", UserAgent: "Chrome"}},
+	}, nil)
+
+	firstPage := domain.AuditQuery{Subject: testUUID, Limit: services.MaxAuditPageSize}
+	secondPage := firstPage
+	secondPage.BeforeId = 5
+	auditReader.On("List", mock.Anything, firstPage).Return([]domain.AuditEvent{
+		{Id: 7, Action: "login", Actor: testUUID, Outcome: domain.AuditOutcomeSuccess, Source: domain.Source{IpAddress: "127.0.0.1"}, Hash: []byte("chain-link")},
+		{Id: 5, Action: "register", Actor: testUUID, Outcome: domain.AuditOutcomeSuccess},
+	}, nil)
+	auditReader.On("List", mock.Anything, secondPage).Return([]domain.AuditEvent{}, nil)
+
+	service := services.NewExportService(userData, sessionProvider, auditReader, NullLogger())
+
+	// act
+	export, err := service.Export(context.Background(), testUUID)
+
+	// assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if export.Version != domain.DataExportVersion || len(export.Sessions) != 1 || len(export.ProfileChanges) != 1 {
+		t.Errorf("incomplete export: %+v", export)
+	}
+
+	if len(export.AuditEvents) != 2 || export.AuditEvents[0].IpAddress != "127.0.0.1" {
+		t.Errorf("audit events missing from the export: %+v", export.AuditEvents)
+	}
+
+	if export.Profile.DeletedAt == nil {
+		t.Errorf("pending deletion is missing from the export")
+	}
+
+	if export.Account.Status != domain.UserStatusSuspended || export.Account.StatusReason != "chargeback" || export.Account.SuspendedUntil == nil {
+		t.Errorf("account status is missing from the export: %+v", export.Account)
+	}
+
+	roles := export.Roles
+	if len(roles.Assignments) != 2 || len(roles.Permissions) != 2 {
+		t.Fatalf("roles are missing from the export: %+v", roles)
+	}
+
+	if roles.Assignments[0].GrantedBy == nil || *roles.Assignments[0].GrantedBy != adminUUID || roles.Assignments[1].GrantedBy != nil {
+		t.Errorf("unexpected role grantors: %+v", roles.Assignments)
+	}
+
+	document, err := services.EncodeExport(export, services.ExportFormatJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if bytes.Contains(document, user.PasswordHash) || bytes.Contains(document, []byte("password")) {
+		t.Errorf("export leaks the password hash")
+	}
+
+	if bytes.Contains(document, []byte("chain-link")) {
+		t.Errorf("export leaks the audit hash chain")
+	}
+}
+
+func TestExportZipManifest(t *testing.T) {
+	// arrange
+	export := &domain.DataExport{
+		Version: domain.DataExportVersion,
+		GeneratedAt: time.Now().UTC(),
+		Profile: domain.ExportedProfile{Id: uuid.New(), Email: "test@test.ru"},
+	}
+
+	// act
+	archive, err := services.EncodeExport(export, services.ExportFormatZip)
+
+	// assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("export is not a zip archive: %v", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("can't open %s: %v", f.Name, err)
+		}
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	var manifest struct {
+		Version int `json:"version"`
+		Files []struct {
+			Name string `json:"name"`
+		} `json:"files"`
+	}
+	if err = json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("bad manifest: %v", err)
+	}
+
+	if manifest.Version != domain.DataExportVersion {
+		t.Errorf("unexpected manifest version %d", manifest.Version)
+	}
+
+	listed := make(map[string]bool)
+	for _, entry := range manifest.Files {
+		listed[entry.Name] = true
+		if _, ok := files[entry.Name]; !ok {
+			t.Errorf("manifest lists missing file %s", entry.Name)
+		}
+	}
+
+	for _, name := range []string{"profile.json", "account.json", "roles.json", "sessions.json", "profile_changes.json", "audit_events.json"} {
+		if !listed[name] {
+			t.Errorf("manifest doesn't list %s", name)
+		}
+	}
+
+	if _, err = services.EncodeExport(export, "xml"); !errors.Is(err, services.ErrUnsupportedExportFormat) {
+		t.Errorf("expected unsupported format error, have %v", err)
+	}
+}
+
+func TestExportUnknownUser(t *testing.T) {
+	// arrange
+	userData := mocks.NewIUserDataProvider(t)
+	userData.On("GetIncludingDeleted", mock.Anything, mock.Anything).Return(nil, database.ErrUserNotFound)
+
+	service := services.NewExportService(userData, mocks.NewISessionProvider(t), mocks.NewIAuditReader(t), NullLogger())
+
+	// act
+	_, err := service.Export(context.Background(), uuid.New())
+
+	// assert
+	if !errors.Is(err, database.ErrUserNotFound) {
+		t.Errorf("expected user not found, have %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	// arrange
+	var (
+		bcryptConf = services.DefaultHasherConfig()
+		strongBcryptConf = services.DefaultHasherConfig()
+		argon2Conf = services.DefaultHasherConfig()
+		strongArgon2Conf = services.DefaultHasherConfig()
+	)
+
+	bcryptConf.BcryptCost = 4
+	strongBcryptConf.BcryptCost = 5
+
+	argon2Conf.Algorithm = services.AlgorithmArgon2id
+	argon2Conf.Argon2.Memory = 1024
+	argon2Conf.Argon2.Iterations = 1
+
+	*strongArgon2Conf = *argon2Conf
+	strongArgon2Conf.Argon2.Iterations = 2
+
+	type Args struct {
+		hashWith *services.HasherConfig
+		verifyWith *services.HasherConfig
+		password string
+		attempt string
+	}
+
+	type Want struct {
+		needsRehash bool
+		err error
+	}
+
+	tests := []struct {
+		name string
+		args Args
+		want Want
+	}{
+		{
+			name: "bcrypt hash verified with same settings",
+			args: Args{hashWith: bcryptConf, verifyWith: bcryptConf, password: "qwerty", attempt: "qwerty"},
+			want: Want{needsRehash: false},
+		},
+		{
+			name: "bcrypt hash with wrong password",
+			args: Args{hashWith: bcryptConf, verifyWith: bcryptConf, password: "qwerty", attempt: "qwertz"},
+			want: Want{err: services.ErrPasswordMismatch},
+		},
+		{
+			name: "bcrypt cost raised",
+			args: Args{hashWith: bcryptConf, verifyWith: strongBcryptConf, password: "qwerty", attempt: "qwerty"},
+			want: Want{needsRehash: true},
+		},
+		{
+			name: "bcrypt migrated to argon2id",
+			args: Args{hashWith: bcryptConf, verifyWith: argon2Conf, password: "qwerty", attempt: "qwerty"},
+			want: Want{needsRehash: true},
+		},
+		{
+			name: "argon2id hash verified with same settings",
+			args: Args{hashWith: argon2Conf, verifyWith: argon2Conf, password: "qwerty", attempt: "qwerty"},
+			want: Want{needsRehash: false},
+		},
+		{
+			name: "argon2id hash with wrong password",
+			args: Args{hashWith: argon2Conf, verifyWith: argon2Conf, password: "qwerty", attempt: "qwertz"},
+			want: Want{err: services.ErrPasswordMismatch},
+		},
+		{
+			name: "argon2id iterations raised",
+			args: Args{hashWith: argon2Conf, verifyWith: strongArgon2Conf, password: "qwerty", attempt: "qwerty"},
+			want: Want{needsRehash: true},
+		},
+		{
+			name: "argon2id rolled back to bcrypt",
+			args: Args{hashWith: argon2Conf, verifyWith: bcryptConf, password: "qwerty", attempt: "qwerty"},
+			want: Want{needsRehash: true},
+		},
+	}
+
+	fmt.Println("========== Run password hasher unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		// act
+		hash, err := TestHasher(tt.args.hashWith).Hash(tt.args.password)
+		if err != nil {
+			t.Errorf("unexpected hash error: %v", err)
+			continue
+		}
+
+		needsRehash, err := TestHasher(tt.args.verifyWith).Verify(hash, tt.args.attempt)
+
+		// assert
+		if !errors.Is(err, tt.want.err) {
+			t.Errorf("unexpected error: want %v, have %v", tt.want.err, err)
+		}
+
+		if needsRehash != tt.want.needsRehash {
+			t.Errorf("unexpected rehash flag: want %v, have %v", tt.want.needsRehash, needsRehash)
+		}
+
+		fmt.Println("PASSED!")
+	}
+
+	if _, err := TestHasher(nil).Verify([]byte("plain-text"), "plain-text"); !errors.Is(err, services.ErrUnknownHashFormat) {
+		t.Errorf("unexpected error for unknown hash format: %v", err)
+	}
+}
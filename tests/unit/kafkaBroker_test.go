@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/events"
+)
+
+func TestKafkaBrokerProducesKeyedRecord(t *testing.T) {
+	// arrange
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(3, events.DefaultTopic))
+	if err != nil {
+		t.Skipf("fake kafka cluster unavailable: %v", err)
+	}
+	defer cluster.Close()
+
+	addrs := cluster.ListenAddrs()
+	broker, err := events.New(&events.Config{Driver: events.DriverKafka, Addr: addrs[0], Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("broker creation failed: %v", err)
+	}
+	defer broker.Close()
+
+	userId := uuid.New()
+	event, _ := domain.NewEvent(domain.EventUserRegistered, userId, domain.UserRegisteredPayload{Email: "test@test.ru"})
+
+	consumer, err := kgo.NewClient(kgo.SeedBrokers(addrs...), kgo.ConsumeTopics(events.DefaultTopic))
+	if err != nil {
+		t.Fatalf("consumer creation failed: %v", err)
+	}
+	defer consumer.Close()
+
+	// act
+	err = broker.Publish(context.Background(), event)
+
+	// assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+
+	records := consumer.PollFetches(ctx).Records()
+	if len(records) != 1 {
+		t.Fatalf("expected one record, have %d", len(records))
+	}
+
+	record := records[0]
+	if string(record.Key) != userId.String() {
+		t.Errorf("record is not keyed by the user: %s", record.Key)
+	}
+	if len(record.Headers) != 1 || record.Headers[0].Key != "id" || string(record.Headers[0].Value) != event.Id.String() {
+		t.Errorf("idempotency key is missing: %v", record.Headers)
+	}
+
+	stored := domain.Event{}
+	if err = json.Unmarshal(record.Value, &stored); err != nil || stored.Id != event.Id {
+		t.Errorf("unexpected record value %s: %v", record.Value, err)
+	}
+}
@@ -0,0 +1,33 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+func TestKeySchema(t *testing.T) {
+	// arrange
+	userId := uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+	custom := database.NewKeySchema("estimator")
+	fallback := database.NewKeySchema("")
+
+	// act & assert
+	tests := map[string]string{
+		custom.Session("token"): "estimator:session:token",
+		custom.UserSessions(userId): "estimator:user:f47ac10b-58cc-4372-a567-0e02b2c3d479:sessions",
+		custom.Lockout("test@test.ru"): "estimator:lockout:test@test.ru",
+		custom.OTP("login", userId): "estimator:otp:login:f47ac10b-58cc-4372-a567-0e02b2c3d479",
+		custom.Token("email-change", "abc"): "estimator:token:email-change:abc",
+		custom.Version(): "estimator:schema_version",
+		fallback.Session("token"): database.DefaultKeyNamespace + ":session:token",
+	}
+
+	for have, want := range tests {
+		if have != want {
+			t.Errorf("unexpected key: want %s, have %s", want, have)
+		}
+	}
+}
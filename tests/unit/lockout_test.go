@@ -0,0 +1,91 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestLoginLockout(t *testing.T) {
+	until := time.Now().Add(time.Minute * 10)
+
+	tests := []struct {
+		name string
+		password string
+		failures int
+		setup func(lockout *mocks.ILoginLockout)
+		wantErr error
+	}{
+		{
+			name: "locked account is refused before the password check",
+			password: "123",
+			failures: 5,
+			wantErr: services.ErrAccountLocked,
+		},
+		{
+			name: "wrong password is counted",
+			password: "wrong",
+			failures: 3,
+			setup: func(lockout *mocks.ILoginLockout) {
+				lockout.On("RecordFailedLogin", mock.Anything, mock.Anything, time.Minute * 15).Return(4, until, nil).Once()
+			},
+			wantErr: services.ErrInvalidCredentials,
+		},
+		{
+			name: "right password resets the counter",
+			password: "123",
+			failures: 3,
+			setup: func(lockout *mocks.ILoginLockout) {
+				lockout.On("ResetFailedLogins", mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			// the account is disabled, so the test stops right after the password check
+			wantErr: services.ErrAccountDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		fmt.Println(tt.name)
+
+		// arrange
+		user := CreateTestUser("test@test.ru", "123")
+		user.Status = domain.UserStatusDisabled
+
+		userProvider := mocks.NewIUserProvider(t)
+		userProvider.On("Get", mock.Anything, "test@test.ru").Return(user, nil)
+
+		lockout := mocks.NewILoginLockout(t)
+		lockout.On("FailedLogins", mock.Anything, user.Id).Return(tt.failures, until, nil).Once()
+		if tt.setup != nil {
+			tt.setup(lockout)
+		}
+
+		authService := services.NewAuthService(
+			userProvider, mocks.NewIUserUpdater(t), mocks.NewIAccountRestorer(t), mocks.NewIRoleProvider(t),
+			mocks.NewISessionProvider(t), mocks.NewISessionSaver(t), mocks.NewISessionRemover(t),
+			lockout, TestHasher(nil), services.EmailNormalizer{}, services.DefaultAccountPolicy(), NullAuditLog(), NullOutbox(), NullLogger(),
+		)
+
+		// act
+		_, _, err := authService.Login(context.Background(), "test@test.ru", tt.password, domain.Source{})
+
+		// assert
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("expected %v, have %v", tt.wantErr, err)
+		}
+
+		var lockedErr *services.AccountLockedError
+		if errors.As(err, &lockedErr) && !lockedErr.Until.Equal(until) {
+			t.Errorf("unexpected lockout end: want %s, have %s", until, lockedErr.Until)
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
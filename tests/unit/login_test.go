@@ -3,7 +3,9 @@ package unit
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -36,10 +38,19 @@ func TestUserLogin(t *testing.T) {
 	)
 
 	validUser.Id = testUUID
+
+	deletedUser := CreateTestUser("deleted@test.ru", "123")
+	deletedUser.Id = testUUID
+	deletedUser.DeletedAt = time.Now().Add(-time.Hour)
 	
+	argon2Conf := services.DefaultHasherConfig()
+	argon2Conf.Algorithm = services.AlgorithmArgon2id
+	argon2Conf.Argon2.Memory = 1024
+
 	type Args struct {
 		creds Credentials
 		ctx context.Context
+		hasherConf *services.HasherConfig
 	}
 
 	tests := []TestCase{
@@ -67,6 +78,38 @@ func TestUserLogin(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Successful login - outdated hash upgraded",
+			args: Args{
+				creds: Credentials{
+					email: "test@test.ru",
+					password: "123",
+				},
+				ctx: context.Background(),
+				hasherConf: argon2Conf,
+			},
+			setupMocks: func(md *MockDependencies) {
+				md.userProvider.
+					On("Get", mock.Anything, "test@test.ru").
+					Return(validUser, nil)
+
+				md.userUpdater.
+					On("UpdatePasswordHash", mock.Anything, testUUID, mock.MatchedBy(func(hash []byte) bool {
+						return strings.HasPrefix(string(hash), "$argon2id$")
+					})).
+					Return(nil).
+					Once()
+				
+				md.sessionProvider.
+					On("GetUserSessions", mock.Anything, testUUID).
+					Return([]*domain.Session{}, nil)
+				
+				md.sessionSaver.
+					On("Save", mock.Anything, mock.Anything, mock.Anything).
+					Return(testRefreshToken, nil)
+			},
+			wantErr: false,
+		},
 		{
 			name: "Failed login - no such user",
 			args: Args{
@@ -80,6 +123,30 @@ func TestUserLogin(t *testing.T) {
 				md.userProvider.
 					On("Get", mock.Anything, "absent@user.com").
 					Return(nil, database.ErrUserNotFound)
+
+				md.accountRestorer.
+					On("GetDeleted", mock.Anything, "absent@user.com").
+					Return(nil, database.ErrUserNotFound)
+			},
+			wantErr: true,
+		},
+		{
+			name: "Failed login - account pending deletion",
+			args: Args{
+				creds: Credentials{
+					email: "deleted@test.ru",
+					password: "123",
+				},
+				ctx: context.Background(),
+			},
+			setupMocks: func(md *MockDependencies) {
+				md.userProvider.
+					On("Get", mock.Anything, "deleted@test.ru").
+					Return(nil, database.ErrUserNotFound)
+
+				md.accountRestorer.
+					On("GetDeleted", mock.Anything, "deleted@test.ru").
+					Return(deletedUser, nil)
 			},
 			wantErr: true,
 		},
@@ -114,6 +181,9 @@ func TestUserLogin(t *testing.T) {
 
 		var (
 			userProvider = mocks.NewIUserProvider(t)
+			userUpdater = mocks.NewIUserUpdater(t)
+			accountRestorer = mocks.NewIAccountRestorer(t)
+			roleProvider = mocks.NewIRoleProvider(t)
 			sessionProvider = mocks.NewISessionProvider(t)
 			sessionSaver = mocks.NewISessionSaver(t)
 			sessionRemover = mocks.NewISessionRemover(t)
@@ -121,6 +191,9 @@ func TestUserLogin(t *testing.T) {
 		
 		md := &MockDependencies{
 			userProvider: userProvider,	
+			userUpdater: userUpdater,
+			accountRestorer: accountRestorer,
+			roleProvider: roleProvider,
 			sessionProvider: sessionProvider,
 			sessionSaver: sessionSaver,
 			sessionRemover: sessionRemover,
@@ -128,8 +201,11 @@ func TestUserLogin(t *testing.T) {
 
 		tt.setupMocks(md)
 
+		roleProvider.On("GetUserGrants", mock.Anything, mock.Anything).Return(&domain.Grants{}, nil).Maybe()
+
 		authService := services.NewAuthService(
-			userProvider, sessionProvider, sessionSaver, sessionRemover, NullLogger(),
+			userProvider, userUpdater, accountRestorer, roleProvider, sessionProvider, sessionSaver, sessionRemover,
+			NullLockout(), TestHasher(args.hasherConf), services.EmailNormalizer{}, services.DefaultAccountPolicy(), NullAuditLog(), NullOutbox(), NullLogger(),
 		)
 
 		// act
@@ -0,0 +1,158 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "github.com/nikita-itmo-gh-acc/car_estimator_api_contracts/gen/profile_v1"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/app"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+func TestMemoryUserRepositoryConstraints(t *testing.T) {
+	// arrange
+	repository := database.NewMemoryUserRepository()
+	ctx := context.Background()
+
+	user := *CreateTestUser("Test@test.ru", "123")
+	user.Id = uuid.New()
+	user.FullName = "Test User"
+	user.Phone = "+79991234567"
+
+	// act
+	firstErr := repository.Save(ctx, user)
+
+	duplicate := user
+	duplicate.Id = uuid.New()
+	duplicate.Email = "test@TEST.ru"
+	duplicate.Phone = "+79990000000"
+	duplicateErr := repository.Save(ctx, duplicate)
+
+	blank := duplicate
+	blank.Email = "other@test.ru"
+	blank.FullName = " "
+	blankErr := repository.Save(ctx, blank)
+
+	// assert
+	if firstErr != nil {
+		t.Fatalf("unexpected error: %v", firstErr)
+	}
+
+	if !errors.Is(duplicateErr, database.ErrUserAlreadyExists) {
+		t.Errorf("case-insensitive email duplicate accepted: %v", duplicateErr)
+	}
+
+	var constraintErr *database.ConstraintError
+	if !errors.As(blankErr, &constraintErr) || constraintErr.Column != "fullname" || !errors.Is(blankErr, database.ErrCheckViolation) {
+		t.Errorf("blank name accepted: %v", blankErr)
+	}
+
+	if _, err := repository.Get(ctx, "TEST@test.ru"); err != nil {
+		t.Errorf("user not found by email in other case: %v", err)
+	}
+}
+
+func TestMemorySessionRepositoryExpiry(t *testing.T) {
+	// arrange
+	repository := database.NewMemorySessionRepository()
+	ctx := context.Background()
+	session := &domain.Session{UserId: uuid.New(), Email: "test@test.ru"}
+
+	short, _ := repository.Save(ctx, session, 50 * time.Millisecond)
+	long, _ := repository.Save(ctx, session, time.Hour)
+
+	// act
+	time.Sleep(100 * time.Millisecond)
+	_, expiredErr := repository.Get(ctx, short)
+	sessions, listErr := repository.GetUserSessions(ctx, session.UserId)
+
+	rotated, rotateErr := repository.Rotate(ctx, long, session, time.Hour)
+	_, replayErr := repository.Rotate(ctx, long, session, time.Hour)
+
+	// assert
+	if !errors.Is(expiredErr, database.ErrSessionNotFound) {
+		t.Errorf("expired session returned: %v", expiredErr)
+	}
+
+	if listErr != nil || len(sessions) != 1 {
+		t.Errorf("unexpected sessions: %v, %v", sessions, listErr)
+	}
+
+	if rotateErr != nil || rotated == long {
+		t.Errorf("unexpected rotation result: %s, %v", rotated, rotateErr)
+	}
+
+	if !errors.Is(replayErr, database.ErrSessionNotFound) {
+		t.Errorf("used token rotated again: %v", replayErr)
+	}
+}
+
+func TestAppRunsOnMemoryStorage(t *testing.T) {
+	// arrange
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("loopback listener unavailable: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	memory := &database.Config{Driver: database.DriverMemory}
+	application, err := app.New(NullLogger(), &app.Config{
+		UserStorage: memory,
+		SessionStorage: memory,
+		Hasher: services.DefaultHasherConfig(),
+		AccountPolicy: services.DefaultAccountPolicy(),
+		LogMail: true,
+		Port: port,
+	})
+	if err != nil {
+		t.Fatalf("app creation failed: %v", err)
+	}
+
+	go application.Run()
+	defer application.Stop()
+
+	cc, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc client creation failed: %v", err)
+	}
+	defer cc.Close()
+
+	client := pb.NewProfileServiceClient(cc)
+	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+	defer cancel()
+
+	// act
+	_, registerErr := client.Register(ctx, &pb.RegisterRequest{
+		Fullname: "Test User",
+		Email: "memory@test.ru",
+		Phone: "+79991234567",
+		Password: "qwertty",
+		Birthdate: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC).Unix(),
+	}, grpc.WaitForReady(true))
+
+	resp, loginErr := client.Login(ctx, &pb.LoginRequest{
+		Email: "memory@test.ru",
+		Password: "qwertty",
+		Source: &pb.SourceData{Ip: "127.0.0.1:8000", UserAgent: "Chrome/137.0.0.0"},
+	})
+
+	// assert
+	if registerErr != nil {
+		t.Fatalf("registration failed: %v", registerErr)
+	}
+
+	if loginErr != nil || resp.GetTokens().GetRefreshToken() == "" {
+		t.Errorf("login failed: %v", loginErr)
+	}
+}
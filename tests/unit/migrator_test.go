@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+)
+
+func assertMigrationStatus(t *testing.T, m *database.Migrator, wantVersion uint, wantDirty bool) {
+	t.Helper()
+
+	version, dirty, err := m.Status()
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if version != wantVersion || dirty != wantDirty {
+		t.Fatalf("expected version %d dirty %v, got version %d dirty %v", wantVersion, wantDirty, version, dirty)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	// arrange
+	conf := &database.Config{Driver: database.DriverSQLite, DBName: filepath.Join(t.TempDir(), "auth.db")}
+
+	m, err := database.NewMigrator(conf)
+	if err != nil {
+		t.Fatalf("migrator creation failed: %v", err)
+	}
+	defer m.Close()
+
+	assertMigrationStatus(t, m, 0, false)
+
+	// act & assert
+	if err = m.Apply(); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	assertMigrationStatus(t, m, 1, false)
+
+	if err = m.Apply(); err != nil {
+		t.Fatalf("apply on an up-to-date database failed: %v", err)
+	}
+
+	if err = m.RollBack(1); err != nil {
+		t.Fatalf("rollback failed: %v", err)
+	}
+	assertMigrationStatus(t, m, 0, false)
+
+	if err = m.Goto(1); err != nil {
+		t.Fatalf("goto failed: %v", err)
+	}
+	assertMigrationStatus(t, m, 1, false)
+
+	if err = m.Goto(99); err == nil {
+		t.Fatal("expected goto to a missing version to fail")
+	}
+
+	if err = m.Force(-1); err != nil {
+		t.Fatalf("force failed: %v", err)
+	}
+	assertMigrationStatus(t, m, 0, false)
+}
@@ -44,6 +44,34 @@ func (_m *ISessionSaver) Save(ctx context.Context, session *domain.Session, expi
 	return r0, r1
 }
 
+// Rotate provides a mock function with given fields: ctx, token, session, expiresIn
+func (_m *ISessionSaver) Rotate(ctx context.Context, token string, session *domain.Session, expiresIn time.Duration) (string, error) {
+	ret := _m.Called(ctx, token, session, expiresIn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Rotate")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Session, time.Duration) (string, error)); ok {
+		return rf(ctx, token, session, expiresIn)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Session, time.Duration) string); ok {
+		r0 = rf(ctx, token, session, expiresIn)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.Session, time.Duration) error); ok {
+		r1 = rf(ctx, token, session, expiresIn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewISessionSaver creates a new instance of ISessionSaver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewISessionSaver(t interface {
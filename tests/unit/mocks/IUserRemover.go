@@ -15,12 +15,12 @@ type IUserRemover struct {
 	mock.Mock
 }
 
-// Delete provides a mock function with given fields: ctx, userId
-func (_m *IUserRemover) Delete(ctx context.Context, userId uuid.UUID) error {
+// SoftDelete provides a mock function with given fields: ctx, userId
+func (_m *IUserRemover) SoftDelete(ctx context.Context, userId uuid.UUID) error {
 	ret := _m.Called(ctx, userId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Delete")
+		panic("no return value specified for SoftDelete")
 	}
 
 	var r0 error
@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/events"
+)
+
+// runJetStream starts an in-process server with JetStream on a random loopback port.
+func runJetStream(t *testing.T) *server.Server {
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("nats server creation failed: %v", err)
+	}
+
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Skip("nats server didn't start")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	return srv
+}
+
+func newJetStreamBroker(t *testing.T, srv *server.Server) events.Broker {
+	broker, err := events.New(&events.Config{Driver: events.DriverNATS, Addr: srv.ClientURL(), JetStream: true, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("broker creation failed: %v", err)
+	}
+	t.Cleanup(func() { broker.Close() })
+
+	return broker
+}
+
+func TestNATSBrokerDeduplicatesRedeliveries(t *testing.T) {
+	// arrange
+	srv := runJetStream(t)
+
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("nats connection failed: %v", err)
+	}
+	defer conn.Close()
+
+	js, _ := jetstream.New(conn)
+	stream, err := js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "AUTH", Subjects: []string{events.DefaultTopic + ".>"}})
+	if err != nil {
+		t.Fatalf("stream creation failed: %v", err)
+	}
+
+	broker := newJetStreamBroker(t, srv)
+	event, _ := domain.NewEvent(domain.EventUserRegistered, uuid.New(), domain.UserRegisteredPayload{Email: "test@test.ru"})
+
+	// act
+	firstErr := broker.Publish(context.Background(), event)
+	secondErr := broker.Publish(context.Background(), event)
+
+	// assert
+	if firstErr != nil || secondErr != nil {
+		t.Fatalf("unexpected errors: %v, %v", firstErr, secondErr)
+	}
+
+	info, err := stream.Info(context.Background())
+	if err != nil {
+		t.Fatalf("stream info failed: %v", err)
+	}
+	if info.State.Msgs != 1 {
+		t.Errorf("redelivery stored again, the stream has %d messages", info.State.Msgs)
+	}
+
+	msg, err := stream.GetLastMsgForSubject(context.Background(), events.DefaultTopic + "." + domain.EventUserRegistered)
+	if err != nil {
+		t.Fatalf("stored event not found: %v", err)
+	}
+	if msg.Header.Get(jetstream.MsgIDHeader) != event.Id.String() {
+		t.Errorf("idempotency key is missing: %v", msg.Header)
+	}
+}
+
+func TestNATSBrokerWithoutStream(t *testing.T) {
+	// arrange
+	broker := newJetStreamBroker(t, runJetStream(t))
+	event, _ := domain.NewEvent(domain.EventUserRegistered, uuid.New(), domain.UserRegisteredPayload{Email: "test@test.ru"})
+
+	// act
+	err := broker.Publish(context.Background(), event)
+
+	// assert
+	if !errors.Is(err, events.ErrNoStream) {
+		t.Errorf("expected no stream error, have %v", err)
+	}
+}
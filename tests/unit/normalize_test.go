@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+)
+
+func TestEmailNormalizer(t *testing.T) {
+	tests := []struct {
+		name string
+		normalizer services.EmailNormalizer
+		email string
+		want string
+		wantErr bool
+	}{
+		{
+			name: "trim and lowercase domain",
+			email: "  Bob@Mail.COM ",
+			want: "Bob@mail.com",
+		},
+		{
+			name: "lowercase local part when enabled",
+			normalizer: services.EmailNormalizer{LowercaseLocal: true},
+			email: "Bob@Mail.com",
+			want: "bob@mail.com",
+		},
+		{
+			name: "internationalised domain to punycode",
+			email: "ivan@Почта.рф",
+			want: "ivan@xn--80a1acny.xn--p1ai",
+		},
+		{
+			name: "quoted local part with @ inside",
+			email: `"a@b"@example.com`,
+			want: `"a@b"@example.com`,
+		},
+		{
+			name: "missing domain",
+			email: "bob@",
+			wantErr: true,
+		},
+		{
+			name: "missing at sign",
+			email: "bob.mail.com",
+			wantErr: true,
+		},
+	}
+
+	fmt.Println("========== Run email normalizer unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		// act
+		email, err := tt.normalizer.Normalize(tt.email)
+
+		// assert
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unexpected error: %v", err)
+			continue
+		}
+
+		if tt.wantErr && !errors.Is(err, services.ErrValidation) {
+			t.Errorf("expected validation error, have %v", err)
+		}
+
+		if email != tt.want {
+			t.Errorf("unexpected result: want %q, have %q", tt.want, email)
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
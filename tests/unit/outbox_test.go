@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/events"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func outboxEvent(t *testing.T, eventType string, attempts int) domain.OutboxEvent {
+	event, err := domain.NewEvent(eventType, uuid.New(), domain.UserDeletedPayload{DeletedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("event creation failed: %v", err)
+	}
+	return domain.OutboxEvent{Event: event, Attempts: attempts}
+}
+
+func TestOutboxRelayPublishesInOrder(t *testing.T) {
+	// arrange
+	conf := services.DefaultRelayConfig()
+	store := mocks.NewIOutboxStore(t)
+	broker := events.NewMemoryBroker()
+
+	pending := []domain.OutboxEvent{
+		outboxEvent(t, domain.EventUserRegistered, 1),
+		outboxEvent(t, domain.EventUserDeleted, 1),
+	}
+
+	store.On("ClaimEvents", mock.Anything, conf.BatchSize, conf.Lease).Return(pending, nil).Once()
+	store.On("MarkPublished", mock.Anything, []uuid.UUID{pending[0].Id, pending[1].Id}).Return(nil).Once()
+
+	relay := services.NewOutboxRelay(store, broker, conf, NullLogger())
+
+	// act
+	relayed, err := relay.RelayOnce(context.Background())
+
+	// assert
+	if err != nil || relayed != 2 {
+		t.Fatalf("unexpected relay result: %d, %v", relayed, err)
+	}
+
+	published := broker.Published()
+	if len(published) != 2 || published[0].Id != pending[0].Id || published[1].Id != pending[1].Id {
+		t.Errorf("unexpected published events: %v", published)
+	}
+}
+
+func TestOutboxRelayRetriesRejectedEvents(t *testing.T) {
+	// arrange
+	conf := services.DefaultRelayConfig()
+	store := mocks.NewIOutboxStore(t)
+	broker := mocks.NewIEventBroker(t)
+
+	accepted := outboxEvent(t, domain.EventUserRegistered, 1)
+	rejected := outboxEvent(t, domain.EventSessionRevoked, 3)
+
+	store.On("ClaimEvents", mock.Anything, conf.BatchSize, conf.Lease).Return([]domain.OutboxEvent{accepted, rejected}, nil).Once()
+	broker.On("Publish", mock.Anything, accepted.Event).Return(nil).Once()
+	broker.On("Publish", mock.Anything, rejected.Event).Return(errors.New("broker is unavailable")).Once()
+
+	// the third attempt waits four poll intervals
+	store.
+		On("MarkFailed", mock.Anything, rejected.Id, "broker is unavailable", mock.MatchedBy(func(retryAt time.Time) bool {
+			return time.Until(retryAt).Round(time.Second) == 4 * conf.PollInterval
+		})).
+		Return(nil).
+		Once()
+	store.On("MarkPublished", mock.Anything, []uuid.UUID{accepted.Id}).Return(nil).Once()
+
+	relay := services.NewOutboxRelay(store, broker, conf, NullLogger())
+
+	// act
+	relayed, err := relay.RelayOnce(context.Background())
+
+	// assert
+	if err != nil || relayed != 2 {
+		t.Errorf("unexpected relay result: %d, %v", relayed, err)
+	}
+}
+
+func TestLogoutPublishesSessionRevoked(t *testing.T) {
+	// arrange
+	userId := uuid.New()
+	sessionProvider := mocks.NewISessionProvider(t)
+	sessionRemover := mocks.NewISessionRemover(t)
+	outbox := mocks.NewIEventOutbox(t)
+
+	sessionProvider.On("Get", mock.Anything, "refresh").Return(&domain.Session{UserId: userId}, nil)
+	sessionRemover.On("Delete", mock.Anything, "refresh").Return(nil).Once()
+	outbox.
+		On("AppendEvent", mock.Anything, mock.MatchedBy(func(event domain.Event) bool {
+			return event.Type == domain.EventSessionRevoked && event.UserId == userId && event.Id != uuid.Nil
+		})).
+		Return(nil).
+		Once()
+
+	authService := services.NewAuthService(
+		mocks.NewIUserProvider(t), mocks.NewIUserUpdater(t), mocks.NewIAccountRestorer(t), mocks.NewIRoleProvider(t),
+		sessionProvider, mocks.NewISessionSaver(t), sessionRemover,
+		NullLockout(), TestHasher(nil), services.EmailNormalizer{}, services.DefaultAccountPolicy(),
+		NullAuditLog(), outbox, NullLogger(),
+	)
+
+	// act
+	err := authService.Logout(context.Background(), "refresh")
+
+	// assert
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPasswordHashUpdatePublishesPasswordChanged(t *testing.T) {
+	// arrange
+	repository := database.NewMemoryUserRepository()
+	ctx := context.Background()
+
+	user := *CreateTestUser("test@test.ru", "123")
+	user.Id = uuid.New()
+	user.Phone = "+79991234567"
+
+	if err := repository.Save(ctx, user); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// act
+	err := repository.UpdatePasswordHash(ctx, user.Id, []byte("upgraded"))
+	pending, claimErr := repository.ClaimEvents(ctx, 10, time.Minute)
+
+	// assert
+	if err != nil || claimErr != nil {
+		t.Fatalf("unexpected error: %v, %v", err, claimErr)
+	}
+
+	last := pending[len(pending) - 1]
+	if last.Type != domain.EventPasswordChanged || last.UserId != user.Id {
+		t.Errorf("unexpected last event: %s of %s", last.Type, last.UserId)
+	}
+}
@@ -0,0 +1,174 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	// arrange
+	var (
+		testUUID = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+		updatedUser = CreateTestUser("test@test.ru", "123")
+	)
+
+	updatedUser.Id = testUUID
+	updatedUser.FullName = "New Name"
+	updatedUser.Version = 2
+
+	type Args struct {
+		ctx context.Context
+		update domain.ProfileUpdate
+	}
+
+	tests := []struct {
+		TestCase
+		wantErrIs error
+	}{
+		{
+			TestCase: TestCase{
+				name: "Successful name update",
+				args: Args{
+					ctx: context.Background(),
+					update: domain.ProfileUpdate{
+						Mask: []string{domain.ProfileFieldFullName},
+						FullName: "  New Name ",
+						ExpectedVersion: 1,
+					},
+				},
+				setupMocks: func(md *MockDependencies) {
+					md.profileUpdater.
+						On("UpdateProfile", mock.Anything, testUUID, mock.MatchedBy(func(u domain.ProfileUpdate) bool {
+							return u.FullName == "New Name"
+						})).
+						Return(updatedUser, nil).
+						Once()
+				},
+				wantErr: false,
+			},
+		},
+		{
+			TestCase: TestCase{
+				name: "Empty update mask",
+				args: Args{
+					ctx: context.Background(),
+					update: domain.ProfileUpdate{FullName: "New Name"},
+				},
+				setupMocks: func(md *MockDependencies) {},
+				wantErr: true,
+			},
+			wantErrIs: services.ErrValidation,
+		},
+		{
+			TestCase: TestCase{
+				name: "Invalid phone and future birth date",
+				args: Args{
+					ctx: context.Background(),
+					update: domain.ProfileUpdate{
+						Mask: []string{domain.ProfileFieldPhone, domain.ProfileFieldBirthDate},
+						Phone: "call me",
+						BirthDate: time.Now().Add(time.Hour * 24),
+					},
+				},
+				setupMocks: func(md *MockDependencies) {},
+				wantErr: true,
+			},
+			wantErrIs: services.ErrValidation,
+		},
+		{
+			TestCase: TestCase{
+				name: "Stale version",
+				args: Args{
+					ctx: context.Background(),
+					update: domain.ProfileUpdate{
+						Mask: []string{domain.ProfileFieldPhone},
+						Phone: "+79999999999",
+						ExpectedVersion: 1,
+					},
+				},
+				setupMocks: func(md *MockDependencies) {
+					md.profileUpdater.
+						On("UpdateProfile", mock.Anything, testUUID, mock.AnythingOfType("domain.ProfileUpdate")).
+						Return(nil, database.ErrVersionConflict)
+				},
+				wantErr: true,
+			},
+			wantErrIs: database.ErrVersionConflict,
+		},
+		{
+			TestCase: TestCase{
+				name: "Phone taken by another account",
+				args: Args{
+					ctx: context.Background(),
+					update: domain.ProfileUpdate{
+						Mask: []string{domain.ProfileFieldPhone},
+						Phone: "+79999999999",
+					},
+				},
+				setupMocks: func(md *MockDependencies) {
+					md.profileUpdater.
+						On("UpdateProfile", mock.Anything, testUUID, mock.AnythingOfType("domain.ProfileUpdate")).
+						Return(nil, database.ErrPhoneAlreadyTaken)
+				},
+				wantErr: true,
+			},
+			wantErrIs: database.ErrPhoneAlreadyTaken,
+		},
+	}
+
+	fmt.Println("========== Run update profile unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		args, ok := tt.args.(Args)
+		if !ok {
+			t.Errorf("unexpected args for the test")
+			continue
+		}
+
+		var (
+			userProvider = mocks.NewIUserProvider(t)
+			profileUpdater = mocks.NewIProfileUpdater(t)
+		)
+
+		md := &MockDependencies{
+			userProvider: userProvider,
+			profileUpdater: profileUpdater,
+		}
+
+		tt.setupMocks(md)
+
+		profileService := services.NewProfileService(userProvider, profileUpdater, NullLogger())
+
+		// act
+		user, err := profileService.UpdateProfile(args.ctx, testUUID, args.update)
+
+		// assert
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+			t.Errorf("unexpected error kind: want %v, have %v", tt.wantErrIs, err)
+		}
+
+		if !tt.wantErr && user.Version != updatedUser.Version {
+			t.Errorf("unexpected version: want %d, have %d", updatedUser.Version, user.Version)
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
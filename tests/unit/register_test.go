@@ -2,7 +2,6 @@ package unit
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -35,10 +34,14 @@ func TestUserRegister(t *testing.T) {
 		}
 
 		invalidUserCredentials = validUserCredentials
+		tooYoungUser = validUserCredentials
+		displayNameEmailUser = validUserCredentials
 		emptyId = uuid.UUID{}
 	)
 	
 	invalidUserCredentials.Email = ""
+	tooYoungUser.BirthDate = time.Now().AddDate(-5, 0, 0)
+	displayNameEmailUser.Email = "Test User <test@test.ru>"
 
 	tests := []TestCase{
 		{
@@ -74,10 +77,37 @@ func TestUserRegister(t *testing.T) {
 				ctx: context.Background(),
 				user: invalidUserCredentials,
 			},
+			setupMocks: func(md *MockDependencies) {},
+			wantErr: true,
+		},
+		{
+			name: "Too young user",
+			args: Args{
+				ctx: context.Background(),
+				user: tooYoungUser,
+			},
+			setupMocks: func(md *MockDependencies) {},
+			wantErr: true,
+		},
+		{
+			name: "Email with display name",
+			args: Args{
+				ctx: context.Background(),
+				user: displayNameEmailUser,
+			},
+			setupMocks: func(md *MockDependencies) {},
+			wantErr: true,
+		},
+		{
+			name: "Storage check violation",
+			args: Args{
+				ctx: context.Background(),
+				user: validUserCredentials,
+			},
 			setupMocks: func(md *MockDependencies) {
 				md.userSaver.
 					On("Save", mock.Anything, mock.AnythingOfType("domain.User")).
-					Return(errors.New("Invalid email value"))
+					Return(&database.ConstraintError{Column: "phone", Err: database.ErrCheckViolation})
 			},
 			wantErr: true,
 		},
@@ -108,13 +138,14 @@ func TestUserRegister(t *testing.T) {
 		tt.setupMocks(md)
 
 		registerService := services.NewRegistrarService(
-			userSaver, userRemover, sessionRemover, sessionProvider, NullLogger(),
+			userSaver, userRemover, sessionRemover, sessionProvider, TestHasher(nil), services.EmailNormalizer{}, NullAuditLog(), NullOutbox(), NullLogger(),
 		)
 
 		// act
 		userId, err := registerService.Register(
 			args.ctx,
 			args.user,
+			domain.Source{},
 		)
 
 		// assert
@@ -0,0 +1,150 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/interceptors"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestAccessTokenCarriesGrants(t *testing.T) {
+	// arrange
+	user := CreateTestUser("admin@test.ru", "123")
+	user.Id = uuid.New()
+
+	grants := domain.Grants{
+		Roles: []string{"admin"},
+		Permissions: []string{domain.PermissionRolesManage, domain.PermissionUsersRead},
+	}
+
+	// act
+	tokenString, err := services.CreateJWT(user, time.Now(), grants)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	token, err := services.VerifyJWT(tokenString)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parsed := services.GetTokenGrants(token)
+
+	// assert
+	if len(parsed.Roles) != 1 || parsed.Roles[0] != "admin" {
+		t.Errorf("unexpected roles: %v", parsed.Roles)
+	}
+
+	if !parsed.HasPermission(domain.PermissionRolesManage) || !parsed.HasPermission(domain.PermissionUsersRead) {
+		t.Errorf("unexpected permissions: %v", parsed.Permissions)
+	}
+}
+
+func TestAuthInterceptorPermissions(t *testing.T) {
+	// arrange
+	const method = "/profile.RoleService/AssignRole"
+
+	user := CreateTestUser("test@test.ru", "123")
+	user.Id = uuid.New()
+
+	adminToken, _ := services.CreateJWT(user, time.Now(), domain.Grants{
+		Roles: []string{"admin"},
+		Permissions: []string{domain.PermissionRolesManage},
+	})
+	supportToken, _ := services.CreateJWT(user, time.Now(), domain.Grants{
+		Roles: []string{"support"},
+		Permissions: []string{domain.PermissionRolesRead},
+	})
+
+	tests := []struct {
+		name string
+		token string
+		wantCode codes.Code
+	}{
+		{name: "Permission granted", token: adminToken, wantCode: codes.OK},
+		{name: "Permission missing", token: supportToken, wantCode: codes.PermissionDenied},
+	}
+
+	interceptor := interceptors.AuthInterceptor(map[string][]string{method: {domain.PermissionRolesManage}})
+
+	fmt.Println("========== Run auth interceptor permissions unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer " + tt.token))
+		handler := func(ctx context.Context, req any) (any, error) { return nil, nil }
+
+		// act
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+
+		// assert
+		if code := status.Code(err); code != tt.wantCode {
+			t.Errorf("unexpected status: want %s, have %s", tt.wantCode, code)
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
+
+func TestRoleAssignment(t *testing.T) {
+	// arrange
+	var (
+		actorId = uuid.New()
+		userId = uuid.New()
+		roles = mocks.NewIRoleManager(t)
+		audit = mocks.NewIAuditLog(t)
+	)
+
+	roles.
+		On("AssignRole", mock.Anything, mock.MatchedBy(func(a domain.RoleAssignment) bool {
+			return a.UserId == userId && a.Role == "support" && a.GrantedBy == actorId
+		})).
+		Return(nil).
+		Once()
+
+	audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(e domain.AuditEvent) bool {
+			return e.Action == services.AuditActionRoleAssign && e.Actor == actorId && e.Target == userId &&
+				e.Metadata["role"] == "support" && e.Outcome == domain.AuditOutcomeSuccess
+		})).
+		Return(nil).
+		Once()
+
+	audit.
+		On("Record", mock.Anything, mock.MatchedBy(func(e domain.AuditEvent) bool {
+			return e.Action == services.AuditActionRoleRevoke && e.Actor == actorId && e.Target == actorId &&
+				e.Metadata["role"] == "admin" && e.Outcome == domain.AuditOutcomeFailure
+		})).
+		Return(nil).
+		Once()
+
+	service := services.NewRoleService(roles, audit, NullLogger())
+
+	// act
+	assignErr := service.AssignRole(context.Background(), actorId, userId, "support", domain.Source{})
+	revokeErr := service.RevokeRole(context.Background(), actorId, actorId, "admin", domain.Source{})
+
+	// assert
+	if assignErr != nil {
+		t.Errorf("unexpected error: %v", assignErr)
+	}
+
+	if !errors.Is(revokeErr, services.ErrSelfRevoke) {
+		t.Errorf("expected self revoke error, have %v", revokeErr)
+	}
+}
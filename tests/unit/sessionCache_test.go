@@ -0,0 +1,188 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+// localBus delivers invalidations to every cache connected to it, like Redis pub/sub does.
+type localBus struct {
+	mu sync.Mutex
+	handlers []func(string)
+}
+
+type localBusClient struct {
+	bus *localBus
+}
+
+func (b *localBus) client() database.InvalidationBus {
+	return localBusClient{bus: b}
+}
+
+func (c localBusClient) Publish(ctx context.Context, message string) error {
+	c.bus.mu.Lock()
+	handlers := append([]func(string){}, c.bus.handlers...)
+	c.bus.mu.Unlock()
+
+	for _, handle := range handlers {
+		handle(message)
+	}
+	return nil
+}
+
+func (c localBusClient) Listen(ctx context.Context, handle func(string), resync func()) {
+	c.bus.mu.Lock()
+	c.bus.handlers = append(c.bus.handlers, handle)
+	c.bus.mu.Unlock()
+
+	resync()
+	<-ctx.Done()
+}
+
+func (c localBusClient) Close() error {
+	return nil
+}
+
+func sessionCacheMetric(name string) int64 {
+	value := expvar.Get("session_cache").(*expvar.Map).Get(name)
+	if value == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(value.String(), 10, 64)
+	return n
+}
+
+// newCachedInstances returns caches of separate instances sharing one session store.
+func newCachedInstances(t *testing.T, count, size int, ttl time.Duration) []*database.SessionCache {
+	backend := database.NewMemorySessionRepository()
+	bus := &localBus{}
+
+	caches := make([]*database.SessionCache, count)
+	for i := range caches {
+		caches[i] = database.NewSessionCache(backend, bus.client(), size, ttl)
+		t.Cleanup(caches[i].Exit)
+	}
+
+	// wait for the listeners to subscribe
+	for {
+		bus.mu.Lock()
+		subscribed := len(bus.handlers)
+		bus.mu.Unlock()
+		if subscribed == count {
+			return caches
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSessionCacheInvalidatesOtherInstances(t *testing.T) {
+	// arrange
+	caches := newCachedInstances(t, 2, 10, time.Minute)
+	ctx := context.Background()
+	userId := uuid.New()
+
+	token, err := caches[0].Save(ctx, &domain.Session{UserId: userId, Email: "cache@mail.ru"}, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hits, misses := sessionCacheMetric("hits"), sessionCacheMetric("misses")
+
+	// act
+	_, firstErr := caches[1].Get(ctx, token)
+	_, secondErr := caches[1].Get(ctx, token)
+	listed, listErr := caches[1].GetUserSessions(ctx, userId)
+	deleteErr := caches[0].Delete(ctx, token)
+	_, deletedErr := caches[1].Get(ctx, token)
+	afterDelete, afterDeleteErr := caches[1].GetUserSessions(ctx, userId)
+
+	// assert
+	if firstErr != nil || secondErr != nil || listErr != nil || deleteErr != nil || afterDeleteErr != nil {
+		t.Fatalf("unexpected errors: %v, %v, %v, %v, %v", firstErr, secondErr, listErr, deleteErr, afterDeleteErr)
+	}
+
+	if sessionCacheMetric("hits") - hits != 1 || sessionCacheMetric("misses") - misses < 1 {
+		t.Errorf("unexpected hit/miss counts")
+	}
+
+	if len(listed) != 1 || len(afterDelete) != 0 {
+		t.Errorf("stale session list served: %d before, %d after delete", len(listed), len(afterDelete))
+	}
+
+	if !errors.Is(deletedErr, database.ErrSessionNotFound) {
+		t.Errorf("session deleted on another instance still served: %v", deletedErr)
+	}
+}
+
+func TestSessionCacheUserInvalidation(t *testing.T) {
+	// arrange
+	caches := newCachedInstances(t, 2, 10, time.Minute)
+	ctx := context.Background()
+	userId := uuid.New()
+
+	token, err := caches[0].Save(ctx, &domain.Session{UserId: userId, Email: "old@mail.ru"}, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = caches[1].Get(ctx, token); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// act
+	updateErr := caches[0].UpdateUserEmail(ctx, userId, "new@mail.ru")
+	updated, getErr := caches[1].Get(ctx, token)
+	removeErr := caches[0].DeleteUserSessions(ctx, userId)
+	_, removedErr := caches[1].Get(ctx, token)
+
+	// assert
+	if updateErr != nil || getErr != nil || removeErr != nil {
+		t.Fatalf("unexpected errors: %v, %v, %v", updateErr, getErr, removeErr)
+	}
+
+	if updated.Email != "new@mail.ru" {
+		t.Errorf("stale email served: %s", updated.Email)
+	}
+
+	if !errors.Is(removedErr, database.ErrSessionNotFound) {
+		t.Errorf("removed session still served: %v", removedErr)
+	}
+}
+
+func TestSessionCacheBounds(t *testing.T) {
+	// arrange
+	caches := newCachedInstances(t, 1, 1, 50 * time.Millisecond)
+	cache := caches[0]
+	ctx := context.Background()
+	session := &domain.Session{UserId: uuid.New()}
+
+	first, _ := cache.Save(ctx, session, time.Hour)
+	second, _ := cache.Save(ctx, session, time.Hour)
+	evictions := sessionCacheMetric("evictions")
+
+	// act
+	cache.Get(ctx, first)
+	cache.Get(ctx, second)
+	hits := sessionCacheMetric("hits")
+	time.Sleep(100 * time.Millisecond)
+	cache.Get(ctx, second)
+
+	// assert
+	if sessionCacheMetric("evictions") - evictions != 1 {
+		t.Errorf("least recently used session not evicted")
+	}
+
+	if sessionCacheMetric("hits") != hits {
+		t.Errorf("expired entry served from the cache")
+	}
+}
@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestRefreshRotatesToken(t *testing.T) {
+	// arrange
+	var (
+		testUUID = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+		source = domain.Source{IpAddress: "127.0.0.1:8000", UserAgent: "Chrome/137.0.0.0"}
+	)
+
+	user := CreateTestUser("test@test.ru", "123")
+	user.Id = testUUID
+	session := &domain.Session{UserId: testUUID, Email: user.Email, Source: source}
+
+	tests := []struct {
+		name string
+		rotateErr error
+		wantErrIs error
+	}{
+		{name: "Token exchanged", rotateErr: nil},
+		{name: "Token already exchanged by a concurrent refresh", rotateErr: database.ErrSessionNotFound, wantErrIs: database.ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				userProvider = mocks.NewIUserProvider(t)
+				roleProvider = mocks.NewIRoleProvider(t)
+				sessionProvider = mocks.NewISessionProvider(t)
+				sessionSaver = mocks.NewISessionSaver(t)
+			)
+
+			sessionProvider.On("Get", mock.Anything, "refresh").Return(session, nil)
+			userProvider.On("GetById", mock.Anything, testUUID).Return(user, nil)
+			roleProvider.On("GetUserGrants", mock.Anything, testUUID).Return(&domain.Grants{}, nil)
+
+			newToken := ""
+			if tt.rotateErr == nil {
+				newToken = "rotated"
+			}
+			sessionSaver.On("Rotate", mock.Anything, "refresh", session, mock.Anything).Return(newToken, tt.rotateErr).Once()
+
+			// no Delete is expected: the lost rotation must not touch the winner's session
+			authService := services.NewAuthService(
+				userProvider, mocks.NewIUserUpdater(t), mocks.NewIAccountRestorer(t), roleProvider,
+				sessionProvider, sessionSaver, mocks.NewISessionRemover(t),
+				NullLockout(), TestHasher(nil), services.EmailNormalizer{}, services.DefaultAccountPolicy(),
+				NullAuditLog(), NullOutbox(), NullLogger(),
+			)
+
+			// act
+			tokens, err := authService.Refresh(context.Background(), "refresh", source)
+
+			// assert
+			if tt.wantErrIs != nil {
+				if !errors.Is(err, tt.wantErrIs) {
+					t.Errorf("unexpected error kind: want %v, have %v", tt.wantErrIs, err)
+				}
+				return
+			}
+
+			if err != nil || tokens.Refresh != "rotated" || !IsValidJWTStructure(tokens.Access) {
+				t.Errorf("unexpected refresh result: %v, %v", tokens, err)
+			}
+		})
+	}
+}
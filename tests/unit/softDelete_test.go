@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestRestoreAccount(t *testing.T) {
+	// arrange
+	var (
+		testUUID = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+		policy = services.DefaultAccountPolicy()
+		testSource = domain.Source{IpAddress: "127.0.0.1:8000", UserAgent: "Chrome/137.0.0.0"}
+	)
+
+	recentlyDeleted := CreateTestUser("deleted@test.ru", "123")
+	recentlyDeleted.Id = testUUID
+	recentlyDeleted.DeletedAt = time.Now().Add(-time.Hour)
+
+	longDeleted := CreateTestUser("deleted@test.ru", "123")
+	longDeleted.Id = testUUID
+	longDeleted.DeletedAt = time.Now().Add(-policy.DeletionGracePeriod - time.Hour)
+
+	tests := []struct {
+		TestCase
+		wantErrIs error
+	}{
+		{
+			TestCase: TestCase{
+				name: "Restore within grace period",
+				args: Credentials{email: "deleted@test.ru", password: "123"},
+				setupMocks: func(md *MockDependencies) {
+					md.accountRestorer.On("GetDeleted", mock.Anything, "deleted@test.ru").Return(recentlyDeleted, nil)
+					md.accountRestorer.On("Restore", mock.Anything, testUUID).Return(nil).Once()
+					md.sessionProvider.On("GetUserSessions", mock.Anything, testUUID).Return([]*domain.Session{}, nil)
+					md.roleProvider.On("GetUserGrants", mock.Anything, testUUID).Return(&domain.Grants{}, nil)
+					md.sessionSaver.On("Save", mock.Anything, mock.Anything, mock.Anything).Return("refresh", nil)
+				},
+				wantErr: false,
+			},
+		},
+		{
+			TestCase: TestCase{
+				name: "Restore after grace period",
+				args: Credentials{email: "deleted@test.ru", password: "123"},
+				setupMocks: func(md *MockDependencies) {
+					md.accountRestorer.On("GetDeleted", mock.Anything, "deleted@test.ru").Return(longDeleted, nil)
+				},
+				wantErr: true,
+			},
+			wantErrIs: services.ErrInvalidCredentials,
+		},
+		{
+			TestCase: TestCase{
+				name: "Restore with wrong password",
+				args: Credentials{email: "deleted@test.ru", password: "321"},
+				setupMocks: func(md *MockDependencies) {
+					md.accountRestorer.On("GetDeleted", mock.Anything, "deleted@test.ru").Return(recentlyDeleted, nil)
+				},
+				wantErr: true,
+			},
+			wantErrIs: services.ErrInvalidCredentials,
+		},
+	}
+
+	fmt.Println("========== Run restore account unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		creds, ok := tt.args.(Credentials)
+		if !ok {
+			t.Errorf("unexpected args for the test")
+			continue
+		}
+
+		md := &MockDependencies{
+			userProvider: mocks.NewIUserProvider(t),
+			userUpdater: mocks.NewIUserUpdater(t),
+			accountRestorer: mocks.NewIAccountRestorer(t),
+			roleProvider: mocks.NewIRoleProvider(t),
+			sessionProvider: mocks.NewISessionProvider(t),
+			sessionSaver: mocks.NewISessionSaver(t),
+			sessionRemover: mocks.NewISessionRemover(t),
+		}
+
+		tt.setupMocks(md)
+
+		authService := services.NewAuthService(
+			md.userProvider, md.userUpdater, md.accountRestorer, md.roleProvider, md.sessionProvider, md.sessionSaver, md.sessionRemover,
+			NullLockout(), TestHasher(nil), services.EmailNormalizer{}, policy, NullAuditLog(), NullOutbox(), NullLogger(),
+		)
+
+		// act
+		tokens, userId, err := authService.RestoreAccount(context.Background(), creds.email, creds.password, testSource)
+
+		// assert
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+			t.Errorf("unexpected error kind: want %v, have %v", tt.wantErrIs, err)
+		}
+
+		if !tt.wantErr && (tokens.Refresh == "" || *userId != testUUID) {
+			t.Errorf("unexpected login result after restore")
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
+
+func TestLoginReportsPendingDeletion(t *testing.T) {
+	// arrange
+	policy := services.DefaultAccountPolicy()
+
+	deleted := CreateTestUser("deleted@test.ru", "123")
+	deleted.DeletedAt = time.Now().Add(-time.Hour)
+
+	md := &MockDependencies{
+		userProvider: mocks.NewIUserProvider(t),
+		accountRestorer: mocks.NewIAccountRestorer(t),
+	}
+
+	md.userProvider.On("Get", mock.Anything, "deleted@test.ru").Return(nil, database.ErrUserNotFound)
+	md.accountRestorer.On("GetDeleted", mock.Anything, "deleted@test.ru").Return(deleted, nil)
+
+	authService := services.NewAuthService(
+		md.userProvider, mocks.NewIUserUpdater(t), md.accountRestorer, mocks.NewIRoleProvider(t),
+		mocks.NewISessionProvider(t), mocks.NewISessionSaver(t), mocks.NewISessionRemover(t),
+		NullLockout(), TestHasher(nil), services.EmailNormalizer{}, policy, NullAuditLog(), NullOutbox(), NullLogger(),
+	)
+
+	// act
+	_, _, err := authService.Login(context.Background(), "deleted@test.ru", "123", domain.Source{})
+
+	// assert
+	var pendingErr *services.PendingDeletionError
+	if !errors.As(err, &pendingErr) {
+		t.Fatalf("expected pending deletion error, have %v", err)
+	}
+
+	if want := deleted.DeletedAt.Add(policy.DeletionGracePeriod); !pendingErr.PurgeAt.Equal(want) {
+		t.Errorf("unexpected purge date: want %v, have %v", want, pendingErr.PurgeAt)
+	}
+}
+
+func TestUnregisterSoftDeletes(t *testing.T) {
+	// arrange
+	testUUID := uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+
+	var (
+		userRemover = mocks.NewIUserRemover(t)
+		sessionProvider = mocks.NewISessionProvider(t)
+		sessionRemover = mocks.NewISessionRemover(t)
+	)
+
+	sessionProvider.On("Get", mock.Anything, "refresh").Return(&domain.Session{UserId: testUUID}, nil)
+	sessionRemover.On("DeleteUserSessions", mock.Anything, testUUID).Return(nil).Once()
+	userRemover.On("SoftDelete", mock.Anything, testUUID).Return(nil).Once()
+
+	registrar := services.NewRegistrarService(
+		mocks.NewIUserSaver(t), userRemover, sessionRemover, sessionProvider,
+		TestHasher(nil), services.EmailNormalizer{}, NullAuditLog(), NullOutbox(), NullLogger(),
+	)
+
+	// act
+	err := registrar.Unregister(context.Background(), testUUID, "refresh", domain.Source{})
+
+	// assert
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAccountPurger(t *testing.T) {
+	// arrange
+	policy := services.DefaultAccountPolicy()
+	userPurger := mocks.NewIUserPurger(t)
+
+	userPurger.
+		On("PurgeDeleted", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+			cutoff := time.Now().Add(-policy.DeletionGracePeriod)
+			return before.Sub(cutoff).Abs() < time.Minute
+		})).
+		Return(int64(2), nil).
+		Once()
+
+	purger := services.NewAccountPurger(userPurger, policy, NullLogger())
+
+	// act
+	purged, err := purger.PurgeOnce(context.Background())
+
+	// assert
+	if err != nil || purged != 2 {
+		t.Errorf("unexpected purge result: %d, %v", purged, err)
+	}
+}
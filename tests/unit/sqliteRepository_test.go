@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/database"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+)
+
+func sqliteRepository(t *testing.T) *database.SQLiteUserRepository {
+	t.Helper()
+
+	conf := &database.Config{Driver: database.DriverSQLite, DBName: filepath.Join(t.TempDir(), "auth.db")}
+
+	m, err := database.NewMigrator(conf)
+	if err != nil {
+		t.Fatalf("migrator creation failed: %v", err)
+	}
+
+	if err = m.Apply(); err != nil {
+		t.Fatalf("migrations apply failed: %v", err)
+	}
+
+	repository, err := database.NewSQLiteUserRepository(conf)
+	if err != nil {
+		t.Fatalf("repository creation failed: %v", err)
+	}
+	t.Cleanup(repository.Exit)
+
+	return repository
+}
+
+func sqliteTestUser(email, phone string) domain.User {
+	user := *CreateTestUser(email, "123")
+	user.Id = uuid.New()
+	user.FullName = "Test User"
+	user.Phone = phone
+	user.BirthDate = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	user.RegisterDate = time.Now()
+	user.PasswordHash = []byte("hash")
+	return user
+}
+
+func TestSQLiteUserRepositoryErrors(t *testing.T) {
+	// arrange
+	repository := sqliteRepository(t)
+	ctx := context.Background()
+
+	user := sqliteTestUser("Test@test.ru", "+79991234567")
+	if err := repository.Save(ctx, user); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	other := sqliteTestUser("other@test.ru", "+79997654321")
+	if err := repository.Save(ctx, other); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	badPhone := sqliteTestUser("third@test.ru", "12345")
+
+	// act
+	duplicateErr := repository.Save(ctx, sqliteTestUser("test@TEST.ru", "+79990000000"))
+	phoneErr := repository.Save(ctx, badPhone)
+	_, takenErr := repository.UpdateProfile(ctx, other.Id, domain.ProfileUpdate{Mask: []string{domain.ProfileFieldPhone}, Phone: user.Phone})
+	updated, updateErr := repository.UpdateProfile(ctx, other.Id, domain.ProfileUpdate{Mask: []string{domain.ProfileFieldFullName}, FullName: "Renamed", ExpectedVersion: 1})
+
+	// assert
+	if !errors.Is(duplicateErr, database.ErrUserAlreadyExists) {
+		t.Errorf("case-insensitive email duplicate accepted: %v", duplicateErr)
+	}
+
+	var constraintErr *database.ConstraintError
+	if !errors.As(phoneErr, &constraintErr) || constraintErr.Column != "phone" || !errors.Is(phoneErr, database.ErrCheckViolation) {
+		t.Errorf("malformed phone accepted: %v", phoneErr)
+	}
+
+	if !errors.Is(takenErr, database.ErrPhoneAlreadyTaken) {
+		t.Errorf("taken phone accepted: %v", takenErr)
+	}
+
+	if updateErr != nil || updated.FullName != "Renamed" || updated.Version != 2 {
+		t.Errorf("unexpected profile update result: %v, %v", updated, updateErr)
+	}
+
+	found, err := repository.Get(ctx, "TEST@test.ru")
+	if err != nil || found.Id != user.Id || !found.BirthDate.Equal(user.BirthDate) || found.Status != domain.UserStatusActive {
+		t.Errorf("unexpected stored user: %+v, %v", found, err)
+	}
+}
+
+func TestSQLiteOutboxClaimsInOrder(t *testing.T) {
+	// arrange
+	repository := sqliteRepository(t)
+	ctx := context.Background()
+
+	first, second := sqliteTestUser("first@test.ru", "+79991111111"), sqliteTestUser("second@test.ru", "+79992222222")
+	for _, user := range []domain.User{first, second} {
+		if err := repository.Save(ctx, user); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// act
+	claimed, claimErr := repository.ClaimEvents(ctx, 10, time.Minute)
+	again, againErr := repository.ClaimEvents(ctx, 10, time.Minute)
+
+	// assert
+	if claimErr != nil || len(claimed) != 2 || claimed[0].UserId != first.Id || claimed[1].UserId != second.Id {
+		t.Fatalf("unexpected claimed events: %v, %v", claimed, claimErr)
+	}
+
+	if againErr != nil || len(again) != 0 {
+		t.Errorf("leased events claimed again: %v, %v", again, againErr)
+	}
+
+	if err := repository.MarkPublished(ctx, []uuid.UUID{claimed[0].Id, claimed[1].Id}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/interceptors"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestReauthenticate(t *testing.T) {
+	// arrange
+	testUUID := uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+
+	user := CreateTestUser("test@test.ru", "123")
+	user.Id = testUUID
+
+	tests := []struct {
+		TestCase
+		wantErrIs error
+	}{
+		{
+			TestCase: TestCase{
+				name: "Elevated token issued",
+				args: "123",
+				setupMocks: func(md *MockDependencies) {
+					md.userProvider.On("GetById", mock.Anything, testUUID).Return(user, nil)
+					md.roleProvider.On("GetUserGrants", mock.Anything, testUUID).Return(&domain.Grants{}, nil)
+				},
+				wantErr: false,
+			},
+		},
+		{
+			TestCase: TestCase{
+				name: "Wrong password",
+				args: "321",
+				setupMocks: func(md *MockDependencies) {
+					md.userProvider.On("GetById", mock.Anything, testUUID).Return(user, nil)
+				},
+				wantErr: true,
+			},
+			wantErrIs: services.ErrInvalidCredentials,
+		},
+	}
+
+	fmt.Println("========== Run reauthenticate unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		password, ok := tt.args.(string)
+		if !ok {
+			t.Errorf("unexpected args for the test")
+			continue
+		}
+
+		md := &MockDependencies{
+			userProvider: mocks.NewIUserProvider(t),
+			roleProvider: mocks.NewIRoleProvider(t),
+		}
+
+		tt.setupMocks(md)
+
+		authService := services.NewAuthService(
+			md.userProvider, mocks.NewIUserUpdater(t), mocks.NewIAccountRestorer(t), md.roleProvider,
+			mocks.NewISessionProvider(t), mocks.NewISessionSaver(t), mocks.NewISessionRemover(t),
+			NullLockout(), TestHasher(nil), services.EmailNormalizer{}, services.DefaultAccountPolicy(), NullAuditLog(), NullOutbox(), NullLogger(),
+		)
+
+		// act
+		token, _, err := authService.Reauthenticate(context.Background(), testUUID, password, domain.Source{})
+
+		// assert
+		if (err != nil) != tt.wantErr {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+			t.Errorf("unexpected error kind: want %v, have %v", tt.wantErrIs, err)
+		}
+
+		if !tt.wantErr {
+			parsed, err := services.VerifyJWT(token)
+			if err != nil || !services.IsElevated(parsed) {
+				t.Errorf("expected a valid elevated token, have %v", err)
+			}
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
+
+func TestStepUpInterceptor(t *testing.T) {
+	// arrange
+	const method = "/profile.ProfileService/Unregister"
+
+	user := CreateTestUser("test@test.ru", "123")
+	user.Id = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+
+	freshToken, _ := services.CreateJWT(user, time.Now(), domain.Grants{})
+	staleToken, _ := services.CreateJWT(user, time.Now().Add(-time.Hour), domain.Grants{})
+	elevatedToken, _ := services.CreateElevatedJWT(user, domain.Grants{}, time.Minute)
+
+	tests := []struct {
+		name string
+		authorization string
+		wantCode codes.Code
+	}{
+		{name: "Fresh login", authorization: "Bearer " + freshToken, wantCode: codes.OK},
+		{name: "Elevated token", authorization: "Bearer " + elevatedToken, wantCode: codes.OK},
+		{name: "Stale login", authorization: "Bearer " + staleToken, wantCode: codes.Unauthenticated},
+		{name: "No access token", authorization: "", wantCode: codes.Unauthenticated},
+	}
+
+	interceptor := interceptors.StepUpInterceptor(map[string]struct{}{method: {}}, time.Minute * 10)
+
+	fmt.Println("========== Run step-up interceptor unit test ==========")
+
+	for i, tt := range tests {
+		fmt.Printf("[test #%d - %s]\n", i, tt.name)
+
+		md := metadata.Pairs("refreshToken", "refresh")
+		if tt.authorization != "" {
+			md.Append("authorization", tt.authorization)
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+
+		var handledUserId any
+		handler := func(ctx context.Context, req any) (any, error) {
+			handledUserId = ctx.Value("userId")
+			return nil, nil
+		}
+
+		// act
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+
+		// assert
+		if code := status.Code(err); code != tt.wantCode {
+			t.Errorf("unexpected status: want %s, have %s", tt.wantCode, code)
+		}
+
+		if tt.wantCode == codes.OK && handledUserId != user.Id {
+			t.Errorf("unexpected user id in context: %v", handledUserId)
+		}
+
+		fmt.Println("PASSED!")
+	}
+}
+
+func TestUnregisterRejectsForeignSession(t *testing.T) {
+	// arrange
+	var (
+		ownerId = uuid.MustParse("f47ac10b-58cc-4372-a567-0e02b2c3d479")
+		callerId = uuid.New()
+		sessionProvider = mocks.NewISessionProvider(t)
+	)
+
+	sessionProvider.On("Get", mock.Anything, "stolen").Return(&domain.Session{UserId: ownerId}, nil)
+
+	registrar := services.NewRegistrarService(
+		mocks.NewIUserSaver(t), mocks.NewIUserRemover(t), mocks.NewISessionRemover(t), sessionProvider,
+		TestHasher(nil), services.EmailNormalizer{}, NullAuditLog(), NullOutbox(), NullLogger(),
+	)
+
+	// act
+	err := registrar.Unregister(context.Background(), callerId, "stolen", domain.Source{})
+
+	// assert
+	if !errors.Is(err, services.ErrSessionMismatch) {
+		t.Errorf("expected session mismatch, have %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/tests/unit/mocks"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+func TestSessionSweeper(t *testing.T) {
+	// arrange
+	pruner := mocks.NewISessionPruner(t)
+	pruner.On("PruneExpiredSessions", mock.Anything).Return(int64(3), nil).Once()
+	pruner.On("PruneExpiredSessions", mock.Anything).Return(int64(1), errors.New("redis is unavailable")).Once()
+
+	sweeper := services.NewSessionSweeper(pruner, services.DefaultAccountPolicy(), NullLogger())
+
+	// act
+	first, firstErr := sweeper.SweepOnce(context.Background())
+	second, secondErr := sweeper.SweepOnce(context.Background())
+
+	// assert
+	if first != 3 || firstErr != nil {
+		t.Errorf("unexpected sweep result: %d, %v", first, firstErr)
+	}
+
+	// a sweep interrupted halfway still reports what it pruned
+	if second != 1 || secondErr == nil {
+		t.Errorf("unexpected failed sweep result: %d, %v", second, secondErr)
+	}
+}
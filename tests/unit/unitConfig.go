@@ -7,6 +7,14 @@ import (
 type MockDependencies struct {
 	userProvider   	*mocks.IUserProvider
 	userSaver		*mocks.IUserSaver
+	userUpdater		*mocks.IUserUpdater
+	accountRestorer	*mocks.IAccountRestorer
+	roleProvider	*mocks.IRoleProvider
+	profileUpdater	*mocks.IProfileUpdater
+	emailUpdater	*mocks.IEmailUpdater
+	sessionUpdater	*mocks.ISessionUpdater
+	tokenStorage	*mocks.ITokenStorage
+	mailer			*mocks.IMailer
 	sessionProvider *mocks.ISessionProvider
 	sessionSaver 	*mocks.ISessionSaver
 	sessionRemover 	*mocks.ISessionRemover
@@ -1,11 +1,15 @@
 package unit
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/domain"
+	"github.com/nikita-itmo-gh-acc/car_estimator_authorization/services"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -30,3 +34,50 @@ func IsValidJWTStructure(tokenString string) bool {
 func NullLogger() *slog.Logger {
     return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
+
+func TestHasher(conf *services.HasherConfig) *services.PasswordHasher {
+    hasher, err := services.NewPasswordHasher(conf)
+    if err != nil {
+        panic(err)
+    }
+    return hasher
+}
+
+type discardOutbox struct{}
+
+func (discardOutbox) AppendEvent(ctx context.Context, event domain.Event) error {
+    return nil
+}
+
+func NullOutbox() services.IEventOutbox {
+    return discardOutbox{}
+}
+
+type discardAuditLog struct{}
+
+func (discardAuditLog) Record(ctx context.Context, event domain.AuditEvent) error {
+    return nil
+}
+
+func NullAuditLog() services.IAuditLog {
+    return discardAuditLog{}
+}
+
+// discardLockout never counts a failed login, so no account gets locked.
+type discardLockout struct{}
+
+func (discardLockout) FailedLogins(ctx context.Context, userId uuid.UUID) (int, time.Time, error) {
+    return 0, time.Time{}, nil
+}
+
+func (discardLockout) RecordFailedLogin(ctx context.Context, userId uuid.UUID, window time.Duration) (int, time.Time, error) {
+    return 0, time.Time{}, nil
+}
+
+func (discardLockout) ResetFailedLogins(ctx context.Context, userId uuid.UUID) error {
+    return nil
+}
+
+func NullLockout() services.ILoginLockout {
+    return discardLockout{}
+}